@@ -28,9 +28,31 @@ type Expression interface {
 	expressionNode()
 }
 
+// StartOf returns the first token of stmt, which gives the position of the
+// statement in the source
+func StartOf(stmt Statement) token.Token {
+	switch stmt := stmt.(type) {
+	case *ExpressionStatement:
+		// The statement token is the first token of the expression, even when
+		// the tree starts elsewhere, like infix expressions do
+		return stmt.Token
+	case *LetStatement:
+		return stmt.Token
+	case *ReturnStatement:
+		return stmt.Token
+	case *ImportStatement:
+		return stmt.Token
+	case *BlockStatement:
+		return stmt.Token
+	default:
+		return token.Token{}
+	}
+}
+
 // Program is the top level struct that holds all the other nodes
 type Program struct {
 	Statements []Statement
+	Comments   []token.Token // The comments of the source, in order
 }
 
 func (p *Program) TokenLiteral() string {
@@ -50,9 +72,10 @@ func (p *Program) String() string {
 }
 
 type LetStatement struct {
-	Token token.Token // For the LET token
-	Name  *Identifier
-	Value Expression
+	Token    token.Token // For the LET token
+	Name     *Identifier
+	Value    Expression
+	Exported bool // Whether the binding is visible to importers of the module
 }
 
 func (ls *LetStatement) statementNode() {}
@@ -62,6 +85,9 @@ func (ls *LetStatement) TokenLiteral() string {
 
 func (ls *LetStatement) String() string {
 	var out bytes.Buffer
+	if ls.Exported {
+		out.WriteString("export ")
+	}
 	out.WriteString(ls.TokenLiteral() + " ")
 	out.WriteString(ls.Name.String())
 	out.WriteString(" = ")
@@ -94,6 +120,39 @@ func (rs *ReturnStatement) String() string {
 	return out.String()
 }
 
+// ImportStatement binds a module either to Alias, as in
+// `import "lib.mk" as lib;`, or binds the listed Names directly, as in
+// `import { add, sub } from "lib.mk";`
+type ImportStatement struct {
+	Token token.Token // For the IMPORT token
+	Path  *StringLiteral
+	Alias *Identifier
+	Names []*Identifier
+}
+
+func (is *ImportStatement) statementNode()       {}
+func (is *ImportStatement) TokenLiteral() string { return is.Token.Literal }
+func (is *ImportStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(is.TokenLiteral() + " ")
+	if is.Alias != nil {
+		out.WriteString(`"` + is.Path.Value + `" as `)
+		out.WriteString(is.Alias.String())
+	} else {
+		names := functools.Map(is.Names, func(x *Identifier) string { return x.String() })
+		if len(names) == 0 {
+			out.WriteString("{}")
+		} else {
+			out.WriteString("{ " + strings.Join(names, ", ") + " }")
+		}
+		out.WriteString(` from "` + is.Path.Value + `"`)
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
 type ExpressionStatement struct {
 	Token      token.Token
 	Expression Expression
@@ -134,6 +193,15 @@ func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+
 type Boolean struct {
 	Token token.Token
 	Value bool
@@ -211,6 +279,7 @@ func (ife *IfExpression) String() string {
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
+	End        token.Token // The closing '}'
 }
 
 func (bs *BlockStatement) statementNode()       {}
@@ -253,6 +322,7 @@ func (fn *FunctionLiteral) String() string {
 type ArrayLiteral struct {
 	Token    token.Token
 	Elements []Expression
+	End      token.Token // The closing ']'
 }
 
 func (al *ArrayLiteral) expressionNode()      {}
@@ -275,6 +345,8 @@ func (al *ArrayLiteral) String() string {
 type HashLiteral struct {
 	Token token.Token
 	Pairs map[Expression]Expression
+	Keys  []Expression // The keys of Pairs, in source order
+	End   token.Token  // The closing '}'
 }
 
 func (hl *HashLiteral) expressionNode()      {}
@@ -283,8 +355,8 @@ func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 	pairs := []string{}
 
-	for key, value := range hl.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, key := range hl.Keys {
+		pairs = append(pairs, key.String()+":"+hl.Pairs[key].String())
 	}
 
 	out.WriteString("{")
@@ -298,6 +370,7 @@ type CallExpression struct {
 	Token     token.Token
 	Function  Expression
 	Arguments []Expression
+	End       token.Token // The closing ')'
 }
 
 func (ce *CallExpression) expressionNode()      {}
@@ -339,6 +412,57 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// SliceExpression takes a part of an array or string, as in `a[1:3]`. Either
+// bound may be left out.
+type SliceExpression struct {
+	Token token.Token // The '[' token
+	Left  Expression
+	Start Expression // nil when slicing from the start
+	End   Expression // nil when slicing to the end
+}
+
+func (se *SliceExpression) expressionNode()      {}
+func (se *SliceExpression) TokenLiteral() string { return se.Token.Literal }
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString("[")
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	out.WriteString("]")
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// MemberExpression reaches into a namespace, as in `math.abs`
+type MemberExpression struct {
+	Token  token.Token // The '.' token
+	Left   Expression
+	Member *Identifier
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(me.Left.String())
+	out.WriteString(".")
+	out.WriteString(me.Member.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
 type StringLiteral struct {
 	Token token.Token
 	Value string
@@ -0,0 +1,249 @@
+package ast
+
+import "fmt"
+
+// A Visitor's Visit method is called by Walk for every node. When the
+// visitor w it returns is not nil, Walk visits the children of the node with
+// w, followed by a call of w.Visit(nil).
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses the tree below node depth first, in the order the nodes
+// appear in the source. It starts by calling v.Visit(node). Missing nodes,
+// such as the alternative of an if without an else, are not visited.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkList(v, n.Statements)
+	case *LetStatement:
+		walkIdentifier(v, n.Name)
+		walkIf(v, n.Value)
+	case *ReturnStatement:
+		walkIf(v, n.ReturnValue)
+	case *ImportStatement:
+		if n.Alias != nil {
+			walkString(v, n.Path)
+			Walk(v, n.Alias)
+		} else {
+			walkList(v, n.Names)
+			walkString(v, n.Path)
+		}
+	case *ExpressionStatement:
+		walkIf(v, n.Expression)
+	case *BlockStatement:
+		walkList(v, n.Statements)
+	case *PrefixExpression:
+		walkIf(v, n.Right)
+	case *InfixExpression:
+		walkIf(v, n.Left)
+		walkIf(v, n.Right)
+	case *IfExpression:
+		walkIf(v, n.Condition)
+		walkBlock(v, n.Consequence)
+		walkBlock(v, n.Alternative)
+	case *FunctionLiteral:
+		walkList(v, n.Parameter)
+		walkBlock(v, n.Body)
+	case *ArrayLiteral:
+		walkList(v, n.Elements)
+	case *HashLiteral:
+		for _, key := range n.Keys {
+			walkIf(v, key)
+			walkIf(v, n.Pairs[key])
+		}
+	case *CallExpression:
+		walkIf(v, n.Function)
+		walkList(v, n.Arguments)
+	case *IndexExpression:
+		walkIf(v, n.Left)
+		walkIf(v, n.Index)
+	case *SliceExpression:
+		walkIf(v, n.Left)
+		walkIf(v, n.Start)
+		walkIf(v, n.End)
+	case *MemberExpression:
+		walkIf(v, n.Left)
+		walkIdentifier(v, n.Member)
+	}
+
+	v.Visit(nil)
+}
+
+// walkIf walks node unless it is missing. Nodes held in fields of a concrete
+// type are checked by the helpers below, as a nil pointer in an interface is
+// not nil.
+func walkIf(v Visitor, node Node) {
+	if node != nil {
+		Walk(v, node)
+	}
+}
+
+func walkIdentifier(v Visitor, ident *Identifier) {
+	if ident != nil {
+		Walk(v, ident)
+	}
+}
+
+func walkString(v Visitor, str *StringLiteral) {
+	if str != nil {
+		Walk(v, str)
+	}
+}
+
+func walkBlock(v Visitor, block *BlockStatement) {
+	if block != nil {
+		Walk(v, block)
+	}
+}
+
+func walkList[T Node](v Visitor, nodes []T) {
+	for _, node := range nodes {
+		walkIf(v, node)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses the tree below node like Walk, calling f for every node.
+// When f returns false, the children of the node are skipped. After the
+// children of a node, f is called with nil.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Rewrite replaces the nodes of the tree below node bottom-up: the children
+// of a node are rewritten before f is called with the node, and the node f
+// returns takes its place. The tree is changed in place, and the new root is
+// returned.
+//
+// The node f returns must fit where the old one was, such as an expression
+// for an expression or an identifier for the name of a let statement, or
+// Rewrite panics. Returning nil removes a statement or expression from a
+// list, or a pair from a hash literal when returned for its key, and leaves
+// a missing node anywhere else.
+func Rewrite(node Node, f func(Node) Node) Node {
+	if node == nil {
+		return nil
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		n.Statements = rewriteList(n.Statements, f)
+	case *LetStatement:
+		n.Name = rewriteAs[*Identifier](n.Name, f)
+		n.Value = rewriteAs[Expression](n.Value, f)
+	case *ReturnStatement:
+		n.ReturnValue = rewriteAs[Expression](n.ReturnValue, f)
+	case *ImportStatement:
+		n.Path = rewriteAs[*StringLiteral](n.Path, f)
+		n.Alias = rewriteAs[*Identifier](n.Alias, f)
+		n.Names = rewriteList(n.Names, f)
+	case *ExpressionStatement:
+		n.Expression = rewriteAs[Expression](n.Expression, f)
+	case *BlockStatement:
+		n.Statements = rewriteList(n.Statements, f)
+	case *PrefixExpression:
+		n.Right = rewriteAs[Expression](n.Right, f)
+	case *InfixExpression:
+		n.Left = rewriteAs[Expression](n.Left, f)
+		n.Right = rewriteAs[Expression](n.Right, f)
+	case *IfExpression:
+		n.Condition = rewriteAs[Expression](n.Condition, f)
+		n.Consequence = rewriteAs[*BlockStatement](n.Consequence, f)
+		n.Alternative = rewriteAs[*BlockStatement](n.Alternative, f)
+	case *FunctionLiteral:
+		n.Parameter = rewriteList(n.Parameter, f)
+		n.Body = rewriteAs[*BlockStatement](n.Body, f)
+	case *ArrayLiteral:
+		n.Elements = rewriteList(n.Elements, f)
+	case *HashLiteral:
+		pairs := make(map[Expression]Expression, len(n.Pairs))
+		var keys []Expression
+		for _, key := range n.Keys {
+			newKey := rewriteAs[Expression](key, f)
+			value := rewriteAs[Expression](n.Pairs[key], f)
+			if newKey == nil {
+				continue
+			}
+			pairs[newKey] = value
+			keys = append(keys, newKey)
+		}
+		n.Pairs, n.Keys = pairs, keys
+	case *CallExpression:
+		n.Function = rewriteAs[Expression](n.Function, f)
+		n.Arguments = rewriteList(n.Arguments, f)
+	case *IndexExpression:
+		n.Left = rewriteAs[Expression](n.Left, f)
+		n.Index = rewriteAs[Expression](n.Index, f)
+	case *SliceExpression:
+		n.Left = rewriteAs[Expression](n.Left, f)
+		n.Start = rewriteAs[Expression](n.Start, f)
+		n.End = rewriteAs[Expression](n.End, f)
+	case *MemberExpression:
+		n.Left = rewriteAs[Expression](n.Left, f)
+		n.Member = rewriteAs[*Identifier](n.Member, f)
+	}
+
+	return f(node)
+}
+
+// rewriteAs rewrites node, which is missing when it is nil, checking that
+// what replaces it is a T
+func rewriteAs[T Node](node T, f func(Node) Node) T {
+	var zero T
+	if isMissing(node) {
+		return zero
+	}
+	replaced := Rewrite(node, f)
+	if replaced == nil {
+		return zero
+	}
+	t, ok := replaced.(T)
+	if !ok {
+		panic(fmt.Sprintf("ast.Rewrite: cannot replace %T with %T", node, replaced))
+	}
+	return t
+}
+
+// rewriteList rewrites every node of nodes, dropping those replaced by nil
+func rewriteList[T Node](nodes []T, f func(Node) Node) []T {
+	if nodes == nil {
+		return nil
+	}
+	rewritten := nodes[:0]
+	for _, node := range nodes {
+		if t := rewriteAs(node, f); !isMissing(t) {
+			rewritten = append(rewritten, t)
+		}
+	}
+	return rewritten
+}
+
+// isMissing reports whether node is nil, either as an interface or as one of
+// the pointers that every node is
+func isMissing(node Node) bool {
+	switch n := node.(type) {
+	case nil:
+		return true
+	case *Identifier:
+		return n == nil
+	case *StringLiteral:
+		return n == nil
+	case *BlockStatement:
+		return n == nil
+	default:
+		return false
+	}
+}
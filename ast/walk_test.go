@@ -0,0 +1,202 @@
+package ast_test
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/ast"
+	"github.com/waridh/go-monkey-interpreter/lexer"
+	"github.com/waridh/go-monkey-interpreter/parser"
+	"github.com/waridh/go-monkey-interpreter/token"
+)
+
+func parse(t *testing.T, src string) *ast.Program {
+	t.Helper()
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return program
+}
+
+// kinds names the nodes that Inspect visits, in order
+func kinds(node ast.Node) []string {
+	var visited []string
+	ast.Inspect(node, func(node ast.Node) bool {
+		if node != nil {
+			visited = append(visited, strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast."))
+		}
+		return true
+	})
+	return visited
+}
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = -1;", []string{"Program", "LetStatement", "Identifier", "PrefixExpression", "IntegerLiteral"}},
+		{"return a + 2.5;", []string{"Program", "ReturnStatement", "InfixExpression", "Identifier", "FloatLiteral"}},
+		{`import { a } from "m.mk"; import "n.mk" as n;`, []string{
+			"Program", "ImportStatement", "Identifier", "StringLiteral", "ImportStatement", "StringLiteral", "Identifier",
+		}},
+		{"if (true) { x } else { y }", []string{
+			"Program", "ExpressionStatement", "IfExpression", "Boolean",
+			"BlockStatement", "ExpressionStatement", "Identifier",
+			"BlockStatement", "ExpressionStatement", "Identifier",
+		}},
+		{"if (x) { }", []string{"Program", "ExpressionStatement", "IfExpression", "Identifier", "BlockStatement"}},
+		{"fn(a) { a }(1)", []string{
+			"Program", "ExpressionStatement", "CallExpression", "FunctionLiteral", "Identifier",
+			"BlockStatement", "ExpressionStatement", "Identifier", "IntegerLiteral",
+		}},
+		{`{"a": [1], 2: b}`, []string{
+			"Program", "ExpressionStatement", "HashLiteral", "StringLiteral", "ArrayLiteral", "IntegerLiteral",
+			"IntegerLiteral", "Identifier",
+		}},
+		{"a[1]; a[:2]; math.abs", []string{
+			"Program", "ExpressionStatement", "IndexExpression", "Identifier", "IntegerLiteral",
+			"ExpressionStatement", "SliceExpression", "Identifier", "IntegerLiteral",
+			"ExpressionStatement", "MemberExpression", "Identifier", "Identifier",
+		}},
+	}
+
+	for _, tt := range tests {
+		if visited := kinds(parse(t, tt.input)); !reflect.DeepEqual(visited, tt.expected) {
+			t.Errorf("%q - expected %v, got %v", tt.input, tt.expected, visited)
+		}
+	}
+}
+
+func TestInspectSkipsChildren(t *testing.T) {
+	program := parse(t, "let f = fn(a) { a + b }; f(c);")
+	var names []string
+	ast.Inspect(program, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Identifier); ok {
+			names = append(names, ident.Value)
+		}
+		_, fn := node.(*ast.FunctionLiteral)
+		return !fn
+	})
+	if expected := []string{"f", "f", "c"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+// depth checks that every node visited by Walk is followed by a nil once its
+// children are done
+type depth struct {
+	level *int
+	max   *int
+}
+
+func (d depth) Visit(node ast.Node) ast.Visitor {
+	if node == nil {
+		*d.level--
+		return nil
+	}
+	*d.level++
+	*d.max = max(*d.max, *d.level)
+	return d
+}
+
+func TestWalk(t *testing.T) {
+	level, deepest := 0, 0
+	ast.Walk(depth{&level, &deepest}, parse(t, "let x = fn() { [1 + 2] };"))
+	// Program, LetStatement, FunctionLiteral, BlockStatement,
+	// ExpressionStatement, ArrayLiteral, InfixExpression, IntegerLiteral
+	if level != 0 || deepest != 8 {
+		t.Errorf("expected to return to level 0 from 8, got %d from %d", level, deepest)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 1 + 2 * 3;", "let x = 7;"},
+		{"f(2 - 1, [4 * 4]); a[1 + 1];", "f(1, [16])(a[2])"},
+		{"if (x) { 1 + 1; 0; 2 } else { 0 }", "ifx 22else"},
+		{`{1 + 1: 0, "a": 2 * 2}`, `{2:0, a:4}`},
+		{"fn(x) { 0; x + 0 }", "fn(x) (x + 0)"},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		rewritten := ast.Rewrite(program, fold)
+		if rewritten != program {
+			t.Errorf("%q - expected the program to be rewritten in place", tt.input)
+		}
+		if rewritten.String() != tt.expected {
+			t.Errorf("%q - expected %q, got %q", tt.input, tt.expected, rewritten.String())
+		}
+	}
+}
+
+// fold adds, subtracts and multiplies integer literals, and drops statements
+// that are just the integer 0
+func fold(node ast.Node) ast.Node {
+	switch node := node.(type) {
+	case *ast.ExpressionStatement:
+		if isZero(node.Expression) {
+			return nil
+		}
+	case *ast.InfixExpression:
+		left, ok := node.Left.(*ast.IntegerLiteral)
+		right, ok2 := node.Right.(*ast.IntegerLiteral)
+		if !ok || !ok2 {
+			return node
+		}
+		var value int64
+		switch node.Operator {
+		case "+":
+			value = left.Value + right.Value
+		case "-":
+			value = left.Value - right.Value
+		case "*":
+			value = left.Value * right.Value
+		default:
+			return node
+		}
+		literal := fmt.Sprint(value)
+		return &ast.IntegerLiteral{Token: token.Token{Type: token.INT, Literal: literal}, Value: value}
+	}
+	return node
+}
+
+func isZero(expr ast.Expression) bool {
+	integer, ok := expr.(*ast.IntegerLiteral)
+	return ok && integer.Value == 0
+}
+
+func TestRewriteRemoves(t *testing.T) {
+	program := parse(t, `[1, "a", 2]; {"a": 1, 2: "b"}; f("a");`)
+	ast.Rewrite(program, func(node ast.Node) ast.Node {
+		if str, ok := node.(*ast.StringLiteral); ok && str.Value == "a" {
+			return nil
+		}
+		return node
+	})
+	if expected := "[1, 2]{2:b}f()"; program.String() != expected {
+		t.Errorf("expected %q, got %q", expected, program.String())
+	}
+}
+
+func TestRewritePanicsOnMisfits(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "cannot replace *ast.Identifier with *ast.IntegerLiteral") {
+			t.Errorf("expected a panic replacing a let name with an integer, got %v", r)
+		}
+	}()
+	ast.Rewrite(parse(t, "let x = 1;"), func(node ast.Node) ast.Node {
+		if _, ok := node.(*ast.Identifier); ok {
+			return &ast.IntegerLiteral{Value: 1}
+		}
+		return node
+	})
+}
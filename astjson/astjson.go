@@ -0,0 +1,285 @@
+// Package astjson converts Monkey syntax trees to and from JSON, for tools
+// written in other languages.
+//
+// Every node is an object whose "kind" is the name of its ast type, such as
+// "LetStatement", and whose "token" is the token it was parsed from, giving
+// its position:
+//
+//	{"type": "LET", "literal": "let", "line": 1, "column": 1}
+//
+// The other fields of each kind are always present, holding null for
+// missing nodes and lists:
+//
+//	Program              version, statements, comments (tokens)
+//	LetStatement         name, value, exported
+//	ReturnStatement      value
+//	ImportStatement      path, alias, names
+//	ExpressionStatement  expression
+//	BlockStatement       statements, close
+//	Identifier           value
+//	IntegerLiteral       value
+//	FloatLiteral         value
+//	Boolean              value
+//	StringLiteral        value
+//	PrefixExpression     operator, right
+//	InfixExpression      left, operator, right
+//	IfExpression         condition, consequence, alternative
+//	FunctionLiteral      parameters, body
+//	ArrayLiteral         elements, close
+//	HashLiteral          pairs (objects with a key and a value), close
+//	CallExpression       function, arguments, close
+//	IndexExpression      left, index
+//	SliceExpression      left, start, end
+//	MemberExpression     left, member
+//
+// Programs have no token. The close of a node is the token that closes it,
+// such as '}', or null when the source ended before it.
+package astjson
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/waridh/go-monkey-interpreter/ast"
+	"github.com/waridh/go-monkey-interpreter/token"
+)
+
+// Version is the version of the schema, which changes only when a change
+// would break readers of the old one
+const Version = 1
+
+type tokenJSON struct {
+	Type    string `json:"type"`
+	Literal string `json:"literal"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+func encodeToken(tok token.Token) *tokenJSON {
+	return &tokenJSON{Type: string(tok.Type), Literal: tok.Literal, Line: tok.Line, Column: tok.Column}
+}
+
+// encodeClose leaves out closing tokens that were never parsed
+func encodeClose(tok token.Token) *tokenJSON {
+	if tok.Type == "" {
+		return nil
+	}
+	return encodeToken(tok)
+}
+
+type programJSON struct {
+	Kind       string       `json:"kind"`
+	Version    int          `json:"version"`
+	Statements []any        `json:"statements"`
+	Comments   []*tokenJSON `json:"comments"`
+}
+
+type letJSON struct {
+	Kind     string     `json:"kind"`
+	Token    *tokenJSON `json:"token"`
+	Name     any        `json:"name"`
+	Value    any        `json:"value"`
+	Exported bool       `json:"exported"`
+}
+
+type returnJSON struct {
+	Kind  string     `json:"kind"`
+	Token *tokenJSON `json:"token"`
+	Value any        `json:"value"`
+}
+
+type importJSON struct {
+	Kind  string     `json:"kind"`
+	Token *tokenJSON `json:"token"`
+	Path  any        `json:"path"`
+	Alias any        `json:"alias"`
+	Names []any      `json:"names"`
+}
+
+type expressionStatementJSON struct {
+	Kind       string     `json:"kind"`
+	Token      *tokenJSON `json:"token"`
+	Expression any        `json:"expression"`
+}
+
+type blockJSON struct {
+	Kind       string     `json:"kind"`
+	Token      *tokenJSON `json:"token"`
+	Statements []any      `json:"statements"`
+	Close      *tokenJSON `json:"close"`
+}
+
+type valueJSON struct {
+	Kind  string     `json:"kind"`
+	Token *tokenJSON `json:"token"`
+	Value any        `json:"value"`
+}
+
+type prefixJSON struct {
+	Kind     string     `json:"kind"`
+	Token    *tokenJSON `json:"token"`
+	Operator string     `json:"operator"`
+	Right    any        `json:"right"`
+}
+
+type infixJSON struct {
+	Kind     string     `json:"kind"`
+	Token    *tokenJSON `json:"token"`
+	Left     any        `json:"left"`
+	Operator string     `json:"operator"`
+	Right    any        `json:"right"`
+}
+
+type ifJSON struct {
+	Kind        string     `json:"kind"`
+	Token       *tokenJSON `json:"token"`
+	Condition   any        `json:"condition"`
+	Consequence any        `json:"consequence"`
+	Alternative any        `json:"alternative"`
+}
+
+type functionJSON struct {
+	Kind       string     `json:"kind"`
+	Token      *tokenJSON `json:"token"`
+	Parameters []any      `json:"parameters"`
+	Body       any        `json:"body"`
+}
+
+type arrayJSON struct {
+	Kind     string     `json:"kind"`
+	Token    *tokenJSON `json:"token"`
+	Elements []any      `json:"elements"`
+	Close    *tokenJSON `json:"close"`
+}
+
+type pairJSON struct {
+	Key   any `json:"key"`
+	Value any `json:"value"`
+}
+
+type hashJSON struct {
+	Kind  string     `json:"kind"`
+	Token *tokenJSON `json:"token"`
+	Pairs []pairJSON `json:"pairs"`
+	Close *tokenJSON `json:"close"`
+}
+
+type callJSON struct {
+	Kind      string     `json:"kind"`
+	Token     *tokenJSON `json:"token"`
+	Function  any        `json:"function"`
+	Arguments []any      `json:"arguments"`
+	Close     *tokenJSON `json:"close"`
+}
+
+type indexJSON struct {
+	Kind  string     `json:"kind"`
+	Token *tokenJSON `json:"token"`
+	Left  any        `json:"left"`
+	Index any        `json:"index"`
+}
+
+type sliceJSON struct {
+	Kind  string     `json:"kind"`
+	Token *tokenJSON `json:"token"`
+	Left  any        `json:"left"`
+	Start any        `json:"start"`
+	End   any        `json:"end"`
+}
+
+type memberJSON struct {
+	Kind   string     `json:"kind"`
+	Token  *tokenJSON `json:"token"`
+	Left   any        `json:"left"`
+	Member any        `json:"member"`
+}
+
+// Marshal encodes program as JSON
+func Marshal(program *ast.Program) ([]byte, error) {
+	return json.Marshal(encode(program))
+}
+
+// MarshalIndent encodes program as JSON, indenting nested values
+func MarshalIndent(program *ast.Program, prefix, indent string) ([]byte, error) {
+	return json.MarshalIndent(encode(program), prefix, indent)
+}
+
+// encode builds the value that node is encoded as, or nil for a missing node
+func encode(node ast.Node) any {
+	// Every node is a pointer, and nil ones are missing
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return nil
+	}
+	switch node := node.(type) {
+	case *ast.Program:
+		var comments []*tokenJSON
+		if node.Comments != nil {
+			comments = make([]*tokenJSON, len(node.Comments))
+		}
+		for i, comment := range node.Comments {
+			comments[i] = encodeToken(comment)
+		}
+		return programJSON{"Program", Version, encodeAll(node.Statements), comments}
+	case *ast.LetStatement:
+		return letJSON{"LetStatement", encodeToken(node.Token), encode(node.Name), encode(node.Value), node.Exported}
+	case *ast.ReturnStatement:
+		return returnJSON{"ReturnStatement", encodeToken(node.Token), encode(node.ReturnValue)}
+	case *ast.ImportStatement:
+		return importJSON{"ImportStatement", encodeToken(node.Token), encode(node.Path), encode(node.Alias), encodeAll(node.Names)}
+	case *ast.ExpressionStatement:
+		return expressionStatementJSON{"ExpressionStatement", encodeToken(node.Token), encode(node.Expression)}
+	case *ast.BlockStatement:
+		return blockJSON{"BlockStatement", encodeToken(node.Token), encodeAll(node.Statements), encodeClose(node.End)}
+	case *ast.Identifier:
+		return valueJSON{"Identifier", encodeToken(node.Token), node.Value}
+	case *ast.IntegerLiteral:
+		return valueJSON{"IntegerLiteral", encodeToken(node.Token), node.Value}
+	case *ast.FloatLiteral:
+		return valueJSON{"FloatLiteral", encodeToken(node.Token), node.Value}
+	case *ast.Boolean:
+		return valueJSON{"Boolean", encodeToken(node.Token), node.Value}
+	case *ast.StringLiteral:
+		return valueJSON{"StringLiteral", encodeToken(node.Token), node.Value}
+	case *ast.PrefixExpression:
+		return prefixJSON{"PrefixExpression", encodeToken(node.Token), node.Operator, encode(node.Right)}
+	case *ast.InfixExpression:
+		return infixJSON{"InfixExpression", encodeToken(node.Token), encode(node.Left), node.Operator, encode(node.Right)}
+	case *ast.IfExpression:
+		return ifJSON{"IfExpression", encodeToken(node.Token), encode(node.Condition), encode(node.Consequence), encode(node.Alternative)}
+	case *ast.FunctionLiteral:
+		return functionJSON{"FunctionLiteral", encodeToken(node.Token), encodeAll(node.Parameter), encode(node.Body)}
+	case *ast.ArrayLiteral:
+		return arrayJSON{"ArrayLiteral", encodeToken(node.Token), encodeAll(node.Elements), encodeClose(node.End)}
+	case *ast.HashLiteral:
+		var pairs []pairJSON
+		if node.Keys != nil {
+			pairs = make([]pairJSON, len(node.Keys))
+		}
+		for i, key := range node.Keys {
+			pairs[i] = pairJSON{encode(key), encode(node.Pairs[key])}
+		}
+		return hashJSON{"HashLiteral", encodeToken(node.Token), pairs, encodeClose(node.End)}
+	case *ast.CallExpression:
+		return callJSON{"CallExpression", encodeToken(node.Token), encode(node.Function), encodeAll(node.Arguments), encodeClose(node.End)}
+	case *ast.IndexExpression:
+		return indexJSON{"IndexExpression", encodeToken(node.Token), encode(node.Left), encode(node.Index)}
+	case *ast.SliceExpression:
+		return sliceJSON{"SliceExpression", encodeToken(node.Token), encode(node.Left), encode(node.Start), encode(node.End)}
+	case *ast.MemberExpression:
+		return memberJSON{"MemberExpression", encodeToken(node.Token), encode(node.Left), encode(node.Member)}
+	default:
+		return nil
+	}
+}
+
+// encodeAll encodes a list of nodes, keeping nil lists apart from empty ones
+func encodeAll[T ast.Node](nodes []T) []any {
+	if nodes == nil {
+		return nil
+	}
+	encoded := make([]any, len(nodes))
+	for i, node := range nodes {
+		encoded[i] = encode(node)
+	}
+	return encoded
+}
@@ -0,0 +1,117 @@
+package astjson
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/ast"
+	"github.com/waridh/go-monkey-interpreter/lexer"
+	"github.com/waridh/go-monkey-interpreter/parser"
+)
+
+func parse(t *testing.T, src string) *ast.Program {
+	t.Helper()
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	return program
+}
+
+func TestMarshal(t *testing.T) {
+	data, err := Marshal(parse(t, "// sum\nlet x = -a + 2.5;"))
+	if err != nil {
+		t.Fatalf("Marshal failed: %s", err)
+	}
+	expected := `{"kind":"Program","version":1,"statements":[` +
+		`{"kind":"LetStatement","token":{"type":"LET","literal":"let","line":2,"column":1},` +
+		`"name":{"kind":"Identifier","token":{"type":"IDENT","literal":"x","line":2,"column":5},"value":"x"},` +
+		`"value":{"kind":"InfixExpression","token":{"type":"+","literal":"+","line":2,"column":12},` +
+		`"left":{"kind":"PrefixExpression","token":{"type":"-","literal":"-","line":2,"column":9},"operator":"-",` +
+		`"right":{"kind":"Identifier","token":{"type":"IDENT","literal":"a","line":2,"column":10},"value":"a"}},` +
+		`"operator":"+",` +
+		`"right":{"kind":"FloatLiteral","token":{"type":"FLOAT","literal":"2.5","line":2,"column":14},"value":2.5}},` +
+		`"exported":false}],` +
+		`"comments":[{"type":"COMMENT","literal":"// sum","line":1,"column":1}]}`
+	if string(data) != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, data)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []string{
+		"",
+		"let x = 5; // five\nreturn x;",
+		`import "lib.mk" as lib; import { a, b } from "other.mk"; export let c = lib.add(a, b);`,
+		"let f = fn(x, y) { if (x > y) { x } else { y } }; f(1, 2);",
+		"fn() {}; if (true) { return 1; }",
+		`let h = {"a": [1, 2.5, true], 2: {}, false: "s"}; h["a"][0:1]; h["a"][:]; h["a"][1:]; h[2];`,
+		"!(-a * (b + c)) == false",
+		"[]",
+		"{}",
+	}
+
+	for _, src := range tests {
+		program := parse(t, src)
+		data, err := Marshal(program)
+		if err != nil {
+			t.Fatalf("%q - Marshal failed: %s", src, err)
+		}
+		decoded, err := Unmarshal(data)
+		if err != nil {
+			t.Fatalf("%q - Unmarshal failed: %s", src, err)
+		}
+		if decoded.String() != program.String() {
+			t.Errorf("%q - expected %q, got %q", src, program.String(), decoded.String())
+		}
+		again, err := Marshal(decoded)
+		if err != nil {
+			t.Fatalf("%q - Marshal failed: %s", src, err)
+		}
+		if !bytes.Equal(again, data) {
+			t.Errorf("%q - expected the decoded program to encode the same, got\n%s\nand\n%s", src, data, again)
+		}
+		// Hashes are keyed by their nodes, which are never equal across trees
+		if !strings.Contains(src, "{\"") && !reflect.DeepEqual(decoded, program) {
+			t.Errorf("%q - expected the decoded program to equal the parsed one", src)
+		}
+	}
+}
+
+func TestMarshalIndent(t *testing.T) {
+	data, err := MarshalIndent(parse(t, "x"), "", "  ")
+	if err != nil {
+		t.Fatalf("MarshalIndent failed: %s", err)
+	}
+	if !strings.HasPrefix(string(data), "{\n  \"kind\": \"Program\",\n  \"version\": 1,\n") {
+		t.Errorf("Unexpected indentation in\n%s", data)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[]`, "astjson: json: cannot unmarshal array into Go value of type astjson.fields"},
+		{`{"kind": "Identifier", "version": 1}`, `astjson: expected a Program, got "Identifier"`},
+		{`{"kind": "Program", "version": 2}`, "astjson: unsupported version 2, expected 1"},
+		{`{"kind": "Program", "version": 1, "statements": [{"kind": "Nope"}]}`, `astjson: statements[0](Nope): unknown kind "Nope"`},
+		{`{"kind": "Program", "version": 1, "statements": [{"kind": "Identifier"}]}`,
+			"astjson: statements[0]: expected a statement, got *ast.Identifier"},
+		{`{"kind": "Program", "version": 1, "statements": [{"kind": "ExpressionStatement", "expression": {"kind": "BlockStatement"}}]}`,
+			"astjson: statements[0](ExpressionStatement).expression: expected an expression, got *ast.BlockStatement"},
+		{`{"kind": "Program", "version": 1, "statements": [{"kind": "LetStatement", "name": {"kind": "IntegerLiteral", "value": "1"}}]}`,
+			"astjson: statements[0](LetStatement).name(IntegerLiteral).value: json: cannot unmarshal string into Go value of type int64"},
+	}
+
+	for _, tt := range tests {
+		_, err := Unmarshal([]byte(tt.input))
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("%s - expected %q, got %v", tt.input, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package astjson
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/waridh/go-monkey-interpreter/ast"
+	"github.com/waridh/go-monkey-interpreter/token"
+)
+
+// fields are the fields of a node, not yet decoded
+type fields map[string]json.RawMessage
+
+// decoder keeps the first error it runs into, naming where in the tree it
+// was, so that nodes can be decoded without checking after every field
+type decoder struct {
+	err error
+}
+
+func (d *decoder) fail(path, format string, args ...any) {
+	if d.err == nil {
+		d.err = fmt.Errorf("astjson: %s: %s", path, fmt.Sprintf(format, args...))
+	}
+}
+
+// Unmarshal decodes a program encoded by Marshal. Fields that are left out
+// are decoded as null, false, zero or empty.
+func Unmarshal(data []byte) (*ast.Program, error) {
+	d := &decoder{}
+	var f fields
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("astjson: %w", err)
+	}
+	var kind string
+	var version int
+	d.field(f, "kind", &kind, "program")
+	d.field(f, "version", &version, "program")
+	if d.err != nil {
+		return nil, d.err
+	}
+	if kind != "Program" {
+		return nil, fmt.Errorf("astjson: expected a Program, got %q", kind)
+	}
+	if version != Version {
+		return nil, fmt.Errorf("astjson: unsupported version %d, expected %d", version, Version)
+	}
+
+	program := &ast.Program{Statements: decodeAll(d, f["statements"], "statements", d.statement)}
+	var comments []*tokenJSON
+	d.field(f, "comments", &comments, "comments")
+	if comments != nil {
+		program.Comments = make([]token.Token, len(comments))
+	}
+	for i, comment := range comments {
+		program.Comments[i] = decodeToken(comment)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return program, nil
+}
+
+// field decodes the field called name into value, leaving value as it is
+// when the field is missing
+func (d *decoder) field(f fields, name string, value any, path string) {
+	raw, ok := f[name]
+	if !ok {
+		return
+	}
+	if err := json.Unmarshal(raw, value); err != nil {
+		d.fail(path+"."+name, "%s", err)
+	}
+}
+
+func decodeToken(tok *tokenJSON) token.Token {
+	if tok == nil {
+		return token.Token{}
+	}
+	return token.Token{Type: token.TokenType(tok.Type), Literal: tok.Literal, Line: tok.Line, Column: tok.Column}
+}
+
+// node decodes any node but a program, returning nil for null
+func (d *decoder) node(raw json.RawMessage, path string) ast.Node {
+	if raw == nil || string(raw) == "null" {
+		return nil
+	}
+	var f fields
+	if err := json.Unmarshal(raw, &f); err != nil {
+		d.fail(path, "%s", err)
+		return nil
+	}
+	var kind string
+	var tok, close *tokenJSON
+	d.field(f, "kind", &kind, path)
+	d.field(f, "token", &tok, path)
+	d.field(f, "close", &close, path)
+	path += "(" + kind + ")"
+
+	switch kind {
+	case "LetStatement":
+		node := &ast.LetStatement{
+			Token: decodeToken(tok),
+			Name:  d.identifier(f["name"], path+".name"),
+			Value: d.expression(f["value"], path+".value"),
+		}
+		d.field(f, "exported", &node.Exported, path)
+		return node
+	case "ReturnStatement":
+		return &ast.ReturnStatement{Token: decodeToken(tok), ReturnValue: d.expression(f["value"], path+".value")}
+	case "ImportStatement":
+		node := &ast.ImportStatement{
+			Token: decodeToken(tok),
+			Alias: d.identifier(f["alias"], path+".alias"),
+			Names: decodeAll(d, f["names"], path+".names", d.identifier),
+		}
+		if expr := d.expression(f["path"], path+".path"); expr != nil {
+			str, ok := expr.(*ast.StringLiteral)
+			if !ok {
+				d.fail(path+".path", "expected a StringLiteral, got %T", expr)
+			}
+			node.Path = str
+		}
+		return node
+	case "ExpressionStatement":
+		return &ast.ExpressionStatement{Token: decodeToken(tok), Expression: d.expression(f["expression"], path+".expression")}
+	case "BlockStatement":
+		return &ast.BlockStatement{
+			Token:      decodeToken(tok),
+			Statements: decodeAll(d, f["statements"], path+".statements", d.statement),
+			End:        decodeToken(close),
+		}
+	case "Identifier":
+		node := &ast.Identifier{Token: decodeToken(tok)}
+		d.field(f, "value", &node.Value, path)
+		return node
+	case "IntegerLiteral":
+		node := &ast.IntegerLiteral{Token: decodeToken(tok)}
+		d.field(f, "value", &node.Value, path)
+		return node
+	case "FloatLiteral":
+		node := &ast.FloatLiteral{Token: decodeToken(tok)}
+		d.field(f, "value", &node.Value, path)
+		return node
+	case "Boolean":
+		node := &ast.Boolean{Token: decodeToken(tok)}
+		d.field(f, "value", &node.Value, path)
+		return node
+	case "StringLiteral":
+		node := &ast.StringLiteral{Token: decodeToken(tok)}
+		d.field(f, "value", &node.Value, path)
+		return node
+	case "PrefixExpression":
+		node := &ast.PrefixExpression{Token: decodeToken(tok), Right: d.expression(f["right"], path+".right")}
+		d.field(f, "operator", &node.Operator, path)
+		return node
+	case "InfixExpression":
+		node := &ast.InfixExpression{
+			Token: decodeToken(tok),
+			Left:  d.expression(f["left"], path+".left"),
+			Right: d.expression(f["right"], path+".right"),
+		}
+		d.field(f, "operator", &node.Operator, path)
+		return node
+	case "IfExpression":
+		return &ast.IfExpression{
+			Token:       decodeToken(tok),
+			Condition:   d.expression(f["condition"], path+".condition"),
+			Consequence: d.block(f["consequence"], path+".consequence"),
+			Alternative: d.block(f["alternative"], path+".alternative"),
+		}
+	case "FunctionLiteral":
+		return &ast.FunctionLiteral{
+			Token:     decodeToken(tok),
+			Parameter: decodeAll(d, f["parameters"], path+".parameters", d.identifier),
+			Body:      d.block(f["body"], path+".body"),
+		}
+	case "ArrayLiteral":
+		return &ast.ArrayLiteral{
+			Token:    decodeToken(tok),
+			Elements: decodeAll(d, f["elements"], path+".elements", d.expression),
+			End:      decodeToken(close),
+		}
+	case "HashLiteral":
+		node := &ast.HashLiteral{
+			Token: decodeToken(tok),
+			Pairs: make(map[ast.Expression]ast.Expression),
+			End:   decodeToken(close),
+		}
+		var pairs []fields
+		d.field(f, "pairs", &pairs, path)
+		if pairs != nil {
+			node.Keys = make([]ast.Expression, 0, len(pairs))
+		}
+		for i, pair := range pairs {
+			pairPath := fmt.Sprintf("%s.pairs[%d]", path, i)
+			key := d.expression(pair["key"], pairPath+".key")
+			node.Pairs[key] = d.expression(pair["value"], pairPath+".value")
+			node.Keys = append(node.Keys, key)
+		}
+		return node
+	case "CallExpression":
+		return &ast.CallExpression{
+			Token:     decodeToken(tok),
+			Function:  d.expression(f["function"], path+".function"),
+			Arguments: decodeAll(d, f["arguments"], path+".arguments", d.expression),
+			End:       decodeToken(close),
+		}
+	case "IndexExpression":
+		return &ast.IndexExpression{
+			Token: decodeToken(tok),
+			Left:  d.expression(f["left"], path+".left"),
+			Index: d.expression(f["index"], path+".index"),
+		}
+	case "SliceExpression":
+		return &ast.SliceExpression{
+			Token: decodeToken(tok),
+			Left:  d.expression(f["left"], path+".left"),
+			Start: d.expression(f["start"], path+".start"),
+			End:   d.expression(f["end"], path+".end"),
+		}
+	case "MemberExpression":
+		return &ast.MemberExpression{
+			Token:  decodeToken(tok),
+			Left:   d.expression(f["left"], path+".left"),
+			Member: d.identifier(f["member"], path+".member"),
+		}
+	default:
+		d.fail(path, "unknown kind %q", kind)
+		return nil
+	}
+}
+
+func (d *decoder) statement(raw json.RawMessage, path string) ast.Statement {
+	node := d.node(raw, path)
+	if node == nil {
+		return nil
+	}
+	stmt, ok := node.(ast.Statement)
+	if !ok {
+		d.fail(path, "expected a statement, got %T", node)
+	}
+	return stmt
+}
+
+func (d *decoder) expression(raw json.RawMessage, path string) ast.Expression {
+	node := d.node(raw, path)
+	if node == nil {
+		return nil
+	}
+	expr, ok := node.(ast.Expression)
+	if !ok {
+		d.fail(path, "expected an expression, got %T", node)
+	}
+	return expr
+}
+
+func (d *decoder) identifier(raw json.RawMessage, path string) *ast.Identifier {
+	node := d.node(raw, path)
+	if node == nil {
+		return nil
+	}
+	ident, ok := node.(*ast.Identifier)
+	if !ok {
+		d.fail(path, "expected an Identifier, got %T", node)
+	}
+	return ident
+}
+
+func (d *decoder) block(raw json.RawMessage, path string) *ast.BlockStatement {
+	node := d.node(raw, path)
+	if node == nil {
+		return nil
+	}
+	block, ok := node.(*ast.BlockStatement)
+	if !ok {
+		d.fail(path, "expected a BlockStatement, got %T", node)
+	}
+	return block
+}
+
+// decodeAll decodes a list of nodes with decode, keeping null lists apart
+// from empty ones
+func decodeAll[T any](d *decoder, raw json.RawMessage, path string, decode func(json.RawMessage, string) T) []T {
+	var raws []json.RawMessage
+	if raw != nil {
+		if err := json.Unmarshal(raw, &raws); err != nil {
+			d.fail(path, "%s", err)
+			return nil
+		}
+	}
+	if raws == nil {
+		return nil
+	}
+	nodes := make([]T, len(raws))
+	for i, raw := range raws {
+		nodes[i] = decode(raw, fmt.Sprintf("%s[%d]", path, i))
+	}
+	return nodes
+}
@@ -0,0 +1,149 @@
+// Package coverage records which statements of a Monkey program ran, and
+// which way each if expression went, and reports it as a summary, in LCOV
+// format or as an HTML page.
+package coverage
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/waridh/go-monkey-interpreter/ast"
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+// Coverage is an object.Observer counting how often each statement runs, and
+// how often each if expression takes either branch. An if without an else
+// still has two branches, the second being taken when the condition fails.
+type Coverage struct {
+	main   string
+	files  []*File
+	module string // The module being imported, if any
+
+	statements map[ast.Statement]*Statement
+	conditions map[ast.Expression]*Branch
+	blocks     map[*ast.BlockStatement]*Branch
+}
+
+// File is the coverage of one source file
+type File struct {
+	Path       string
+	Statements []*Statement // In order of position
+	Branches   []*Branch
+}
+
+type Statement struct {
+	Line, Column int
+	Count        int
+}
+
+// Branch is an if expression, and how often its condition was evaluated and
+// its consequence taken. The alternative was taken the rest of the times.
+type Branch struct {
+	Line, Column int
+	Evaluated    int
+	Then         int
+}
+
+// Else is how often the alternative of the if expression was taken
+func (b *Branch) Else() int {
+	return b.Evaluated - b.Then
+}
+
+// New records the coverage of the program in the file at path, and of the
+// modules it imports, which are found next to it
+func New(path string) *Coverage {
+	return &Coverage{
+		main:       path,
+		statements: make(map[ast.Statement]*Statement),
+		conditions: make(map[ast.Expression]*Branch),
+		blocks:     make(map[*ast.BlockStatement]*Branch),
+	}
+}
+
+// Files lists the files that were evaluated, the main file first
+func (c *Coverage) Files() []*File {
+	return c.files
+}
+
+func (c *Coverage) Enter(node ast.Node, env *object.Environment) {
+	switch node := node.(type) {
+	case *ast.Program:
+		path := c.main
+		if len(c.files) != 0 {
+			path = filepath.Join(filepath.Dir(c.main), filepath.FromSlash(c.module))
+		}
+		c.add(path, node)
+	case *ast.ImportStatement:
+		c.module = object.ModuleName(env.Module(), node.Path.Value)
+	case *ast.BlockStatement:
+		if branch, ok := c.blocks[node]; ok {
+			branch.Then++
+		}
+	}
+	if stmt, ok := node.(ast.Statement); ok {
+		if s, ok := c.statements[stmt]; ok {
+			s.Count++
+		}
+	}
+}
+
+func (c *Coverage) Exit(node ast.Node, result object.Object) {
+	expr, ok := node.(ast.Expression)
+	if !ok {
+		return
+	}
+	if branch, ok := c.conditions[expr]; ok {
+		if _, failed := result.(*object.Error); !failed {
+			branch.Evaluated++
+		}
+	}
+}
+
+func (c *Coverage) Call(fn object.Object, args []object.Object)                    {}
+func (c *Coverage) Return(fn object.Object, result object.Object)                  {}
+func (c *Coverage) Bind(name string, value object.Object, env *object.Environment) {}
+func (c *Coverage) Error(node ast.Node, err *object.Error)                         {}
+
+// add starts recording the statements and branches of program, unless it was
+// already evaluated once
+func (c *Coverage) add(path string, program *ast.Program) {
+	for _, f := range c.files {
+		if f.Path == path {
+			return
+		}
+	}
+	f := &File{Path: path}
+	c.files = append(c.files, f)
+	c.collect(f, program)
+	sort.SliceStable(f.Statements, func(i, j int) bool {
+		return before(f.Statements[i].Line, f.Statements[i].Column, f.Statements[j].Line, f.Statements[j].Column)
+	})
+	sort.SliceStable(f.Branches, func(i, j int) bool {
+		return before(f.Branches[i].Line, f.Branches[i].Column, f.Branches[j].Line, f.Branches[j].Column)
+	})
+}
+
+func before(line, column, otherLine, otherColumn int) bool {
+	return line < otherLine || (line == otherLine && column < otherColumn)
+}
+
+// collect finds the statements and if expressions below node
+func (c *Coverage) collect(f *File, node ast.Node) {
+	ast.Inspect(node, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.BlockStatement:
+			// Blocks are not statements of their own, only what is in them
+		case ast.Statement:
+			tok := ast.StartOf(node)
+			s := &Statement{Line: tok.Line, Column: tok.Column}
+			c.statements[node] = s
+			f.Statements = append(f.Statements, s)
+		case *ast.IfExpression:
+			b := &Branch{Line: node.Token.Line, Column: node.Token.Column}
+			c.conditions[node.Condition] = b
+			c.blocks[node.Consequence] = b
+			f.Branches = append(f.Branches, b)
+		}
+		return true
+	})
+}
@@ -0,0 +1,148 @@
+package coverage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/monkey"
+)
+
+const program = `import { double } from "lib.mk";
+let sign = fn(x) {
+  if (x < 0) {
+    return -1;
+  }
+  if (x == 0) { 0 } else { 1 }
+};
+let unused = fn() { puts("never") };
+sign(2);
+sign(-3);
+double(sign(5));
+`
+
+const lib = `export let double = fn(x) {
+  if (x > 100) { puts("big") }
+  x * 2
+};
+`
+
+// run evaluates the program and library under coverage, returning the
+// coverage and the directory the files are in
+func run(t *testing.T) (*Coverage, string) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.mk")
+	if err := os.WriteFile(main, []byte(program), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "lib.mk"), []byte(lib), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := New(main)
+	if _, err := monkey.New(monkey.WithObserver(c)).EvalFile(main); err != nil {
+		t.Fatalf("EvalFile failed: %s", err)
+	}
+	return c, dir
+}
+
+func TestCoverage(t *testing.T) {
+	c, dir := run(t)
+	files := c.Files()
+	if len(files) != 2 || files[0].Path != filepath.Join(dir, "main.mk") || files[1].Path != filepath.Join(dir, "lib.mk") {
+		t.Fatalf("Unexpected files %+v", files)
+	}
+
+	tests := []struct {
+		file     *File
+		expected Summary
+	}{
+		// Of the 12 statements in main.mk, the consequence of the second if
+		// never runs, nor does the body of unused
+		{files[0], Summary{Statements: 12, StatementsRun: 10, Branches: 4, BranchesTaken: 3}},
+		{files[1], Summary{Statements: 4, StatementsRun: 3, Branches: 2, BranchesTaken: 1}},
+	}
+	for _, tt := range tests {
+		if s := tt.file.Summary(); s != tt.expected {
+			t.Errorf("%s - expected %+v, got %+v", filepath.Base(tt.file.Path), tt.expected, s)
+		}
+	}
+
+	counts := map[int]int{}
+	for _, stmt := range files[0].Statements {
+		counts[stmt.Line] = max(counts[stmt.Line], stmt.Count)
+	}
+	expected := map[int]int{1: 1, 2: 1, 3: 3, 4: 1, 6: 2, 8: 1, 9: 1, 10: 1, 11: 1}
+	for line, count := range expected {
+		if counts[line] != count {
+			t.Errorf("line %d - expected to run %d times, got %d", line, count, counts[line])
+		}
+	}
+	branches := files[0].Branches
+	if branches[0].Then != 1 || branches[0].Else() != 2 || branches[1].Then != 0 || branches[1].Else() != 2 {
+		t.Errorf("Unexpected branches %+v %+v", *branches[0], *branches[1])
+	}
+}
+
+func TestReports(t *testing.T) {
+	c, dir := run(t)
+
+	var summary bytes.Buffer
+	c.WriteSummary(&summary)
+	rows := [][]string{
+		{"file", "statements", "branches"},
+		{filepath.Join(dir, "main.mk"), "10/12", "83.3%", "3/4", "75.0%"},
+		{filepath.Join(dir, "lib.mk"), "3/4", "75.0%", "1/2", "50.0%"},
+		{"total", "13/16", "81.2%", "4/6", "66.7%"},
+	}
+	lines := strings.Split(strings.TrimSuffix(summary.String(), "\n"), "\n")
+	if len(lines) != len(rows) {
+		t.Fatalf("Expected %d rows, got\n%s", len(rows), summary.String())
+	}
+	for i, row := range rows {
+		if fields := strings.Fields(lines[i]); strings.Join(fields, " ") != strings.Join(row, " ") {
+			t.Errorf("row %d - expected %q, got %q", i, row, fields)
+		}
+	}
+
+	var lcov bytes.Buffer
+	if err := c.WriteLCOV(&lcov); err != nil {
+		t.Fatalf("WriteLCOV failed: %s", err)
+	}
+	expected := "TN:\nSF:" + filepath.Join(dir, "lib.mk") + `
+BRDA:2,0,0,0
+BRDA:2,0,1,1
+BRF:2
+BRH:1
+DA:1,1
+DA:2,1
+DA:3,1
+LF:3
+LH:3
+end_of_record
+`
+	if !strings.HasSuffix(lcov.String(), expected) {
+		t.Errorf("Expected the LCOV report to end with\n%s\ngot\n%s", expected, lcov.String())
+	}
+	for _, text := range []string{"BRDA:6,1,0,0", "DA:8,1", "LF:9\nLH:9"} {
+		if !strings.Contains(lcov.String(), text) {
+			t.Errorf("Expected the LCOV report to contain %q", text)
+		}
+	}
+
+	var html bytes.Buffer
+	if err := c.WriteHTML(&html); err != nil {
+		t.Fatalf("WriteHTML failed: %s", err)
+	}
+	for _, text := range []string{
+		`<span class="covered" title="ran 3 times, then taken 1 times, else 2 times"><span class="number">3</span>  if (x &lt; 0) {</span>`,
+		`<span class="partial" title="ran 2 times, then taken 0 times, else 2 times"><span class="number">6</span>`,
+		`<span class="partial" title="ran 1 times"><span class="number">8</span>`,
+		`<span class=""><span class="number">5</span>  }</span>`,
+	} {
+		if !strings.Contains(html.String(), text) {
+			t.Errorf("Expected the HTML report to contain %q, got\n%s", text, html.String())
+		}
+	}
+}
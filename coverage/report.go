@@ -0,0 +1,214 @@
+package coverage
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// Summary counts what was covered in a file
+type Summary struct {
+	Statements, StatementsRun int
+	Branches, BranchesTaken   int // Each if expression has two branches
+}
+
+func (f *File) Summary() Summary {
+	var s Summary
+	for _, stmt := range f.Statements {
+		s.Statements++
+		if stmt.Count > 0 {
+			s.StatementsRun++
+		}
+	}
+	for _, branch := range f.Branches {
+		s.Branches += 2
+		if branch.Then > 0 {
+			s.BranchesTaken++
+		}
+		if branch.Else() > 0 {
+			s.BranchesTaken++
+		}
+	}
+	return s
+}
+
+func percent(part, whole int) string {
+	if whole == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(part)/float64(whole))
+}
+
+// WriteSummary writes a table of the statements and branches covered in
+// each file, and in all of them
+func (c *Coverage) WriteSummary(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "file\tstatements\t\tbranches")
+	var total Summary
+	row := func(name string, s Summary) {
+		fmt.Fprintf(tw, "%s\t%d/%d\t%s\t%d/%d\t%s\n", name,
+			s.StatementsRun, s.Statements, percent(s.StatementsRun, s.Statements),
+			s.BranchesTaken, s.Branches, percent(s.BranchesTaken, s.Branches))
+	}
+	for _, f := range c.files {
+		s := f.Summary()
+		row(f.Path, s)
+		total.Statements += s.Statements
+		total.StatementsRun += s.StatementsRun
+		total.Branches += s.Branches
+		total.BranchesTaken += s.BranchesTaken
+	}
+	if len(c.files) > 1 {
+		row("total", total)
+	}
+	return tw.Flush()
+}
+
+// lines gives how often each line ran, as the most any statement starting on
+// it ran
+func (f *File) lines() (order []int, counts map[int]int) {
+	counts = map[int]int{}
+	for _, stmt := range f.Statements {
+		count, seen := counts[stmt.Line]
+		if !seen {
+			order = append(order, stmt.Line)
+		}
+		counts[stmt.Line] = max(count, stmt.Count)
+	}
+	return order, counts
+}
+
+// WriteLCOV writes the coverage in the tracefile format of LCOV, which
+// genhtml and most editors and CI services read
+func (c *Coverage) WriteLCOV(w io.Writer) error {
+	var out strings.Builder
+	for _, f := range c.files {
+		fmt.Fprintf(&out, "TN:\nSF:%s\n", f.Path)
+		s := f.Summary()
+		for i, branch := range f.Branches {
+			if branch.Evaluated == 0 {
+				fmt.Fprintf(&out, "BRDA:%d,%d,0,-\nBRDA:%d,%d,1,-\n", branch.Line, i, branch.Line, i)
+				continue
+			}
+			fmt.Fprintf(&out, "BRDA:%d,%d,0,%d\nBRDA:%d,%d,1,%d\n", branch.Line, i, branch.Then, branch.Line, i, branch.Else())
+		}
+		fmt.Fprintf(&out, "BRF:%d\nBRH:%d\n", s.Branches, s.BranchesTaken)
+
+		order, counts := f.lines()
+		hit := 0
+		for _, line := range order {
+			fmt.Fprintf(&out, "DA:%d,%d\n", line, counts[line])
+			if counts[line] > 0 {
+				hit++
+			}
+		}
+		fmt.Fprintf(&out, "LF:%d\nLH:%d\nend_of_record\n", len(order), hit)
+	}
+	_, err := io.WriteString(w, out.String())
+	return err
+}
+
+// How a line of source is shown in HTML reports
+const (
+	lineCode      = ""
+	lineCovered   = "covered"
+	lineUncovered = "uncovered"
+	linePartial   = "partial" // Some of the line ran, or a branch on it was never taken
+)
+
+type htmlLine struct {
+	Number int
+	Text   string
+	Class  string
+	Title  string
+}
+
+type htmlFile struct {
+	Path    string
+	Summary string
+	Lines   []htmlLine
+}
+
+var htmlReport = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Coverage</title>
+<style>
+body { font-family: sans-serif; }
+pre { line-height: 1.3; }
+.number { color: #888; display: inline-block; width: 4em; text-align: right; margin-right: 1em; }
+.covered { background: #c8f0c8; }
+.uncovered { background: #f4c2c2; }
+.partial { background: #f6e8a8; }
+</style>
+</head>
+<body>
+{{range .}}<h2>{{.Path}}</h2>
+<p>{{.Summary}}</p>
+<pre>{{range .Lines}}<span class="{{.Class}}"{{if .Title}} title="{{.Title}}"{{end}}><span class="number">{{.Number}}</span>{{.Text}}</span>
+{{end}}</pre>
+{{end}}</body>
+</html>
+`))
+
+// WriteHTML writes a page showing the source of every file, with the lines
+// that ran, did not run, or only partly ran highlighted. The sources are read
+// from disk.
+func (c *Coverage) WriteHTML(w io.Writer) error {
+	files := []htmlFile{}
+	for _, f := range c.files {
+		src, err := os.ReadFile(f.Path)
+		if err != nil {
+			return err
+		}
+		s := f.Summary()
+		page := htmlFile{
+			Path: f.Path,
+			Summary: fmt.Sprintf("statements %d/%d (%s), branches %d/%d (%s)",
+				s.StatementsRun, s.Statements, percent(s.StatementsRun, s.Statements),
+				s.BranchesTaken, s.Branches, percent(s.BranchesTaken, s.Branches)),
+		}
+		classes, titles := f.classes()
+		for i, text := range strings.Split(strings.TrimSuffix(string(src), "\n"), "\n") {
+			page.Lines = append(page.Lines, htmlLine{Number: i + 1, Text: text, Class: classes[i+1], Title: titles[i+1]})
+		}
+		files = append(files, page)
+	}
+	return htmlReport.Execute(w, files)
+}
+
+// classes decides how each line is shown, and what its tooltip says
+func (f *File) classes() (map[int]string, map[int]string) {
+	classes, titles := map[int]string{}, map[int]string{}
+	for _, stmt := range f.Statements {
+		class := lineUncovered
+		if stmt.Count > 0 {
+			class = lineCovered
+		}
+		switch classes[stmt.Line] {
+		case lineCode:
+			classes[stmt.Line] = class
+			titles[stmt.Line] = fmt.Sprintf("ran %d times", stmt.Count)
+		case class:
+		default:
+			classes[stmt.Line] = linePartial
+		}
+	}
+	for _, branch := range f.Branches {
+		if branch.Evaluated == 0 {
+			continue
+		}
+		if titles[branch.Line] != "" {
+			titles[branch.Line] += ", "
+		}
+		titles[branch.Line] += fmt.Sprintf("then taken %d times, else %d times", branch.Then, branch.Else())
+		if branch.Then == 0 || branch.Else() == 0 {
+			classes[branch.Line] = linePartial
+		}
+	}
+	return classes, titles
+}
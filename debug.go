@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/waridh/go-monkey-interpreter/debug"
+	"github.com/waridh/go-monkey-interpreter/monkey"
+)
+
+// debugCommand runs a file under the debugger, taking commands from the
+// terminal. With -dap, it is a debug adapter for editors instead, speaking
+// over the standard input and output. As with monkey run, the script can only
+// touch files below the directory given with -allow-fs.
+func debugCommand(args []string) int {
+	flags := flag.NewFlagSet("debug", flag.ContinueOnError)
+	dap := flags.Bool("dap", false, "serve the Debug Adapter Protocol over stdio")
+	allowFS := flags.String("allow-fs", "", "let the script read and write files below `dir`")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey debug [-allow-fs dir] file")
+		fmt.Fprintln(flags.Output(), "       monkey debug -dap")
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *dap {
+		if flags.NArg() != 0 {
+			flags.Usage()
+			return 2
+		}
+		if err := debug.ServeDAP(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	path := flags.Arg(0)
+	src, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	// The standard input is left to the debugger's commands
+	var opts []monkey.Option
+	if *allowFS != "" {
+		opts = append(opts, monkey.WithFileSystem(*allowFS))
+	}
+	session, err := debug.Open(path, true, opts...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := debug.RunTerminal(session, string(src), os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,401 @@
+package debug
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+
+	"github.com/waridh/go-monkey-interpreter/message"
+	"github.com/waridh/go-monkey-interpreter/monkey"
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+// The parts of the Debug Adapter Protocol that the adapter speaks. Monkey has
+// a single thread, and lines and columns count from 1.
+
+type dapRequest struct {
+	Seq       int             `json:"seq"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+}
+
+type dapResponse struct {
+	Seq        int    `json:"seq"`
+	Type       string `json:"type"`
+	RequestSeq int    `json:"request_seq"`
+	Success    bool   `json:"success"`
+	Command    string `json:"command"`
+	Message    string `json:"message,omitempty"`
+	Body       any    `json:"body,omitempty"`
+}
+
+type dapEvent struct {
+	Seq   int    `json:"seq"`
+	Type  string `json:"type"`
+	Event string `json:"event"`
+	Body  any    `json:"body,omitempty"`
+}
+
+type dapSource struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+type dapStackFrame struct {
+	ID     int       `json:"id"`
+	Name   string    `json:"name"`
+	Source dapSource `json:"source"`
+	Line   int       `json:"line"`
+	Column int       `json:"column"`
+}
+
+type dapScope struct {
+	Name               string `json:"name"`
+	VariablesReference int    `json:"variablesReference"`
+	Expensive          bool   `json:"expensive"`
+}
+
+type dapVariable struct {
+	Name               string `json:"name"`
+	Value              string `json:"value"`
+	Type               string `json:"type"`
+	VariablesReference int    `json:"variablesReference"`
+}
+
+type dapBreakpoint struct {
+	Verified bool `json:"verified"`
+	Line     int  `json:"line"`
+}
+
+const threadID = 1
+
+type adapter struct {
+	mu  sync.Mutex // Guards writes, which the running program also makes
+	out io.Writer
+	seq int
+
+	opts        []monkey.Option
+	session     *Session
+	program     string
+	breakpoints []int
+	launched    bool
+	configured  bool
+
+	frames []Frame
+	// The environments shown as scopes since the last stop, each referred to
+	// by its index plus one
+	scopes []*object.Environment
+}
+
+// dapHandlers answer the requests of the protocol that the adapter supports
+var dapHandlers = map[string]func(a *adapter, args json.RawMessage) (any, error){
+	"initialize":        (*adapter).initialize,
+	"launch":            (*adapter).launch,
+	"setBreakpoints":    (*adapter).setBreakpoints,
+	"configurationDone": (*adapter).configurationDone,
+	"threads":           (*adapter).threads,
+	"stackTrace":        (*adapter).stackTrace,
+	"scopes":            (*adapter).scopeList,
+	"variables":         (*adapter).variables,
+	"continue":          resume((*Session).Continue),
+	"next":              resume((*Session).StepOver),
+	"stepIn":            resume((*Session).StepIn),
+	"stepOut":           resume((*Session).StepOut),
+	"pause":             (*adapter).pause,
+	"evaluate":          (*adapter).evaluate,
+}
+
+// ServeDAP speaks the Debug Adapter Protocol over in and out until the client
+// disconnects, or in runs out. Programs are launched in interpreters made with
+// opts, and what they print is sent to the client as output events.
+func ServeDAP(in io.Reader, out io.Writer, opts ...monkey.Option) error {
+	a := &adapter{out: out}
+	a.opts = append(opts, monkey.WithStdout(writerFunc(a.output("stdout"))), monkey.WithStderr(writerFunc(a.output("stderr"))))
+
+	requests := make(chan dapRequest)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		reader := bufio.NewReader(in)
+		for {
+			body, err := message.Read(reader)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			var req dapRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case requests <- req:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	defer a.terminate()
+	for {
+		var events <-chan Event
+		if a.session != nil {
+			events = a.session.Events()
+		}
+		select {
+		case err := <-readErr:
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		case req := <-requests:
+			if req.Command == "disconnect" || req.Command == "terminate" {
+				a.terminate()
+				a.reply(req, nil, nil)
+				if req.Command == "terminate" {
+					a.event("terminated", nil)
+					continue
+				}
+				return nil
+			}
+			handler, ok := dapHandlers[req.Command]
+			if !ok {
+				a.reply(req, nil, fmt.Errorf("unsupported request %q", req.Command))
+				continue
+			}
+			body, err := handler(a, req.Arguments)
+			a.reply(req, body, err)
+			if req.Command == "initialize" {
+				a.event("initialized", nil)
+			}
+		case event, ok := <-events:
+			if !ok {
+				a.session = nil
+				continue
+			}
+			a.stopped(event)
+		}
+	}
+}
+
+func (a *adapter) reply(req dapRequest, body any, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.seq++
+	resp := dapResponse{Seq: a.seq, Type: "response", RequestSeq: req.Seq, Success: err == nil, Command: req.Command, Body: body}
+	if err != nil {
+		resp.Message = err.Error()
+	}
+	message.Write(a.out, resp)
+}
+
+func (a *adapter) event(name string, body any) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.seq++
+	message.Write(a.out, dapEvent{Seq: a.seq, Type: "event", Event: name, Body: body})
+}
+
+// output makes a writer that sends what is written as output events
+func (a *adapter) output(category string) func(p []byte) (int, error) {
+	return func(p []byte) (int, error) {
+		a.event("output", map[string]any{"category": category, "output": string(p)})
+		return len(p), nil
+	}
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (w writerFunc) Write(p []byte) (int, error) { return w(p) }
+
+// stopped tells the client about a stop or the end of the run
+func (a *adapter) stopped(event Event) {
+	a.frames, a.scopes = event.Frames, nil
+	if !event.Done() {
+		a.event("stopped", map[string]any{"reason": event.Reason, "threadId": threadID, "allThreadsStopped": true})
+		return
+	}
+	exitCode := 0
+	if event.Err != nil {
+		a.event("output", map[string]any{"category": "stderr", "output": event.Err.Error() + "\n"})
+		exitCode = 1
+	}
+	a.event("exited", map[string]any{"exitCode": exitCode})
+	a.event("terminated", nil)
+}
+
+// terminate ends a run that is stopped. Runs that are going cannot be
+// interrupted, and are left to end with the process.
+func (a *adapter) terminate() {
+	if a.session != nil {
+		a.session.Terminate()
+		a.session = nil
+	}
+}
+
+// start runs the program once it is launched and the client has set its
+// breakpoints
+func (a *adapter) start() {
+	if a.launched && a.configured && a.session != nil {
+		a.session.SetBreakpoints(a.breakpoints)
+		a.session.Start()
+	}
+}
+
+func (a *adapter) initialize(args json.RawMessage) (any, error) {
+	return map[string]any{
+		"supportsConfigurationDoneRequest": true,
+		"supportsEvaluateForHovers":        true,
+		"supportsTerminateRequest":         true,
+	}, nil
+}
+
+func (a *adapter) launch(args json.RawMessage) (any, error) {
+	var p struct {
+		Program     string `json:"program"`
+		StopOnEntry bool   `json:"stopOnEntry"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, err
+	}
+	if a.launched {
+		return nil, fmt.Errorf("a program was already launched")
+	}
+	session, err := Open(p.Program, p.StopOnEntry, a.opts...)
+	if err != nil {
+		return nil, err
+	}
+	a.session, a.program, a.launched = session, p.Program, true
+	a.start()
+	return nil, nil
+}
+
+func (a *adapter) setBreakpoints(args json.RawMessage) (any, error) {
+	var p struct {
+		Breakpoints []struct {
+			Line int `json:"line"`
+		} `json:"breakpoints"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, err
+	}
+	a.breakpoints = nil
+	breakpoints := []dapBreakpoint{}
+	for _, bp := range p.Breakpoints {
+		a.breakpoints = append(a.breakpoints, bp.Line)
+		breakpoints = append(breakpoints, dapBreakpoint{Verified: true, Line: bp.Line})
+	}
+	if a.session != nil {
+		a.session.SetBreakpoints(a.breakpoints)
+	}
+	return map[string]any{"breakpoints": breakpoints}, nil
+}
+
+func (a *adapter) configurationDone(args json.RawMessage) (any, error) {
+	a.configured = true
+	a.start()
+	return nil, nil
+}
+
+func (a *adapter) threads(args json.RawMessage) (any, error) {
+	return map[string]any{"threads": []map[string]any{{"id": threadID, "name": "main"}}}, nil
+}
+
+func (a *adapter) stackTrace(args json.RawMessage) (any, error) {
+	source := dapSource{Name: filepath.Base(a.program), Path: a.program}
+	frames := []dapStackFrame{}
+	for i, frame := range a.frames {
+		frames = append(frames, dapStackFrame{ID: i, Name: frame.Name, Source: source, Line: frame.Line, Column: frame.Column})
+	}
+	return map[string]any{"stackFrames": frames, "totalFrames": len(frames)}, nil
+}
+
+// scopeList gives the environment of a frame, then each environment around it
+// out to the globals
+func (a *adapter) scopeList(args json.RawMessage) (any, error) {
+	var p struct {
+		FrameID int `json:"frameId"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, err
+	}
+	if p.FrameID < 0 || p.FrameID >= len(a.frames) {
+		return nil, fmt.Errorf("no frame %d", p.FrameID)
+	}
+	scopes := []dapScope{}
+	for env := a.frames[p.FrameID].Env; env != nil; env = env.Outer() {
+		name := "Closure"
+		switch {
+		case env.Outer() == nil:
+			name = "Globals"
+		case len(scopes) == 0:
+			name = "Locals"
+		}
+		a.scopes = append(a.scopes, env)
+		scopes = append(scopes, dapScope{Name: name, VariablesReference: len(a.scopes)})
+	}
+	return map[string]any{"scopes": scopes}, nil
+}
+
+func (a *adapter) variables(args json.RawMessage) (any, error) {
+	var p struct {
+		VariablesReference int `json:"variablesReference"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, err
+	}
+	if p.VariablesReference < 1 || p.VariablesReference > len(a.scopes) {
+		return nil, fmt.Errorf("no variables %d", p.VariablesReference)
+	}
+	env := a.scopes[p.VariablesReference-1]
+	variables := []dapVariable{}
+	for _, name := range env.Names() {
+		value, _ := env.Get(name)
+		variables = append(variables, dapVariable{Name: name, Value: object.Describe(value), Type: string(value.Type())})
+	}
+	return map[string]any{"variables": variables}, nil
+}
+
+// resume makes a handler for a request that resumes the run with step
+func resume(step func(*Session) error) func(a *adapter, args json.RawMessage) (any, error) {
+	return func(a *adapter, args json.RawMessage) (any, error) {
+		if a.session == nil {
+			return nil, fmt.Errorf("no program is running")
+		}
+		if err := step(a.session); err != nil {
+			return nil, err
+		}
+		a.frames, a.scopes = nil, nil
+		return map[string]any{"allThreadsContinued": true}, nil
+	}
+}
+
+func (a *adapter) pause(args json.RawMessage) (any, error) {
+	if a.session == nil {
+		return nil, fmt.Errorf("no program is running")
+	}
+	a.session.Pause()
+	return nil, nil
+}
+
+func (a *adapter) evaluate(args json.RawMessage) (any, error) {
+	var p struct {
+		Expression string `json:"expression"`
+		FrameID    int    `json:"frameId"`
+	}
+	if err := json.Unmarshal(args, &p); err != nil {
+		return nil, err
+	}
+	if a.session == nil {
+		return nil, fmt.Errorf("no program is running")
+	}
+	result, err := a.session.Evaluate(p.Expression, p.FrameID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"result": object.Describe(result), "type": string(result.Type()), "variablesReference": 0}, nil
+}
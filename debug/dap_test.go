@@ -0,0 +1,222 @@
+package debug
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/message"
+)
+
+type dapMessage struct {
+	Type       string          `json:"type"`
+	Event      string          `json:"event"`
+	Command    string          `json:"command"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// client drives an adapter the way an editor would, keeping the events it
+// has not asked for yet
+type client struct {
+	t      *testing.T
+	in     *io.PipeWriter
+	out    *bufio.Reader
+	seq    int
+	events []dapMessage
+	done   chan error
+}
+
+func newClient(t *testing.T) *client {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	c := &client{t: t, in: inWriter, out: bufio.NewReader(outReader), done: make(chan error, 1)}
+	go func() {
+		c.done <- ServeDAP(inReader, outWriter)
+		outWriter.Close()
+	}()
+	return c
+}
+
+func (c *client) read() dapMessage {
+	c.t.Helper()
+	body, err := message.Read(c.out)
+	if err != nil {
+		c.t.Fatalf("could not read from the adapter: %s", err)
+	}
+	var msg dapMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		c.t.Fatalf("invalid message %s: %s", body, err)
+	}
+	return msg
+}
+
+// request sends a request and waits for its response
+func (c *client) request(command string, args any) dapMessage {
+	c.t.Helper()
+	c.seq++
+	req := map[string]any{"seq": c.seq, "type": "request", "command": command, "arguments": args}
+	if err := message.Write(c.in, req); err != nil {
+		c.t.Fatalf("could not send %s: %s", command, err)
+	}
+	for {
+		msg := c.read()
+		if msg.Type == "response" && msg.RequestSeq == c.seq {
+			return msg
+		}
+		c.events = append(c.events, msg)
+	}
+}
+
+// event waits for the event called name
+func (c *client) event(name string) dapMessage {
+	c.t.Helper()
+	for i, msg := range c.events {
+		if msg.Event == name {
+			c.events = append(c.events[:i], c.events[i+1:]...)
+			return msg
+		}
+	}
+	for {
+		msg := c.read()
+		if msg.Event == name {
+			return msg
+		}
+		c.events = append(c.events, msg)
+	}
+}
+
+func TestDAP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.mk")
+	src := program[:len(program)-len("y\n")] + "puts(y);\n"
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newClient(t)
+	if resp := c.request("initialize", map[string]any{"adapterID": "monkey"}); !resp.Success {
+		t.Fatalf("initialize failed: %s", resp.Message)
+	}
+	c.event("initialized")
+	if resp := c.request("launch", map[string]any{"program": path}); !resp.Success {
+		t.Fatalf("launch failed: %s", resp.Message)
+	}
+	resp := c.request("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": path},
+		"breakpoints": []map[string]any{{"line": 2}},
+	})
+	if !resp.Success {
+		t.Fatalf("setBreakpoints failed: %s", resp.Message)
+	}
+	c.request("configurationDone", nil)
+
+	stopped := decodeAs[struct {
+		Reason   string `json:"reason"`
+		ThreadID int    `json:"threadId"`
+	}](t, c.event("stopped").Body)
+	if stopped.Reason != ReasonBreakpoint || stopped.ThreadID != threadID {
+		t.Errorf("Unexpected stop %+v", stopped)
+	}
+
+	trace := decodeAs[struct {
+		StackFrames []dapStackFrame `json:"stackFrames"`
+	}](t, c.request("stackTrace", map[string]any{"threadId": threadID}).Body)
+	if len(trace.StackFrames) != 2 || trace.StackFrames[0].Name != "add" || trace.StackFrames[0].Line != 2 {
+		t.Fatalf("Unexpected stack %+v", trace.StackFrames)
+	}
+	if trace.StackFrames[1].Source.Path != path {
+		t.Errorf("Expected frames in %s, got %+v", path, trace.StackFrames[1].Source)
+	}
+
+	scopes := decodeAs[struct {
+		Scopes []dapScope `json:"scopes"`
+	}](t, c.request("scopes", map[string]any{"frameId": 0}).Body).Scopes
+	if len(scopes) != 2 || scopes[0].Name != "Locals" || scopes[1].Name != "Globals" {
+		t.Fatalf("Unexpected scopes %+v", scopes)
+	}
+	variables := decodeAs[struct {
+		Variables []dapVariable `json:"variables"`
+	}](t, c.request("variables", map[string]any{"variablesReference": scopes[0].VariablesReference}).Body).Variables
+	if len(variables) != 2 || variables[0].Name != "a" || variables[0].Value != "1" || variables[0].Type != "INTEGER" {
+		t.Errorf("Unexpected locals %+v", variables)
+	}
+
+	evaluated := decodeAs[struct {
+		Result string `json:"result"`
+	}](t, c.request("evaluate", map[string]any{"expression": "a * 10 + b", "frameId": 0}).Body)
+	if evaluated.Result != "12" {
+		t.Errorf("Expected a * 10 + b to be 12, got %q", evaluated.Result)
+	}
+	if resp := c.request("evaluate", map[string]any{"expression": "nope", "frameId": 0}); resp.Success {
+		t.Errorf("Expected evaluating an unbound name to fail")
+	}
+
+	c.request("next", map[string]any{"threadId": threadID})
+	c.event("stopped")
+	trace = decodeAs[struct {
+		StackFrames []dapStackFrame `json:"stackFrames"`
+	}](t, c.request("stackTrace", map[string]any{"threadId": threadID}).Body)
+	if trace.StackFrames[0].Line != 3 {
+		t.Errorf("Expected next to stop at line 3, got %d", trace.StackFrames[0].Line)
+	}
+
+	c.request("continue", map[string]any{"threadId": threadID})
+	output := decodeAs[struct {
+		Category string `json:"category"`
+		Output   string `json:"output"`
+	}](t, c.event("output").Body)
+	if output.Category != "stdout" || output.Output != "6\n" {
+		t.Errorf("Expected the program's output, got %+v", output)
+	}
+	exited := decodeAs[struct {
+		ExitCode int `json:"exitCode"`
+	}](t, c.event("exited").Body)
+	if exited.ExitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d", exited.ExitCode)
+	}
+	c.event("terminated")
+
+	if resp := c.request("unknown", nil); resp.Success {
+		t.Errorf("Expected unknown requests to fail")
+	}
+	c.request("disconnect", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("ServeDAP failed: %s", err)
+	}
+}
+
+func TestDAPLaunchErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.mk")
+	if err := os.WriteFile(path, []byte("let = 1;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := newClient(t)
+	if resp := c.request("launch", map[string]any{"program": path}); resp.Success {
+		t.Errorf("Expected launching a program with parser errors to fail")
+	}
+	if resp := c.request("launch", map[string]any{"program": path + ".missing"}); resp.Success {
+		t.Errorf("Expected launching a missing program to fail")
+	}
+	if resp := c.request("continue", map[string]any{"threadId": threadID}); resp.Success {
+		t.Errorf("Expected continuing without a program to fail")
+	}
+	c.in.Close()
+	if err := <-c.done; err != nil {
+		t.Errorf("ServeDAP failed: %s", err)
+	}
+}
+
+func decodeAs[T any](t *testing.T, raw json.RawMessage) T {
+	t.Helper()
+	var value T
+	if err := json.Unmarshal(raw, &value); err != nil {
+		t.Fatalf("could not decode %s: %s", raw, err)
+	}
+	return value
+}
@@ -0,0 +1,338 @@
+// Package debug runs Monkey programs under a debugger, which stops them at
+// breakpoints and steps through them a statement at a time. Sessions are
+// driven from a terminal or by an editor speaking the Debug Adapter
+// Protocol.
+package debug
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/waridh/go-monkey-interpreter/ast"
+	"github.com/waridh/go-monkey-interpreter/evaluator"
+	"github.com/waridh/go-monkey-interpreter/lexer"
+	"github.com/waridh/go-monkey-interpreter/monkey"
+	"github.com/waridh/go-monkey-interpreter/object"
+	"github.com/waridh/go-monkey-interpreter/parser"
+)
+
+// Why a run stopped
+const (
+	ReasonEntry      = "entry"
+	ReasonBreakpoint = "breakpoint"
+	ReasonStep       = "step"
+	ReasonPause      = "pause"
+)
+
+// Frame is a function call being evaluated, or the program itself at the
+// bottom of the stack
+type Frame struct {
+	Name   string
+	Line   int // Where the statement being evaluated starts
+	Column int
+	Env    *object.Environment
+}
+
+// Event is sent when the run stops at a statement, and once more when it
+// ends
+type Event struct {
+	Reason string  // Why the run stopped, empty once it has ended
+	Frames []Frame // The call stack, innermost first
+
+	Result object.Object // The value of the program, once it has ended
+	Err    error         // The runtime error the program ended with
+}
+
+// Done reports whether the event is the end of the run
+func (e Event) Done() bool {
+	return e.Reason == ""
+}
+
+// How the run continues after a stop
+type resumeMode int
+
+const (
+	running resumeMode = iota
+	stepIn
+	stepOver
+	stepOut
+	terminate
+)
+
+// Session is a run of a program under the debugger. The program is
+// evaluated on a goroutine of its own, which sends an Event whenever it
+// stops and waits to be resumed.
+//
+// Breakpoints are lines of the main file. Statements in imported modules are
+// stepped over.
+type Session struct {
+	program *ast.Program
+	file    string // Where the program came from, if anywhere
+	env     *object.Environment
+	events  chan Event
+	resume  chan resumeMode
+
+	mu          sync.Mutex
+	breakpoints map[int]bool
+	pause       bool // Whether to stop at the next statement
+	stopped     bool
+
+	// Only used by the evaluating goroutine, or while it is stopped
+	mode       resumeMode
+	entry      bool // Whether the run has yet to stop on entry
+	target     int  // The depth that stepping over or out stops at
+	frames     []Frame
+	evaluating bool // Set while expressions are evaluated for the user
+}
+
+// NewSession prepares program to be debugged in env. With stopOnEntry, the
+// run stops before the first statement.
+func NewSession(program *ast.Program, env *object.Environment, stopOnEntry bool) *Session {
+	s := &Session{
+		program:     program,
+		env:         env,
+		events:      make(chan Event),
+		resume:      make(chan resumeMode),
+		breakpoints: make(map[int]bool),
+		frames:      []Frame{{Name: "main", Env: env}},
+	}
+	if stopOnEntry {
+		s.mode, s.entry = stepIn, true
+	}
+	return s
+}
+
+// Open parses the program at path for debugging in an interpreter made with
+// opts. Unless opts choose otherwise, imports are loaded relative to the
+// directory of the file, as they are by monkey.Interpreter.EvalFile.
+func Open(path string, stopOnEntry bool, opts ...monkey.Option) (*Session, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, &monkey.ParseError{File: path, Errors: p.Errors()}
+	}
+
+	env := monkey.New(opts...).Environment()
+	if modules := env.Runtime().Modules; modules.Loader == nil {
+		modules.Loader = object.NewDirLoader(filepath.Dir(path))
+	}
+	s := NewSession(program, env, stopOnEntry)
+	s.file = path
+	return s, nil
+}
+
+// Start begins evaluating the program
+func (s *Session) Start() {
+	go s.run()
+}
+
+// Events is where the stops and the end of the run are sent. It is closed
+// after the end, or once the session is terminated.
+func (s *Session) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Session) run() {
+	rt := s.env.Runtime()
+	rt.Debugger = s
+	result := evaluator.Eval(s.program, s.env)
+	rt.Debugger = nil
+
+	event := Event{Result: result}
+	if result == nil {
+		event.Result = evaluator.NULL
+	}
+	if err, ok := result.(*object.Error); ok {
+		event = Event{Err: &monkey.RuntimeError{File: s.file, Message: err.Message}}
+	}
+	s.events <- event
+	close(s.events)
+}
+
+// SetBreakpoints replaces the breakpoints with lines
+func (s *Session) SetBreakpoints(lines []int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.breakpoints = make(map[int]bool)
+	for _, line := range lines {
+		s.breakpoints[line] = true
+	}
+}
+
+// Breakpoints lists the lines with breakpoints, in order
+func (s *Session) Breakpoints() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lines := make([]int, 0, len(s.breakpoints))
+	for line := range s.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Pause stops the run at the next statement
+func (s *Session) Pause() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pause = true
+}
+
+// Continue resumes the run until the next breakpoint
+func (s *Session) Continue() error { return s.resumeWith(running) }
+
+// StepIn resumes the run until the next statement, in whichever function
+func (s *Session) StepIn() error { return s.resumeWith(stepIn) }
+
+// StepOver resumes the run until the next statement of the current function,
+// or of a caller once it returns
+func (s *Session) StepOver() error { return s.resumeWith(stepOver) }
+
+// StepOut resumes the run until the current function has returned
+func (s *Session) StepOut() error { return s.resumeWith(stepOut) }
+
+// Terminate ends a stopped run without evaluating the rest of it
+func (s *Session) Terminate() error { return s.resumeWith(terminate) }
+
+func (s *Session) resumeWith(mode resumeMode) error {
+	s.mu.Lock()
+	stopped := s.stopped
+	s.stopped = false
+	s.mu.Unlock()
+	if !stopped {
+		return errors.New("the program is not stopped")
+	}
+	s.resume <- mode
+	return nil
+}
+
+// Evaluate evaluates src in the environment of frame, counted from the
+// innermost, while the run is stopped. Breakpoints are ignored meanwhile.
+func (s *Session) Evaluate(src string, frame int) (object.Object, error) {
+	s.mu.Lock()
+	stopped := s.stopped
+	s.mu.Unlock()
+	if !stopped {
+		return nil, errors.New("the program is running")
+	}
+	if frame < 0 || frame >= len(s.frames) {
+		return nil, errors.New("no such frame")
+	}
+
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, errors.New(strings.Join(p.Errors(), "\n"))
+	}
+	s.evaluating = true
+	result := evaluator.Eval(program, s.frames[len(s.frames)-1-frame].Env)
+	s.evaluating = false
+	if err, ok := result.(*object.Error); ok {
+		return nil, errors.New(err.Message)
+	}
+	if result == nil {
+		return evaluator.NULL, nil
+	}
+	return result, nil
+}
+
+// Statement stops the run before stmt when a breakpoint or step says so
+func (s *Session) Statement(stmt ast.Statement, env *object.Environment) {
+	if s.evaluating || !s.isMain(env) {
+		return
+	}
+	tok := ast.StartOf(stmt)
+	top := &s.frames[len(s.frames)-1]
+	// Breakpoints stop the run once for each time their line is reached,
+	// not once for each statement on it
+	moved := top.Line != tok.Line
+	top.Line, top.Column, top.Env = tok.Line, tok.Column, env
+
+	s.mu.Lock()
+	pause, breakpoint := s.pause, s.breakpoints[tok.Line]
+	s.pause = false
+	s.mu.Unlock()
+
+	depth := len(s.frames)
+	var reason string
+	switch {
+	case pause:
+		reason = ReasonPause
+	case breakpoint && moved:
+		reason = ReasonBreakpoint
+	case s.mode == stepIn,
+		s.mode == stepOver && depth <= s.target,
+		s.mode == stepOut && depth < s.target:
+		reason = ReasonStep
+		if s.entry {
+			reason = ReasonEntry
+			s.entry = false
+		}
+	default:
+		return
+	}
+	s.stop(reason)
+}
+
+func (s *Session) stop(reason string) {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	s.events <- Event{Reason: reason, Frames: s.Frames()}
+
+	mode := <-s.resume
+	if mode == terminate {
+		s.env.Runtime().Debugger = nil
+		close(s.events)
+		runtime.Goexit()
+	}
+	s.mode = mode
+	s.target = len(s.frames)
+}
+
+// Call and Return keep track of the call stack
+func (s *Session) Call(fn *object.Function, env *object.Environment) {
+	if s.evaluating {
+		return
+	}
+	name := fn.Name
+	if name == "" {
+		name = "fn"
+	}
+	s.frames = append(s.frames, Frame{Name: name, Env: env})
+}
+
+func (s *Session) Return(fn *object.Function, result object.Object) {
+	if s.evaluating {
+		return
+	}
+	s.frames = s.frames[:len(s.frames)-1]
+}
+
+// Frames returns the call stack, innermost first. It is only meaningful
+// while the run is stopped.
+func (s *Session) Frames() []Frame {
+	frames := make([]Frame, len(s.frames))
+	for i, frame := range s.frames {
+		frames[len(frames)-1-i] = frame
+	}
+	return frames
+}
+
+// isMain reports whether env belongs to the main program rather than to an
+// imported module
+func (s *Session) isMain(env *object.Environment) bool {
+	for env.Outer() != nil {
+		env = env.Outer()
+	}
+	return env == s.env
+}
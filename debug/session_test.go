@@ -0,0 +1,195 @@
+package debug
+
+import (
+	"testing"
+	"time"
+
+	"github.com/waridh/go-monkey-interpreter/evaluator"
+	"github.com/waridh/go-monkey-interpreter/lexer"
+	"github.com/waridh/go-monkey-interpreter/object"
+	"github.com/waridh/go-monkey-interpreter/parser"
+)
+
+const program = `let add = fn(a, b) {
+  let sum = a + b;
+  sum
+};
+let x = add(1, 2);
+let y = x * 2;
+y
+`
+
+func newSession(t *testing.T, src string, stopOnEntry bool) *Session {
+	p := parser.New(lexer.New(src))
+	prog := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+	env := object.NewEnvironment()
+	env.Runtime().Builtins = evaluator.NewBuiltins()
+	return NewSession(prog, env, stopOnEntry)
+}
+
+// next waits for the next event of s
+func next(t *testing.T, s *Session) Event {
+	t.Helper()
+	select {
+	case event, ok := <-s.Events():
+		if !ok {
+			t.Fatalf("Expected an event, the session was over")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for an event")
+	}
+	return Event{}
+}
+
+func expectStop(t *testing.T, event Event, reason, name string, line int) {
+	t.Helper()
+	if event.Done() {
+		t.Fatalf("Expected a stop at line %d, the run ended with %v %v", line, event.Result, event.Err)
+	}
+	frame := event.Frames[0]
+	if event.Reason != reason || frame.Name != name || frame.Line != line {
+		t.Errorf("Expected a %s stop in %s at line %d, got a %s stop in %s at line %d",
+			reason, name, line, event.Reason, frame.Name, frame.Line)
+	}
+}
+
+func expectValue(t *testing.T, s *Session, expr string, frame int, expected string) {
+	t.Helper()
+	result, err := s.Evaluate(expr, frame)
+	if err != nil {
+		t.Errorf("Evaluate(%q) failed: %s", expr, err)
+		return
+	}
+	if result.Inspect() != expected {
+		t.Errorf("Evaluate(%q) - expected %s, got %s", expr, expected, result.Inspect())
+	}
+}
+
+func TestStepping(t *testing.T) {
+	tests := []struct {
+		step func(*Session) error
+		name string
+		line int
+	}{
+		{(*Session).StepOver, "main", 5},
+		{(*Session).StepIn, "add", 2},
+		{(*Session).StepOver, "add", 3},
+		{(*Session).StepOver, "main", 6},
+		{(*Session).StepIn, "main", 7},
+	}
+
+	s := newSession(t, program, true)
+	s.Start()
+	expectStop(t, next(t, s), ReasonEntry, "main", 1)
+	for _, tt := range tests {
+		if err := tt.step(s); err != nil {
+			t.Fatalf("could not step: %s", err)
+		}
+		expectStop(t, next(t, s), ReasonStep, tt.name, tt.line)
+	}
+	s.Continue()
+	if event := next(t, s); !event.Done() || event.Result.Inspect() != "6" {
+		t.Errorf("Expected the program to end with 6, got %+v", event)
+	}
+}
+
+func TestStepOut(t *testing.T) {
+	s := newSession(t, program, true)
+	s.Start()
+	next(t, s)
+	s.StepOver()
+	next(t, s)
+	s.StepIn()
+	expectStop(t, next(t, s), ReasonStep, "add", 2)
+	s.StepOut()
+	expectStop(t, next(t, s), ReasonStep, "main", 6)
+	s.Terminate()
+}
+
+func TestBreakpoints(t *testing.T) {
+	s := newSession(t, program, false)
+	s.SetBreakpoints([]int{2, 7})
+	s.Start()
+
+	event := next(t, s)
+	expectStop(t, event, ReasonBreakpoint, "add", 2)
+	if len(event.Frames) != 2 || event.Frames[1].Name != "main" || event.Frames[1].Line != 5 {
+		t.Errorf("Expected add to be called from line 5, got %+v", event.Frames)
+	}
+	expectValue(t, s, "a + b", 0, "3")
+	expectValue(t, s, "add(a, b)", 0, "3")
+	if _, err := s.Evaluate("x", 1); err == nil {
+		t.Errorf("Expected x to be unbound before add returns")
+	}
+
+	s.Continue()
+	expectStop(t, next(t, s), ReasonBreakpoint, "main", 7)
+	expectValue(t, s, "y", 0, "6")
+	s.Continue()
+	if event := next(t, s); !event.Done() {
+		t.Errorf("Expected the run to end, got %+v", event)
+	}
+}
+
+func TestRecursion(t *testing.T) {
+	src := `let count = fn(n) {
+  if (n > 0) {
+    count(n - 1)
+  } else {
+    n
+  }
+};
+count(2);`
+	s := newSession(t, src, false)
+	s.SetBreakpoints([]int{3})
+	s.Start()
+	for _, n := range []string{"2", "1"} {
+		expectStop(t, next(t, s), ReasonBreakpoint, "count", 3)
+		expectValue(t, s, "n", 0, n)
+		s.Continue()
+	}
+	if event := next(t, s); !event.Done() {
+		t.Errorf("Expected the run to end, got %+v", event)
+	}
+}
+
+func TestPauseAndTerminate(t *testing.T) {
+	s := newSession(t, program, false)
+	if err := s.Continue(); err == nil {
+		t.Errorf("Expected resuming a run that is not stopped to fail")
+	}
+	if _, err := s.Evaluate("1", 0); err == nil {
+		t.Errorf("Expected evaluating while running to fail")
+	}
+
+	s.Pause()
+	s.Start()
+	expectStop(t, next(t, s), ReasonPause, "main", 1)
+	if err := s.Terminate(); err != nil {
+		t.Fatalf("could not terminate: %s", err)
+	}
+	select {
+	case event, ok := <-s.Events():
+		if ok {
+			t.Errorf("Expected the session to be over, got %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for the session to end")
+	}
+}
+
+func TestRuntimeError(t *testing.T) {
+	s := newSession(t, "let x = 1;\nx + true;", false)
+	s.Start()
+	event := next(t, s)
+	if !event.Done() || event.Err == nil {
+		t.Fatalf("Expected the run to end with an error, got %+v", event)
+	}
+	if event.Err.Error() != "type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("Unexpected error %q", event.Err)
+	}
+}
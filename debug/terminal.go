@@ -0,0 +1,200 @@
+package debug
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+const terminalHelp = `commands:
+  break, b [line]   set a breakpoint, or list them without a line
+  clear line        remove a breakpoint
+  continue, c       run until the next breakpoint
+  next, n           step over calls to the next statement
+  step, s           step into calls to the next statement
+  out, o            run until the current function returns
+  print, p expr     evaluate an expression in the current frame
+  watch, w [expr]   evaluate an expression at every stop, or list them
+  unwatch n         remove the nth watch expression
+  locals, l         show the variables of every enclosing scope
+  stack, bt         show the call stack
+  quit, q           stop debugging
+`
+
+// terminal debugs a session with commands typed one to a line
+type terminal struct {
+	session *Session
+	lines   []string // The source of the program, for showing where it stopped
+	in      *bufio.Scanner
+	out     io.Writer
+
+	frames  []Frame
+	watches []string
+}
+
+// RunTerminal starts session and debugs it with commands read from in, until
+// the program ends or the user quits. source is the text of the program.
+func RunTerminal(session *Session, source string, in io.Reader, out io.Writer) error {
+	t := &terminal{
+		session: session,
+		lines:   strings.Split(source, "\n"),
+		in:      bufio.NewScanner(in),
+		out:     out,
+	}
+	session.Start()
+	for event := range session.Events() {
+		if event.Done() {
+			if event.Err != nil {
+				fmt.Fprintf(out, "program failed: %s\n", event.Err)
+			} else {
+				fmt.Fprintf(out, "program finished: %s\n", event.Result.Inspect())
+			}
+			return nil
+		}
+
+		t.frames = event.Frames
+		t.where(event.Reason)
+		if quit := t.prompt(); quit {
+			return session.Terminate()
+		}
+	}
+	return nil
+}
+
+// where shows the statement the run stopped at, and the watch expressions
+func (t *terminal) where(reason string) {
+	frame := t.frames[0]
+	fmt.Fprintf(t.out, "stopped (%s) in %s at line %d\n", reason, frame.Name, frame.Line)
+	if frame.Line >= 1 && frame.Line <= len(t.lines) {
+		fmt.Fprintf(t.out, "%4d  %s\n", frame.Line, t.lines[frame.Line-1])
+	}
+	for i, expr := range t.watches {
+		fmt.Fprintf(t.out, "  %d: %s = %s\n", i+1, expr, t.evaluate(expr))
+	}
+}
+
+// prompt reads commands until one resumes the run. It reports whether the
+// user quit, which running out of input also counts as.
+func (t *terminal) prompt() bool {
+	for {
+		fmt.Fprint(t.out, "(debug) ")
+		if !t.in.Scan() {
+			return true
+		}
+		command, arg, _ := strings.Cut(strings.TrimSpace(t.in.Text()), " ")
+		arg = strings.TrimSpace(arg)
+
+		switch command {
+		case "":
+		case "continue", "c":
+			t.session.Continue()
+			return false
+		case "next", "n":
+			t.session.StepOver()
+			return false
+		case "step", "s":
+			t.session.StepIn()
+			return false
+		case "out", "o":
+			t.session.StepOut()
+			return false
+		case "quit", "q":
+			return true
+		case "break", "b":
+			t.setBreakpoint(arg)
+		case "clear":
+			t.clearBreakpoint(arg)
+		case "print", "p":
+			fmt.Fprintln(t.out, t.evaluate(arg))
+		case "watch", "w":
+			if arg != "" {
+				t.watches = append(t.watches, arg)
+			}
+			for i, expr := range t.watches {
+				fmt.Fprintf(t.out, "  %d: %s = %s\n", i+1, expr, t.evaluate(expr))
+			}
+		case "unwatch":
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 1 || n > len(t.watches) {
+				fmt.Fprintf(t.out, "no watch expression %q\n", arg)
+				continue
+			}
+			t.watches = append(t.watches[:n-1], t.watches[n:]...)
+		case "locals", "l":
+			t.locals()
+		case "stack", "bt":
+			for i, frame := range t.frames {
+				fmt.Fprintf(t.out, "  #%d %s at line %d\n", i, frame.Name, frame.Line)
+			}
+		case "help", "h":
+			fmt.Fprint(t.out, terminalHelp)
+		default:
+			fmt.Fprintf(t.out, "unknown command %q, try help\n", command)
+		}
+	}
+}
+
+func (t *terminal) setBreakpoint(arg string) {
+	lines := t.session.Breakpoints()
+	if arg == "" {
+		for _, line := range lines {
+			fmt.Fprintf(t.out, "  line %d\n", line)
+		}
+		return
+	}
+	line, err := strconv.Atoi(arg)
+	if err != nil || line < 1 || line > len(t.lines) {
+		fmt.Fprintf(t.out, "no line %q\n", arg)
+		return
+	}
+	t.session.SetBreakpoints(append(lines, line))
+	fmt.Fprintf(t.out, "breakpoint at line %d\n", line)
+}
+
+func (t *terminal) clearBreakpoint(arg string) {
+	line, err := strconv.Atoi(arg)
+	if err != nil {
+		fmt.Fprintf(t.out, "no line %q\n", arg)
+		return
+	}
+	kept := []int{}
+	for _, l := range t.session.Breakpoints() {
+		if l != line {
+			kept = append(kept, l)
+		}
+	}
+	t.session.SetBreakpoints(kept)
+}
+
+func (t *terminal) evaluate(expr string) string {
+	if expr == "" {
+		return "nothing to evaluate"
+	}
+	result, err := t.session.Evaluate(expr, 0)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return result.Inspect()
+}
+
+// locals shows the variables of the current frame, then those of each scope
+// around it out to the globals
+func (t *terminal) locals() {
+	depth := 0
+	for env := t.frames[0].Env; env != nil; env = env.Outer() {
+		if env.Outer() == nil {
+			fmt.Fprintln(t.out, "globals:")
+		} else {
+			fmt.Fprintf(t.out, "scope %d:\n", depth)
+		}
+		for _, name := range env.Names() {
+			value, _ := env.Get(name)
+			fmt.Fprintf(t.out, "  %s = %s\n", name, object.Describe(value))
+		}
+		depth++
+	}
+}
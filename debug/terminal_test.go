@@ -0,0 +1,57 @@
+package debug
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTerminal(t *testing.T) {
+	commands := strings.Join([]string{
+		"break 2",
+		"watch x",
+		"continue",
+		"print a + b",
+		"stack",
+		"locals",
+		"bogus",
+		"clear 2",
+		"out",
+		"continue",
+	}, "\n")
+	var out bytes.Buffer
+	if err := RunTerminal(newSession(t, program, true), program, strings.NewReader(commands), &out); err != nil {
+		t.Fatalf("RunTerminal failed: %s", err)
+	}
+
+	expected := []string{
+		"stopped (entry) in main at line 1",
+		"   1  let add = fn(a, b) {",
+		"breakpoint at line 2",
+		"1: x = error: identity not found: x",
+		"stopped (breakpoint) in add at line 2",
+		"   2    let sum = a + b;",
+		"(debug) 3\n",
+		"  #0 add at line 2\n  #1 main at line 5",
+		"scope 0:\n  a = 1\n  b = 2\nglobals:\n  add = fn(a, b)",
+		`unknown command "bogus"`,
+		"stopped (step) in main at line 6",
+		"1: x = 3",
+		"program finished: 6",
+	}
+	for _, text := range expected {
+		if !strings.Contains(out.String(), text) {
+			t.Errorf("Expected the output to contain %q, got\n%s", text, out.String())
+		}
+	}
+}
+
+func TestTerminalQuit(t *testing.T) {
+	var out bytes.Buffer
+	if err := RunTerminal(newSession(t, program, true), program, strings.NewReader("quit\n"), &out); err != nil {
+		t.Fatalf("RunTerminal failed: %s", err)
+	}
+	if strings.Contains(out.String(), "program finished") {
+		t.Errorf("Expected quitting to stop the program, got\n%s", out.String())
+	}
+}
@@ -2,10 +2,60 @@ package evaluator
 
 import (
 	"fmt"
+	"unicode/utf8"
 
 	"github.com/waridh/go-monkey-interpreter/object"
 )
 
+var builtinCapabilities = map[string][]object.Capability{
+	"puts":       {object.CapOutput},
+	"eputs":      {object.CapOutput},
+	"read_file":  {object.CapFileSystem},
+	"write_file": {object.CapFileSystem},
+	"read_lines": {object.CapFileSystem},
+	"list_dir":   {object.CapFileSystem},
+	"read_line":  {object.CapStdin},
+}
+
+// defaultBuiltins is used by runs that do not have a registry of their own.
+// It must not be changed.
+var defaultBuiltins *object.Builtins
+
+// The builtins call back into Eval, so the registry is built in init to
+// avoid an initialization cycle.
+func init() {
+	defaultBuiltins = NewBuiltins()
+}
+
+// NewBuiltins returns a registry holding the standard builtins, which hosts
+// can then extend or restrict. The file system and standard input
+// capabilities start out disabled, so hosts must opt in to them.
+func NewBuiltins() *object.Builtins {
+	registry := object.NewBuiltins()
+	groups := []map[string]*object.Builtin{
+		builtins, collectionBuiltins, hashBuiltins, stringBuiltins,
+		mathBuiltins, typeBuiltins, jsonBuiltins, ioBuiltins,
+	}
+	for _, group := range groups {
+		for name, builtin := range group {
+			registry.Define(name, builtin, builtinCapabilities[name]...)
+		}
+	}
+	for name, constant := range mathConstants {
+		registry.Define(name, constant)
+	}
+	registry.Disable(object.CapFileSystem)
+	registry.Disable(object.CapStdin)
+	return registry
+}
+
+func builtinsOf(env *object.Environment) *object.Builtins {
+	if registry := env.Runtime().Builtins; registry != nil {
+		return registry
+	}
+	return defaultBuiltins
+}
+
 func builtinLenCheck(funcName string, expected int, args []object.Object) object.Object {
 	if len(args) != expected {
 		return newError("wrong number of arguments for %s. got=%d, want=%d", funcName, len(args), expected)
@@ -13,24 +63,28 @@ func builtinLenCheck(funcName string, expected int, args []object.Object) object
 	return nil
 }
 
+// The standard builtins are registered in every new registry. Builtins that
+// need a capability are listed in builtinCapabilities.
 var builtins = map[string]*object.Builtin{
 	"len": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
 			if err := builtinLenCheck("len", 1, args); err != nil {
 				return err
 			}
 			switch arg := args[0].(type) {
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(arg.Len())}
 			default:
 				return newError("argument to `%s` not supported, got=%s", "len", arg.Type())
 			}
 		},
 	},
 	"first": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
 			if err := builtinLenCheck("len", 1, args); err != nil {
 				return err
 			}
@@ -46,7 +100,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"last": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
 			if err := builtinLenCheck("len", 1, args); err != nil {
 				return err
 			}
@@ -62,23 +116,23 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"rest": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
 			if err := builtinLenCheck("len", 1, args); err != nil {
 				return err
 			}
 			switch arg := args[0].(type) {
 			case *object.Array:
 				if len(arg.Elements) == 0 {
-					return &object.Array{Elements: []object.Object{}}
+					return track(env, &object.Array{Elements: []object.Object{}})
 				}
-				return &object.Array{Elements: arg.Elements[1:]}
+				return track(env, &object.Array{Elements: arg.Elements[1:]})
 			default:
 				return newError("argument to `%s` not supported, got=%s", "rest", arg.Type())
 			}
 		},
 	},
 	"push": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
 			if err := builtinLenCheck("len", 2, args); err != nil {
 				return err
 			}
@@ -88,16 +142,26 @@ var builtins = map[string]*object.Builtin{
 				newElements := make([]object.Object, length+1, length+1)
 				copy(newElements, arg.Elements)
 				newElements[length] = args[1]
-				return &object.Array{Elements: newElements}
+				return track(env, &object.Array{Elements: newElements})
 			default:
 				return newError("argument to `%s` not supported, got=%s", "len", arg.Type())
 			}
 		},
 	},
 	"puts": {
-		Fn: func(args ...object.Object) object.Object {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			for _, arg := range args {
+				fmt.Fprintln(env.Runtime().Stdout, arg.Inspect())
+			}
+			return NULL
+		},
+	},
+	// eputs is puts for warnings and diagnostics, which go to the standard
+	// error of the run
+	"eputs": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
 			for _, arg := range args {
-				fmt.Println(arg.Inspect())
+				fmt.Fprintln(env.Runtime().Stderr, arg.Inspect())
 			}
 			return NULL
 		},
@@ -0,0 +1,401 @@
+package evaluator
+
+import (
+	"sort"
+
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+// Higher order builtins over arrays. These build their results natively,
+// rather than through repeated calls to rest and push, which copy the array
+// every time.
+var collectionBuiltins = map[string]*object.Builtin{
+	"map": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArgs("map", args)
+			if err != nil {
+				return err
+			}
+			result := make([]object.Object, len(arr.Elements))
+			for i, ele := range arr.Elements {
+				mapped := applyFunction(env, fn, []object.Object{ele})
+				if isError(mapped) {
+					return mapped
+				}
+				result[i] = mapped
+			}
+			return track(env, &object.Array{Elements: result})
+		},
+	},
+	"filter": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArgs("filter", args)
+			if err != nil {
+				return err
+			}
+			result := []object.Object{}
+			for _, ele := range arr.Elements {
+				keep := applyFunction(env, fn, []object.Object{ele})
+				if isError(keep) {
+					return keep
+				}
+				if isTruthy(keep) {
+					result = append(result, ele)
+				}
+			}
+			return track(env, &object.Array{Elements: result})
+		},
+	},
+	"reduce": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError("wrong number of arguments for reduce. got=%d, want=2 or 3", len(args))
+			}
+			arr, fn, err := arrayAndFunctionArgs("reduce", args[:2])
+			if err != nil {
+				return err
+			}
+			elements := arr.Elements
+			var acc object.Object
+			if len(args) == 3 {
+				acc = args[2]
+			} else if len(elements) == 0 {
+				return newError("reduce of empty array with no initial value")
+			} else {
+				acc, elements = elements[0], elements[1:]
+			}
+			for _, ele := range elements {
+				acc = applyFunction(env, fn, []object.Object{acc, ele})
+				if isError(acc) {
+					return acc
+				}
+			}
+			return acc
+		},
+	},
+	"find": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArgs("find", args)
+			if err != nil {
+				return err
+			}
+			for _, ele := range arr.Elements {
+				found := applyFunction(env, fn, []object.Object{ele})
+				if isError(found) {
+					return found
+				}
+				if isTruthy(found) {
+					return ele
+				}
+			}
+			return NULL
+		},
+	},
+	"any": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArgs("any", args)
+			if err != nil {
+				return err
+			}
+			for _, ele := range arr.Elements {
+				result := applyFunction(env, fn, []object.Object{ele})
+				if isError(result) {
+					return result
+				}
+				if isTruthy(result) {
+					return TRUE
+				}
+			}
+			return FALSE
+		},
+	},
+	"all": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArgs("all", args)
+			if err != nil {
+				return err
+			}
+			for _, ele := range arr.Elements {
+				result := applyFunction(env, fn, []object.Object{ele})
+				if isError(result) {
+					return result
+				}
+				if !isTruthy(result) {
+					return FALSE
+				}
+			}
+			return TRUE
+		},
+	},
+	"zip": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) == 0 {
+				return newError("wrong number of arguments for zip. got=0, want at least 1")
+			}
+			arrays := make([]*object.Array, len(args))
+			length := -1
+			for i, arg := range args {
+				arr, ok := arg.(*object.Array)
+				if !ok {
+					return newError("argument to `%s` not supported, got=%s", "zip", arg.Type())
+				}
+				arrays[i] = arr
+				if length == -1 || len(arr.Elements) < length {
+					length = len(arr.Elements)
+				}
+			}
+			result := make([]object.Object, length)
+			for i := range result {
+				tuple := make([]object.Object, len(arrays))
+				for j, arr := range arrays {
+					tuple[j] = arr.Elements[i]
+				}
+				result[i] = track(env, &object.Array{Elements: tuple})
+				if isError(result[i]) {
+					return result[i]
+				}
+			}
+			return track(env, &object.Array{Elements: result})
+		},
+	},
+	"enumerate": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck("enumerate", 1, args); err != nil {
+				return err
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `%s` not supported, got=%s", "enumerate", args[0].Type())
+			}
+			result := make([]object.Object, len(arr.Elements))
+			for i, ele := range arr.Elements {
+				pair := []object.Object{&object.Integer{Value: int64(i)}, ele}
+				result[i] = track(env, &object.Array{Elements: pair})
+				if isError(result[i]) {
+					return result[i]
+				}
+			}
+			return track(env, &object.Array{Elements: result})
+		},
+	},
+	"flat_map": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			arr, fn, err := arrayAndFunctionArgs("flat_map", args)
+			if err != nil {
+				return err
+			}
+			result := []object.Object{}
+			for _, ele := range arr.Elements {
+				mapped := applyFunction(env, fn, []object.Object{ele})
+				if isError(mapped) {
+					return mapped
+				}
+				inner, ok := mapped.(*object.Array)
+				if !ok {
+					return newError("function given to `flat_map` must return ARRAY, got=%s", mapped.Type())
+				}
+				result = append(result, inner.Elements...)
+			}
+			return track(env, &object.Array{Elements: result})
+		},
+	},
+	"sort": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments for sort. got=%d, want=1 or 2", len(args))
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `%s` not supported, got=%s", "sort", args[0].Type())
+			}
+			less := compareObjects
+			if len(args) == 2 {
+				if !isCallable(args[1]) {
+					return newError("argument to `%s` not supported, got=%s", "sort", args[1].Type())
+				}
+				less = func(a, b object.Object) (bool, object.Object) {
+					return callComparator(env, args[1], a, b)
+				}
+			}
+
+			result := make([]object.Object, len(arr.Elements))
+			copy(result, arr.Elements)
+			var sortErr object.Object
+			sort.SliceStable(result, func(i, j int) bool {
+				if sortErr != nil {
+					return false
+				}
+				isLess, err := less(result[i], result[j])
+				if err != nil {
+					sortErr = err
+				}
+				return isLess
+			})
+			if sortErr != nil {
+				return sortErr
+			}
+			return track(env, &object.Array{Elements: result})
+		},
+	},
+	"reverse": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck("reverse", 1, args); err != nil {
+				return err
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `%s` not supported, got=%s", "reverse", args[0].Type())
+			}
+			length := len(arr.Elements)
+			result := make([]object.Object, length)
+			for i, ele := range arr.Elements {
+				result[length-1-i] = ele
+			}
+			return track(env, &object.Array{Elements: result})
+		},
+	},
+	"slice": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 2 && len(args) != 3 {
+				return newError("wrong number of arguments for slice. got=%d, want=2 or 3", len(args))
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `%s` not supported, got=%s", "slice", args[0].Type())
+			}
+			bounds, err := integerArgs("slice", args[1:])
+			if err != nil {
+				return err
+			}
+			end := int64(len(arr.Elements))
+			if len(bounds) == 2 {
+				end = bounds[1]
+			}
+			start, end := sliceBounds(bounds[0], end, len(arr.Elements))
+			result := make([]object.Object, end-start)
+			copy(result, arr.Elements[start:end])
+			return track(env, &object.Array{Elements: result})
+		},
+	},
+	"range": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) < 1 || len(args) > 3 {
+				return newError("wrong number of arguments for range. got=%d, want=1 to 3", len(args))
+			}
+			bounds, err := integerArgs("range", args)
+			if err != nil {
+				return err
+			}
+			start, end, step := int64(0), bounds[0], int64(1)
+			if len(bounds) > 1 {
+				start, end = bounds[0], bounds[1]
+			}
+			if len(bounds) > 2 {
+				step = bounds[2]
+			}
+			if step == 0 {
+				return newError("range step cannot be zero")
+			}
+
+			// The span and step are counted in uint64, which holds the
+			// distance between any two integers and the size of any step
+			var span, size uint64
+			if step > 0 && end > start {
+				span, size = uint64(end)-uint64(start), uint64(step)
+			} else if step < 0 && start > end {
+				span, size = uint64(start)-uint64(end), -uint64(step)
+			}
+			count := uint64(0)
+			if span > 0 {
+				count = (span-1)/size + 1
+			}
+			if count > object.MaxArrayLen {
+				return newError("range of %d elements is too large", count)
+			}
+			if err := env.Runtime().Memory.Check(object.ARRAY_OBJ, object.ArraySize(int64(count))); err != nil {
+				return newError("%s", err)
+			}
+			result := make([]object.Object, count)
+			for i := range result {
+				result[i] = &object.Integer{Value: start + int64(i)*step}
+			}
+			return track(env, &object.Array{Elements: result})
+		},
+	},
+}
+
+func isCallable(obj object.Object) bool {
+	switch obj.(type) {
+	case *object.Function, *object.Builtin:
+		return true
+	default:
+		return false
+	}
+}
+
+// arrayAndFunctionArgs checks the arguments of builtins called as
+// name(array, function)
+func arrayAndFunctionArgs(name string, args []object.Object) (*object.Array, object.Object, object.Object) {
+	if err := builtinLenCheck(name, 2, args); err != nil {
+		return nil, nil, err
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return nil, nil, newError("argument to `%s` not supported, got=%s", name, args[0].Type())
+	}
+	if !isCallable(args[1]) {
+		return nil, nil, newError("argument to `%s` not supported, got=%s", name, args[1].Type())
+	}
+	return arr, args[1], nil
+}
+
+func integerArgs(name string, args []object.Object) ([]int64, object.Object) {
+	ints := make([]int64, len(args))
+	for i, arg := range args {
+		integer, ok := arg.(*object.Integer)
+		if !ok {
+			return nil, newError("argument to `%s` not supported, got=%s", name, arg.Type())
+		}
+		ints[i] = integer.Value
+	}
+	return ints, nil
+}
+
+// sliceBounds resolves negative indices from the end of a sequence of
+// length elements, and clamps the bounds to the sequence
+func sliceBounds(start, end int64, length int) (int64, int64) {
+	clamp := func(idx int64) int64 {
+		if idx < 0 {
+			idx += int64(length)
+		}
+		return max(0, min(idx, int64(length)))
+	}
+	start, end = clamp(start), clamp(end)
+	if end < start {
+		end = start
+	}
+	return start, end
+}
+
+// callComparator calls a sort comparator, which either returns whether a
+// belongs before b, or an integer that is negative when it does
+func callComparator(env *object.Environment, fn, a, b object.Object) (bool, object.Object) {
+	result := applyFunction(env, fn, []object.Object{a, b})
+	switch result := result.(type) {
+	case *object.Error:
+		return false, result
+	case *object.Boolean:
+		return result.Value, nil
+	case *object.Integer:
+		return result.Value < 0, nil
+	default:
+		return false, newError("comparator given to `sort` must return BOOLEAN or INTEGER, got=%s", result.Type())
+	}
+}
+
+// compareObjects is the default ordering used by sort, for arrays of numbers,
+// strings or arrays
+func compareObjects(a, b object.Object) (bool, object.Object) {
+	order, err := compare(a, b)
+	return order < 0, err
+}
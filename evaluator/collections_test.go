@@ -0,0 +1,90 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+func TestCollectionBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`map([1, 2, 3], fn(x) { x * 2 })`, []any{2, 4, 6}},
+		{`map([], fn(x) { x })`, []any{}},
+		{`map(["a", "b"], len)`, []any{1, 1}},
+		{`filter([1, 2, 3, 4], fn(x) { x > 2 })`, []any{3, 4}},
+		{`reduce([1, 2, 3, 4], fn(acc, x) { acc + x }, 10)`, 20},
+		{`reduce([1, 2, 3, 4], fn(acc, x) { acc * x })`, 24},
+		{`reduce([], fn(acc, x) { acc + x }, 0)`, 0},
+		{`find([1, 2, 3, 4], fn(x) { x > 2 })`, 3},
+		{`find([1, 2], fn(x) { x > 2 })`, nil},
+		{`any([1, 2, 3], fn(x) { x == 2 })`, true},
+		{`any([], fn(x) { true })`, false},
+		{`all([1, 2, 3], fn(x) { x > 0 })`, true},
+		{`all([1, 2, 3], fn(x) { x > 1 })`, false},
+		{`zip([1, 2, 3], ["a", "b"])`, []any{[]any{1, "a"}, []any{2, "b"}}},
+		{`enumerate(["a", "b"])`, []any{[]any{0, "a"}, []any{1, "b"}}},
+		{`flat_map([1, 2], fn(x) { [x, x * 10] })`, []any{1, 10, 2, 20}},
+		{`sort([3, 1, 2])`, []any{1, 2, 3}},
+		{`sort(["b", "c", "a"])`, []any{"a", "b", "c"}},
+		{`sort([[2], [1, 5], [1]])`, []any{[]any{1}, []any{1, 5}, []any{2}}},
+		{`sort([3, 1, 2], fn(a, b) { a > b })`, []any{3, 2, 1}},
+		{`sort([3, 1, 2], fn(a, b) { b - a })`, []any{3, 2, 1}},
+		{`let a = [3, 1, 2]; sort(a); a`, []any{3, 1, 2}},
+		{`reverse([1, 2, 3])`, []any{3, 2, 1}},
+		{`slice([1, 2, 3, 4], 1, 3)`, []any{2, 3}},
+		{`slice([1, 2, 3, 4], 2)`, []any{3, 4}},
+		{`slice([1, 2, 3, 4], -2)`, []any{3, 4}},
+		{`slice([1, 2, 3, 4], 3, 1)`, []any{}},
+		{`slice([1, 2, 3, 4], 0, 100)`, []any{1, 2, 3, 4}},
+		{`range(4)`, []any{0, 1, 2, 3}},
+		{`range(2, 5)`, []any{2, 3, 4}},
+		{`range(0, 10, 3)`, []any{0, 3, 6, 9}},
+		{`range(5, 0, -2)`, []any{5, 3, 1}},
+		{`range(5, 0)`, []any{}},
+		{`range(0, 9223372036854775807, 9223372036854775807)`, []any{0}},
+		{`range(9223372036854775806, 9223372036854775807)`, []any{9223372036854775806}},
+		{`range(-9223372036854775807, 9223372036854775807, 9223372036854775807)`, []any{-9223372036854775807, 0}},
+		{`range(0, -9223372036854775807 - 1, -9223372036854775807 - 1)`, []any{0}},
+		{`range(9223372036854775807, -9223372036854775807 - 1, -9223372036854775807 - 1)`, []any{9223372036854775807, -1}},
+		{`reduce(map(range(1, 101), fn(x) { x * x }), fn(a, b) { a + b })`, 338350},
+	}
+	for _, tt := range tests {
+		testLiteralObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestCollectionBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map([1, 2], fn(x) { x + true })`, "type mismatch: INTEGER + BOOLEAN"},
+		{`filter([1], fn(x) { y })`, "identity not found: y"},
+		{`map(1, fn(x) { x })`, "argument to `map` not supported, got=INTEGER"},
+		{`map([1], 1)`, "argument to `map` not supported, got=INTEGER"},
+		{`map([1])`, "wrong number of arguments for map. got=1, want=2"},
+		{`reduce([], fn(a, b) { a })`, "reduce of empty array with no initial value"},
+		{`flat_map([1], fn(x) { x })`, "function given to `flat_map` must return ARRAY, got=INTEGER"},
+		{`sort([1, "a"])`, "cannot compare STRING and INTEGER"},
+		{`sort([2, 1], fn(a, b) { "no" })`, "comparator given to `sort` must return BOOLEAN or INTEGER, got=STRING"},
+		{`sort([2, 1], fn(a, b) { a + true })`, "type mismatch: INTEGER + BOOLEAN"},
+		{`range(1, 2, 0)`, "range step cannot be zero"},
+		{`range("a")`, "argument to `range` not supported, got=STRING"},
+		{`range(-9223372036854775807, 9223372036854775807)`, "range of 18446744073709551614 elements is too large"},
+		{`range(9223372036854775807, -9223372036854775807 - 1, -1)`, "range of 18446744073709551615 elements is too large"},
+		{`zip([1], 2)`, "argument to `zip` not supported, got=INTEGER"},
+	}
+	for _, tt := range tests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestRangeMemoryLimit(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Runtime().Memory.Limit = 1 << 20
+	testErrorObject(t, testEvalEnv(`range(1000000000)`, env),
+		"memory limit exceeded: allocating 16000000024 bytes for ARRAY with 0 of 1048576 bytes in use")
+}
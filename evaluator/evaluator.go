@@ -2,12 +2,17 @@ package evaluator
 
 import (
 	"bytes"
+	"cmp"
 	"fmt"
+	"math"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/waridh/go-monkey-interpreter/ast"
 	"github.com/waridh/go-monkey-interpreter/functools"
+	"github.com/waridh/go-monkey-interpreter/lexer"
 	"github.com/waridh/go-monkey-interpreter/object"
+	"github.com/waridh/go-monkey-interpreter/parser"
 )
 
 // For static values, we can just refer to the same objects
@@ -17,7 +22,27 @@ var (
 	NULL  = &object.Null{}
 )
 
+// Eval evaluates node in env. Errors are marked with the node that made
+// them, and the Observer of the run, if any, is told about every node.
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	observer := env.Runtime().Observer
+	if observer != nil {
+		observer.Enter(node, env)
+	}
+	result := evalNode(node, env)
+	if err, ok := result.(*object.Error); ok && err.Node == nil {
+		err.Node = node
+		if observer != nil {
+			observer.Error(node, err)
+		}
+	}
+	if observer != nil {
+		observer.Exit(node, result)
+	}
+	return result
+}
+
+func evalNode(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
 		return evalProgram(node.Statements, env)
@@ -31,27 +56,28 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return val
 		}
 		return evalLetStatement(node, val, env)
+	case *ast.ImportStatement:
+		return evalImportStatement(node, env)
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.Boolean:
 		return booleanObjectOfNativeBool(node.Value)
 	case *ast.StringLiteral:
-		return &object.String{Value: node.Value}
+		return track(env, &object.String{Value: node.Value})
 	case *ast.Identifier:
-		if val, ok := env.Get(node.Value); ok {
-			return val
-		}
-		if builtin, ok := builtins[node.Value]; ok {
-			return builtin
-		}
-		return newError("identity not found: %s", node.Value)
+		return evalIdentifier(node, env)
 	case *ast.FunctionLiteral:
 		params := node.Parameter
 		body := node.Body
 		return &object.Function{Parameter: params, Body: body, Env: env}
 	case *ast.ArrayLiteral:
 		ele := evalExpressions(node.Elements, env)
-		return &object.Array{Elements: ele}
+		if len(ele) == 1 && isError(ele[0]) {
+			return ele[0]
+		}
+		return track(env, &object.Array{Elements: ele})
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
 	case *ast.PrefixExpression:
@@ -69,7 +95,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(right) {
 			return right
 		}
-		return evalInfixOperator(node.Operator, left, right)
+		return track(env, evalInfixOperator(node.Operator, left, right))
 	case *ast.BlockStatement:
 		return evalBlockStatements(node.Statements, env)
 	case *ast.IfExpression:
@@ -83,7 +109,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
-		return applyFunction(function, args)
+		return applyFunction(env, function, args)
 	case *ast.IndexExpression:
 		array := Eval(node.Left, env)
 		if isError(array) {
@@ -93,35 +119,73 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(index) {
 			return index
 		}
-		return evalIndex(array, index)
+		return evalIndex(array, index, env)
+	case *ast.SliceExpression:
+		return evalSliceExpression(node, env)
+	case *ast.MemberExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		return evalMember(left, node.Member.Value, env)
 	default:
 		return nil
 	}
 }
 
+// Bindings shadow builtins, which are looked up in the registry of the run
+func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
+	if val, ok := env.Get(node.Value); ok {
+		return val
+	}
+	registry := builtinsOf(env)
+	if builtin, ok := registry.Lookup(node.Value); ok {
+		return builtin
+	}
+	if capability, ok := registry.Disabled(node.Value); ok {
+		return newError("%s is unavailable: the %s capability is disabled", node.Value, capability)
+	}
+	return newError("identity not found: %s", node.Value)
+}
+
+func evalMember(left object.Object, member string, env *object.Environment) object.Object {
+	switch l := left.(type) {
+	case *object.Module:
+		value, ok := l.Members[member]
+		if !ok {
+			return newError("module %s has no member %s", l.Name, member)
+		}
+		return value
+	case *object.Hash:
+		return evalIndex(l, &object.String{Value: member}, env)
+	default:
+		return newError("%s does not have members", left.Type())
+	}
+}
+
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	hash := &object.Hash{Pairs: map[object.HashKey]object.HashPair{}}
-	for key, value := range node.Pairs {
+	hash := object.NewHash(len(node.Keys))
+	for _, key := range node.Keys {
 		keyObj := Eval(key, env)
 		if isError(keyObj) {
 			return keyObj
 		}
-		hashKey, ok := keyObj.(object.Hashable)
+		hashKey, ok := object.AsHashable(keyObj)
 		if !ok {
 			return newError("%s object not hashable", keyObj.Type())
 		}
 
-		valueObj := Eval(value, env)
+		valueObj := Eval(node.Pairs[key], env)
 		if isError(valueObj) {
 			return valueObj
 		}
 
-		hash.Pairs[hashKey.HashKey()] = object.HashPair{Key: keyObj, Value: valueObj}
+		hash.Set(hashKey, valueObj)
 	}
-	return hash
+	return track(env, hash)
 }
 
-func evalIndex(indexable object.Object, index object.Object) object.Object {
+func evalIndex(indexable object.Object, index object.Object, env *object.Environment) object.Object {
 	switch a := indexable.(type) {
 	case *object.Array:
 		if index.Type() != object.INTEGER_OBJ {
@@ -138,12 +202,21 @@ func evalIndex(indexable object.Object, index object.Object) object.Object {
 		}
 
 		return a.Elements[idx]
+	case *object.String:
+		if index.Type() != object.INTEGER_OBJ {
+			return newError("%s can only be indexed using %s", a.Type(), object.INTEGER_OBJ)
+		}
+		char, ok := runeAt(a.Value, index.(*object.Integer).Value)
+		if !ok {
+			return NULL
+		}
+		return track(env, &object.String{Value: char})
 	case *object.Hash:
-		hashable, ok := index.(object.Hashable)
+		hashable, ok := object.AsHashable(index)
 		if !ok {
 			return newError("%s is not hashable", index.Type())
 		}
-		hashPair, ok := a.Pairs[hashable.HashKey()]
+		hashPair, ok := a.Get(hashable)
 		if !ok {
 			return NULL
 		}
@@ -153,7 +226,85 @@ func evalIndex(indexable object.Object, index object.Object) object.Object {
 	}
 }
 
-func applyFunction(function object.Object, args []object.Object) object.Object {
+// runeAt finds the rune at idx in s, as strings are indexed by rune rather
+// than by byte. Negative indexes count from the end. The string is walked
+// rather than converted to runes, to avoid copying it for every index.
+func runeAt(s string, idx int64) (string, bool) {
+	if idx < 0 {
+		idx += int64(utf8.RuneCountInString(s))
+		if idx < 0 {
+			return "", false
+		}
+	}
+	i := int64(0)
+	for _, r := range s {
+		if i == idx {
+			return string(r), true
+		}
+		i++
+	}
+	return "", false
+}
+
+// evalSliceExpression slices arrays, and strings by rune. Negative bounds
+// count from the end, and bounds past either end are clamped.
+func evalSliceExpression(node *ast.SliceExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+	start, err := evalSliceBound(node.Start, left, 0, env)
+	if err != nil {
+		return err
+	}
+	end, err := evalSliceBound(node.End, left, math.MaxInt64, env)
+	if err != nil {
+		return err
+	}
+
+	switch l := left.(type) {
+	case *object.Array:
+		start, end := sliceBounds(start, end, len(l.Elements))
+		result := make([]object.Object, end-start)
+		copy(result, l.Elements[start:end])
+		return track(env, &object.Array{Elements: result})
+	case *object.String:
+		runes := []rune(l.Value)
+		start, end := sliceBounds(start, end, len(runes))
+		return track(env, &object.String{Value: string(runes[start:end])})
+	default:
+		return newError("%s does not support slicing", left.Type())
+	}
+}
+
+// evalSliceBound evaluates one bound of a slice, which is missing when the
+// slice is open on that side
+func evalSliceBound(bound ast.Expression, left object.Object, missing int64, env *object.Environment) (int64, object.Object) {
+	if bound == nil {
+		return missing, nil
+	}
+	obj := Eval(bound, env)
+	if isError(obj) {
+		return 0, obj
+	}
+	integer, ok := obj.(*object.Integer)
+	if !ok {
+		return 0, newError("%s can only be sliced using %s, got=%s", left.Type(), object.INTEGER_OBJ, obj.Type())
+	}
+	return integer.Value, nil
+}
+
+// Apply calls function with args, as a call from code evaluated in env
+// would. It lets Go code such as builtins registered by hosts call back into
+// Monkey functions.
+func Apply(env *object.Environment, function object.Object, args []object.Object) object.Object {
+	return unwrapReturnValue(applyFunction(env, function, args))
+}
+
+// applyFunction calls function with args. env is the environment of the
+// caller, which builtins use to reach the runtime.
+func applyFunction(env *object.Environment, function object.Object, args []object.Object) object.Object {
+	observer := env.Runtime().Observer
 	switch fn := function.(type) {
 	case *object.Function:
 		newEnv := object.NewEnclosedEnvironment(fn.Env)
@@ -166,16 +317,35 @@ func applyFunction(function object.Object, args []object.Object) object.Object {
 			out.WriteString(strings.Join(expected, ", "))
 			out.WriteString("\n\tgot: ")
 			out.WriteString(strings.Join(got, ", "))
-			return newError(out.String())
+			return newError("%s", out.String())
+		}
+		if observer != nil {
+			observer.Call(fn, args)
 		}
 		for idx, arg := range args {
-			newEnv.Set(fn.Parameter[idx].Value, arg)
+			bind(newEnv, fn.Parameter[idx].Value, arg)
+		}
+		debugger := env.Runtime().Debugger
+		if debugger != nil {
+			debugger.Call(fn, newEnv)
+		}
+		evaluated := unwrapReturnValue(Eval(fn.Body, newEnv))
+		if debugger != nil {
+			debugger.Return(fn, evaluated)
 		}
-		evaluated := Eval(fn.Body, newEnv)
-		return unwrapReturnValue(evaluated)
+		if observer != nil {
+			observer.Return(fn, evaluated)
+		}
+		return evaluated
 
 	case *object.Builtin:
-		return fn.Fn(args...)
+		if observer == nil {
+			return fn.Fn(env, args...)
+		}
+		observer.Call(fn, args)
+		result := fn.Fn(env, args...)
+		observer.Return(fn, result)
+		return result
 
 	default:
 		return newError("not a function: %s", function.Type())
@@ -199,7 +369,11 @@ func evalExpressions(exprs []ast.Expression, env *object.Environment) []object.O
 
 func evalProgram(node []ast.Statement, env *object.Environment) object.Object {
 	var result object.Object
+	debugger := env.Runtime().Debugger
 	for _, stmt := range node {
+		if debugger != nil {
+			debugger.Statement(stmt, env)
+		}
 		result = Eval(stmt, env)
 		switch res := result.(type) {
 		case *object.ReturnValue:
@@ -214,7 +388,11 @@ func evalProgram(node []ast.Statement, env *object.Environment) object.Object {
 
 func evalBlockStatements(node []ast.Statement, env *object.Environment) object.Object {
 	var result object.Object
+	debugger := env.Runtime().Debugger
 	for _, stmt := range node {
+		if debugger != nil {
+			debugger.Statement(stmt, env)
+		}
 		result = Eval(stmt, env)
 		if result.Type() == object.RETURN_VALUE_OBJ || result.Type() == object.ERROR_OBJ {
 			return result
@@ -225,7 +403,78 @@ func evalBlockStatements(node []ast.Statement, env *object.Environment) object.O
 }
 
 func evalLetStatement(node *ast.LetStatement, val object.Object, env *object.Environment) object.Object {
-	return env.Set(node.Name.Value, val)
+	// Functions are known by the first name they are bound to
+	if fn, ok := val.(*object.Function); ok && fn.Name == "" {
+		fn.Name = node.Name.Value
+	}
+	return bind(env, node.Name.Value, val)
+}
+
+// bind binds name to value in env, telling the Observer of the run
+func bind(env *object.Environment, name string, value object.Object) object.Object {
+	if observer := env.Runtime().Observer; observer != nil {
+		observer.Bind(name, value, env)
+	}
+	return env.Set(name, value)
+}
+
+func evalImportStatement(node *ast.ImportStatement, env *object.Environment) object.Object {
+	imported := importModule(object.ModuleName(env.Module(), node.Path.Value), env)
+	if isError(imported) {
+		return imported
+	}
+	module := imported.(*object.Module)
+
+	if node.Alias != nil {
+		return bind(env, node.Alias.Value, module)
+	}
+	for _, name := range node.Names {
+		value, ok := module.Members[name.Value]
+		if !ok {
+			return newError("module %s does not export %s", module.Name, name.Value)
+		}
+		bind(env, name.Value, value)
+	}
+	return module
+}
+
+// importModule evaluates the module called name in an environment of its
+// own, and returns its exported bindings. Modules are only evaluated the
+// first time they are imported during a run.
+func importModule(name string, env *object.Environment) object.Object {
+	modules := env.Runtime().Modules
+	if module, ok := modules.Get(name); ok {
+		return module
+	}
+	if err := modules.Begin(name); err != nil {
+		return newError("%s", err)
+	}
+	var module *object.Module
+	defer func() { modules.End(module) }()
+
+	src, err := modules.Loader.Load(name)
+	if err != nil {
+		return newError("cannot import %s: %s", name, err)
+	}
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return newError("cannot import %s: parser errors:\n\t%s", name, strings.Join(p.Errors(), "\n\t"))
+	}
+
+	moduleEnv := object.NewModuleEnvironment(env.Runtime(), name)
+	if result := Eval(program, moduleEnv); isError(result) {
+		return newError("in module %s: %s", name, result.(*object.Error).Message)
+	}
+
+	members := map[string]object.Object{}
+	for _, stmt := range program.Statements {
+		if let, ok := stmt.(*ast.LetStatement); ok && let.Exported {
+			members[let.Name.Value], _ = moduleEnv.Get(let.Name.Value)
+		}
+	}
+	module = &object.Module{Name: name, Members: members}
+	return module
 }
 
 func unwrapReturnValue(evaluated object.Object) object.Object {
@@ -267,6 +516,12 @@ func evalIfExpression(node *ast.IfExpression, env *object.Environment) object.Ob
 	}
 }
 
+// IsTruthy reports whether obj counts as true in a condition, such as that
+// of an if expression
+func IsTruthy(obj object.Object) bool {
+	return isTruthy(obj)
+}
+
 func isTruthy(cond object.Object) bool {
 	switch cond {
 	case NULL:
@@ -289,16 +544,22 @@ func isError(obj object.Object) bool {
 
 func evalInfixOperator(operator string, left object.Object, right object.Object) object.Object {
 	switch {
+	case operator == "==":
+		return booleanObjectOfNativeBool(object.Equal(left, right))
+	case operator == "!=":
+		return booleanObjectOfNativeBool(!object.Equal(left, right))
+	case left.Type() == object.FLOAT_OBJ && isNumber(right), right.Type() == object.FLOAT_OBJ && isNumber(left):
+		return evalInfixFloatExpression(operator, toFloat(left), toFloat(right))
 	case left.Type() == right.Type():
 		switch {
 		case left.Type() == object.INTEGER_OBJ:
 			leftVal := left.(*object.Integer).Value
 			rightVal := right.(*object.Integer).Value
 			return evalInfixIntegerExpression(operator, leftVal, rightVal)
-		case left.Type() == object.BOOLEAN_OBJ:
-			return evalInfixBooleanExpression(operator, left, right)
 		case left.Type() == object.STRING_OBJ:
 			return evalInfixStringExpression(operator, left, right)
+		case left.Type() == object.ARRAY_OBJ:
+			return evalInfixArrayExpression(operator, left, right)
 		default:
 			return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 		}
@@ -324,15 +585,59 @@ func evalInfixStringExpression(operator string, left, right object.Object) objec
 	switch operator {
 	case "+":
 		return &object.String{Value: leftVal + rightVal}
-	case "==":
-		return &object.Boolean{Value: leftVal == rightVal}
-	case "!=":
-		return &object.Boolean{Value: leftVal != rightVal}
+	case "<":
+		return booleanObjectOfNativeBool(leftVal < rightVal)
+	case ">":
+		return booleanObjectOfNativeBool(leftVal > rightVal)
 	default:
 		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
+// Arrays are ordered lexicographically
+func evalInfixArrayExpression(operator string, left, right object.Object) object.Object {
+	if operator != "<" && operator != ">" {
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+	order, err := compare(left, right)
+	if err != nil {
+		return err
+	}
+	if operator == "<" {
+		return booleanObjectOfNativeBool(order < 0)
+	}
+	return booleanObjectOfNativeBool(order > 0)
+}
+
+// compare orders numbers, strings, and arrays of comparable elements,
+// returning a negative number when a comes before b, zero when they are
+// equal, and a positive number otherwise
+func compare(a, b object.Object) (int, object.Object) {
+	switch {
+	case isNumber(a) && isNumber(b):
+		if a.Type() == object.INTEGER_OBJ && b.Type() == object.INTEGER_OBJ {
+			return cmp.Compare(a.(*object.Integer).Value, b.(*object.Integer).Value), nil
+		}
+		return cmp.Compare(toFloat(a), toFloat(b)), nil
+	case a.Type() == object.STRING_OBJ && b.Type() == object.STRING_OBJ:
+		return strings.Compare(a.(*object.String).Value, b.(*object.String).Value), nil
+	case a.Type() == object.ARRAY_OBJ && b.Type() == object.ARRAY_OBJ:
+		left, right := a.(*object.Array).Elements, b.(*object.Array).Elements
+		for i := 0; i < len(left) && i < len(right); i++ {
+			if object.Equal(left[i], right[i]) {
+				continue
+			}
+			order, err := compare(left[i], right[i])
+			if err != nil || order != 0 {
+				return order, err
+			}
+		}
+		return cmp.Compare(len(left), len(right)), nil
+	default:
+		return 0, newError("cannot compare %s and %s", a.Type(), b.Type())
+	}
+}
+
 func evalInfixIntegerExpression(operator string, left int64, right int64) object.Object {
 	switch operator {
 	case "+":
@@ -342,28 +647,36 @@ func evalInfixIntegerExpression(operator string, left int64, right int64) object
 	case "*":
 		return &object.Integer{Value: left * right}
 	case "/":
+		if right == 0 {
+			return newError("division by zero")
+		}
 		return &object.Integer{Value: left / right}
 	case "<":
 		return booleanObjectOfNativeBool(left < right)
 	case ">":
 		return booleanObjectOfNativeBool(left > right)
-	case "==":
-		return booleanObjectOfNativeBool(left == right)
-	case "!=":
-		return booleanObjectOfNativeBool(left != right)
 	default:
 		return newError("unknown operator: %s %s %s", object.INTEGER_OBJ, operator, object.INTEGER_OBJ)
 	}
 }
 
-func evalInfixBooleanExpression(operator string, left object.Object, right object.Object) object.Object {
+// Integers are promoted to floats when they meet a float
+func evalInfixFloatExpression(operator string, left float64, right float64) object.Object {
 	switch operator {
-	case "==":
-		return booleanObjectOfNativeBool(left == right)
-	case "!=":
-		return booleanObjectOfNativeBool(left != right)
+	case "+":
+		return &object.Float{Value: left + right}
+	case "-":
+		return &object.Float{Value: left - right}
+	case "*":
+		return &object.Float{Value: left * right}
+	case "/":
+		return &object.Float{Value: left / right}
+	case "<":
+		return booleanObjectOfNativeBool(left < right)
+	case ">":
+		return booleanObjectOfNativeBool(left > right)
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError("unknown operator: %s %s %s", object.FLOAT_OBJ, operator, object.FLOAT_OBJ)
 	}
 }
 
@@ -387,11 +700,38 @@ func evalPrefixMinus(right object.Object) object.Object {
 			return newError("unknown operator: %s%s", "-", right.Type())
 		}
 		return &object.Integer{Value: -v.Value}
+	case *object.Float:
+		return &object.Float{Value: -v.Value}
 	default:
 		return newError("unknown operator: %s%s", "-", right.Type())
 	}
 }
 
+// track charges a newly allocated object against the memory of the run. If
+// the memory limit has been reached, an error is returned in its place.
+func track(env *object.Environment, obj object.Object) object.Object {
+	if err := env.Runtime().Memory.Track(obj); err != nil {
+		return newError("%s", err)
+	}
+	return obj
+}
+
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+// toFloat widens a numeric object into a float64
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.Float:
+		return obj.Value
+	default:
+		return 0
+	}
+}
+
 func newError(format string, a ...any) *object.Error {
 	return &object.Error{Message: fmt.Sprintf(format, a...)}
 }
@@ -1,9 +1,12 @@
 package evaluator
 
 import (
+	"bytes"
 	"fmt"
+	"sync"
 	"testing"
 
+	"github.com/waridh/go-monkey-interpreter/ast"
 	"github.com/waridh/go-monkey-interpreter/lexer"
 	"github.com/waridh/go-monkey-interpreter/object"
 	"github.com/waridh/go-monkey-interpreter/parser"
@@ -163,6 +166,78 @@ func TestEvalBooleanExpression(t *testing.T) {
 	}
 }
 
+func TestEqualityAndOrdering(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`[1, 2] == [1, 2]`, true},
+		{`[1, 2] == [2, 1]`, false},
+		{`[1, 2] != [1, 2, 3]`, true},
+		{`[[1, "a"], [true]] == [[1, "a"], [true]]`, true},
+		{`[] == []`, true},
+		{`{"a": 1, "b": [2]} == {"b": [2], "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"a": 1, "b": 2}`, false},
+		{`{} != {}`, false},
+		{`let f = fn(x) { x }; f == f`, true},
+		{`fn(x) { x } == fn(x) { x }`, false},
+		{`len == len`, true},
+		{`first([]) == first([])`, true},
+		{`first([]) == 0`, false},
+		{`1 == true`, false},
+		{`"1" != 1`, true},
+		{`"abc" < "abd"`, true},
+		{`"b" > "abc"`, true},
+		{`"a" < "a"`, false},
+		{`[1, 2] < [1, 3]`, true},
+		{`[1, 2] < [1, 2, 0]`, true},
+		{`[2] > [1, 5]`, true},
+		{`[1, 2] > [1, 2]`, false},
+		{`[[1, "b"]] > [[1, "a"]]`, true},
+		{`[true, 1] < [true, 2]`, true},
+	}
+
+	for _, tt := range tests {
+		testLiteralObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{"half + half", 1.0},
+		{"half * 3", 1.5},
+		{"3 - half", 2.5},
+		{"1 / quarter", 4.0},
+		{"-half", -0.5},
+		{"half < 1", true},
+		{"half > quarter", true},
+		{"half == quarter * 2", true},
+		{"half != 0", true},
+		{"1.5 + 2", 3.5},
+		{"0.1 * 10", 1.0},
+		{"1 == 1.0", true},
+		{"1 != 1.5", true},
+		{"9007199254740993 == 9007199254740992.0", false},
+		{"[1] == [1.0]", true},
+		{`{"a": 1} == {"a": 1.0}`, true},
+		{`{1: "a"} == {1.0: "a"}`, true},
+		{`{[1]: "a"} == {[1.0]: "a"}`, true},
+		{`{"a": [2]} != {"a": [2.0]}`, false},
+		{"[1, 2.5] < [1.0, 3]", true},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.Set("half", &object.Float{Value: 0.5})
+		env.Set("quarter", &object.Float{Value: 0.25})
+		testLiteralObject(t, testEvalEnv(tt.input, env), tt.expected)
+	}
+}
+
 func TestStringExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -362,6 +437,10 @@ func TestErrorHandling(t *testing.T) {
 			"5 + true; 5;",
 			"type mismatch: INTEGER + BOOLEAN",
 		},
+		{
+			"let x = 0; 10 / x",
+			"division by zero",
+		},
 		{
 			"-true; 6;",
 			"unknown operator: -BOOLEAN",
@@ -416,6 +495,26 @@ func TestErrorHandling(t *testing.T) {
 			`{"name":"Monkey"}[fn(x) { x }];`,
 			"FUNCTION is not hashable",
 		},
+		{
+			`[1] < ["a"]`,
+			"cannot compare INTEGER and STRING",
+		},
+		{
+			`[true] < [false]`,
+			"cannot compare BOOLEAN and BOOLEAN",
+		},
+		{
+			`{} < {}`,
+			"unknown operator: HASH < HASH",
+		},
+		{
+			`"a" < 1`,
+			"type mismatch: STRING < INTEGER",
+		},
+		{
+			`{[1, fn(x) { x }]: 1};`,
+			"ARRAY object not hashable",
+		},
 	}
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
@@ -698,6 +797,114 @@ func TestBuiltinFunction(t *testing.T) {
 	}
 }
 
+func TestBuiltinRegistry(t *testing.T) {
+	registry := NewBuiltins()
+	registry.Register("text.shout", func(env *object.Environment, args ...object.Object) object.Object {
+		return &object.String{Value: args[0].Inspect() + "!"}
+	})
+	registry.Disable(object.CapOutput)
+
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`text.shout("hi")`, "hi!"},
+		{`let text = {"shout": 1}; text.shout`, 1},
+		{`len("four")`, 4},
+		{`{"a": 5}.a`, 5},
+		{`{"a": 5}.b`, nil},
+		{`puts("hi")`, "puts is unavailable: the output capability is disabled"},
+		{`text.whisper("hi")`, "module text has no member whisper"},
+		{`5.a`, "INTEGER does not have members"},
+	}
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.Runtime().Builtins = registry
+		evaluated := testEvalEnv(tt.input, env)
+		if err, ok := evaluated.(*object.Error); ok {
+			testErrorObject(t, err, tt.expected.(string))
+		} else {
+			testLiteralObject(t, evaluated, tt.expected)
+		}
+	}
+
+	if _, ok := testEval(`text`).(*object.Error); !ok {
+		t.Errorf("Expected registering into a new registry to leave the default alone")
+	}
+}
+
+func TestImportStatement(t *testing.T) {
+	loader := object.MapLoader{
+		"math.mk": `
+      export let square = fn(x) { x * x };
+      let helper = fn(x) { x };
+      export let cube = fn(x) { square(x) * helper(x) };
+      `,
+		"consts.mk":   `import "math.mk" as m; export let nine = m.square(3);`,
+		"a.mk":        `import "b.mk" as b;`,
+		"b.mk":        `import "c.mk" as c;`,
+		"c.mk":        `import "a.mk" as a;`,
+		"broken.mk":   `export let x = 5 + true;`,
+		"bad.mk":      `let = 5;`,
+		"lib/a.mk":    `import "b.mk" as b; export let value = b.value + 1;`,
+		"lib/b.mk":    `export let value = 10;`,
+		"lib/up.mk":   `import "../math.mk" as m; export let four = m.square(2);`,
+		"lib/lazy.mk": `export let get = fn() { import "b.mk" as b; b.value };`,
+	}
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`import "math.mk" as m; m.square(4)`, 16},
+		{`import "./math.mk" as m; m.cube(2)`, 8},
+		{`import { square, cube } from "math.mk"; square(3) + cube(1)`, 10},
+		{`import "consts.mk" as c; c.nine`, 9},
+		{`import "math.mk" as m; m.helper(1)`, "module math.mk has no member helper"},
+		{`import { helper } from "math.mk";`, "module math.mk does not export helper"},
+		{`import "missing.mk" as m;`, `cannot import missing.mk: module "missing.mk" not found`},
+		{`import "a.mk" as a;`, "in module a.mk: in module b.mk: in module c.mk: import cycle: a.mk -> b.mk -> c.mk -> a.mk"},
+		{`import "broken.mk" as b;`, "in module broken.mk: type mismatch: INTEGER + BOOLEAN"},
+		{`import "bad.mk" as b;`, "cannot import bad.mk: parser errors:\n\tParser expected IDENT but got =\n\tno prefix parse function for = found"},
+		{`import "lib/a.mk" as a; a.value`, 11},
+		{`import "lib/up.mk" as u; u.four`, 4},
+		{`import { get } from "lib/lazy.mk"; get()`, 10},
+		{`import "../math.mk" as m;`, `cannot import ../math.mk: module "../math.mk" not found`},
+	}
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.Runtime().Modules.Loader = loader
+		evaluated := testEvalEnv(tt.input, env)
+		if err, ok := evaluated.(*object.Error); ok {
+			testErrorObject(t, err, tt.expected.(string))
+		} else {
+			testLiteralObject(t, evaluated, tt.expected)
+		}
+	}
+
+	if err, ok := testEval(`import "math.mk" as m;`).(*object.Error); !ok ||
+		err.Message != "cannot import math.mk: no module loader is configured" {
+		t.Errorf("Expected imports to fail without a loader, got %v", err)
+	}
+}
+
+func TestImportEvaluatesOnce(t *testing.T) {
+	var out bytes.Buffer
+	env := object.NewEnvironment()
+	env.Runtime().Stdout = &out
+	env.Runtime().Modules.Loader = object.MapLoader{
+		"noisy.mk": `puts("loading"); export let value = 1;`,
+	}
+
+	testLiteralObject(t, testEvalEnv(`
+    import "noisy.mk" as a;
+    import { value } from "noisy.mk";
+    import "./noisy.mk" as b;
+    a.value + value + b.value`, env), 3)
+	if out.String() != "loading\n" {
+		t.Errorf("Expected the module to be evaluated once, got output %q", out.String())
+	}
+}
+
 func TestArrayLiterals(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -727,29 +934,36 @@ func TestHashLiterals(t *testing.T) {
   false: 6
   }
   `
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		TRUE.HashKey():                             5,
-		FALSE.HashKey():                            6,
+	expected := []struct {
+		key   object.Hashable
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{&object.Integer{Value: 4}, 4},
+		{TRUE, 5},
+		{FALSE, 6},
 	}
 	evaluated := testEval(input)
 	result, ok := evaluated.(*object.Hash)
 	if !ok {
 		t.Fatalf("Could not cast to Hash. Got %T. (%+v)", evaluated, evaluated)
 	}
-	if len(result.Pairs) != len(expected) {
-		t.Errorf("Expected %d elements, got %d", len(expected), len(result.Pairs))
+	if result.Len() != len(expected) {
+		t.Errorf("Expected %d elements, got %d", len(expected), result.Len())
 	}
-	for key, value := range expected {
-		pair, ok := result.Pairs[key]
+	for idx, tt := range expected {
+		pair, ok := result.Get(tt.key)
 		if !ok {
-			t.Errorf("No pair for expected key")
+			t.Errorf("No pair for expected key %s", tt.key.Inspect())
+			continue
+		}
+		if result.Pairs()[idx].Key.Inspect() != tt.key.Inspect() {
+			t.Errorf("Expected key %d to be %s, got %s", idx, tt.key.Inspect(), result.Pairs()[idx].Key.Inspect())
 		}
 
-		testLiteralObject(t, pair.Value, value)
+		testLiteralObject(t, pair.Value, tt.value)
 	}
 }
 
@@ -786,6 +1000,42 @@ func TestHashIndexExpression(t *testing.T) {
 			`{false : 5}[false]`,
 			5,
 		},
+		{
+			`{[1, "a"] : 5}[[1, "a"]]`,
+			5,
+		},
+		{
+			`{[1, [2]] : 5}[[1, [2]]]`,
+			5,
+		},
+		{
+			`{["a", 1] : 5}[[1, "a"]]`,
+			nil,
+		},
+		{
+			`{[1] : 5, [1] : 6}[[1]]`,
+			6,
+		},
+		{
+			`{1 : 5}[1.0]`,
+			5,
+		},
+		{
+			`{0 : 5}[-0.0]`,
+			5,
+		},
+		{
+			`{1.5 : 5}[1]`,
+			nil,
+		},
+		{
+			`{[1] : 5}[[1.0]]`,
+			5,
+		},
+		{
+			`len({1 : 5, 1.0 : 6})`,
+			1,
+		},
 	}
 	for _, tt := range tests {
 		testLiteralObject(t, testEval(tt.input), tt.expected)
@@ -898,11 +1148,177 @@ func TestClosures(t *testing.T) {
 	}
 }
 
+func TestMemoryLimit(t *testing.T) {
+	tests := []struct {
+		input    string
+		limit    int64
+		expected any
+	}{
+		{
+			`let grow = fn(arr, n) { if (n == 0) { arr } else { grow(push(arr, n), n - 1) } };
+      len(grow([], 100));`,
+			0,
+			100,
+		},
+		{
+			`let grow = fn(arr, n) { if (n == 0) { arr } else { grow(push(arr, n), n - 1) } };
+      len(grow([], 100));`,
+			4096,
+			"memory limit exceeded: allocating 360 bytes for ARRAY with 3864 of 4096 bytes in use",
+		},
+		{
+			`let double = fn(s, n) { if (n == 0) { s } else { double(s + s, n - 1) } };
+      len(double("ab", 4));`,
+			0,
+			32,
+		},
+		{
+			`let double = fn(s, n) { if (n == 0) { s } else { double(s + s, n - 1) } };
+      len(double("ab", 64));`,
+			1 << 20,
+			"memory limit exceeded: allocating 524304 bytes for STRING with 524574 of 1048576 bytes in use",
+		},
+	}
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.Runtime().Memory.Limit = tt.limit
+		evaluated := testEvalEnv(tt.input, env)
+		if msg, ok := tt.expected.(string); ok {
+			testErrorObject(t, evaluated, msg)
+		} else {
+			testLiteralObject(t, evaluated, tt.expected)
+		}
+	}
+}
+
+func TestMemoryStats(t *testing.T) {
+	env := object.NewEnvironment()
+	testEvalEnv(`let a = [1, 2, 3]; let b = "hello" + " world"; {"x": a}`, env)
+
+	stats := env.Runtime().Memory.Stats()
+	// The array, the four strings and the hash.
+	if stats.Objects != 6 {
+		t.Errorf("Expected 6 tracked objects, got %d", stats.Objects)
+	}
+	expected := int64((24 + 3*16) + (16 + 5) + (16 + 6) + (16 + 11) + (16 + 1) + (48 + 64*1))
+	if stats.Allocated != expected {
+		t.Errorf("Expected %d bytes allocated, got %d", expected, stats.Allocated)
+	}
+	if stats.Largest != 48+64*1 {
+		t.Errorf("Expected the largest object to be %d bytes, got %d", 48+64*1, stats.Largest)
+	}
+}
+
+// recorder notes what an Observer is told, one line per call
+type recorder struct {
+	events  []string
+	depth   int // How many nodes are being evaluated
+	deepest int
+}
+
+func (r *recorder) Enter(node ast.Node, env *object.Environment) {
+	r.depth++
+	r.deepest = max(r.deepest, r.depth)
+}
+
+func (r *recorder) Exit(node ast.Node, result object.Object) {
+	r.depth--
+}
+
+func (r *recorder) Call(fn object.Object, args []object.Object) {
+	r.events = append(r.events, fmt.Sprintf("call %s %d", object.Describe(fn), len(args)))
+}
+
+func (r *recorder) Return(fn object.Object, result object.Object) {
+	r.events = append(r.events, "return "+result.Inspect())
+}
+
+func (r *recorder) Bind(name string, value object.Object, env *object.Environment) {
+	r.events = append(r.events, "bind "+name+" "+object.Describe(value))
+}
+
+func (r *recorder) Error(node ast.Node, err *object.Error) {
+	r.events = append(r.events, fmt.Sprintf("error %s at %q", err.Message, node.String()))
+}
+
+func TestObserver(t *testing.T) {
+	env := object.NewEnvironment()
+	r := &recorder{}
+	env.Runtime().Observer = r
+	testEvalEnv(`let add = fn(a, b) { a + b };
+let x = len([add(1, 2)]);
+x + missing;`, env)
+
+	expected := []string{
+		"bind add fn(a, b)",
+		"call fn(a, b) 2",
+		"bind a 1",
+		"bind b 2",
+		"return 3",
+		"call builtin function 1",
+		"return 1",
+		"bind x 1",
+		`error identity not found: missing at "missing"`,
+	}
+	if len(r.events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d: %q", len(expected), len(r.events), r.events)
+	}
+	for i, event := range expected {
+		if r.events[i] != event {
+			t.Errorf("events[%d] - expected %q, got %q", i, event, r.events[i])
+		}
+	}
+	if r.depth != 0 || r.deepest < 5 {
+		t.Errorf("Expected every node entered to be exited, got depth %d of %d", r.depth, r.deepest)
+	}
+}
+
+func TestErrorNode(t *testing.T) {
+	result := testEval("let x = 1;\nif (x) { x + true }")
+	err, ok := result.(*object.Error)
+	if !ok {
+		t.Fatalf("Expected an error, got %T (%+v)", result, result)
+	}
+	if err.Node == nil || err.Node.String() != "(x + true)" {
+		t.Errorf("Expected the error to come from (x + true), got %v", err.Node)
+	}
+}
+
+// Runs may share a registry, as those without one of their own share the
+// default, and namespaces are built on first use. Run with -race.
+func TestSharedBuiltins(t *testing.T) {
+	for range 20 {
+		registry := NewBuiltins()
+		start := make(chan struct{})
+		results := make([]object.Object, 2)
+		var wg sync.WaitGroup
+		for i := range results {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				env := object.NewEnvironment()
+				env.Runtime().Builtins = registry
+				<-start
+				results[i] = testEvalEnv("let m = math; m.abs(-2) + m.max(1, 2)", env)
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		for _, result := range results {
+			testIntegerObject(t, result, 4)
+		}
+	}
+}
+
 func testEval(input string) object.Object {
+	return testEvalEnv(input, object.NewEnvironment())
+}
+
+func testEvalEnv(input string, env *object.Environment) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
 	program := p.ParseProgram()
-	env := object.NewEnvironment()
 	return Eval(program, env)
 }
 
@@ -914,6 +1330,8 @@ func testLiteralObject(t *testing.T, input object.Object, expected any) bool {
 		return testIntegerObject(t, input, int64(v))
 	case int64:
 		return testIntegerObject(t, input, v)
+	case float64:
+		return testFloatObject(t, input, v)
 	case string:
 		return testStringObject(t, input, v)
 	case nil:
@@ -1015,6 +1433,20 @@ func testIntegerObject(t *testing.T, input object.Object, expected int64) bool {
 	return true
 }
 
+func testFloatObject(t *testing.T, input object.Object, expected float64) bool {
+	floatObj, ok := input.(*object.Float)
+	if !ok {
+		t.Errorf("Failed to cast Object into %s. Got %T (%+v)", "Float", input, input)
+		return false
+	}
+
+	if floatObj.Value != expected {
+		t.Errorf("Expected representation to be %g, got %g", expected, floatObj.Value)
+		return false
+	}
+	return true
+}
+
 func testBooleanObject(t *testing.T, input object.Object, expected bool) bool {
 	// Type check
 	if input == nil {
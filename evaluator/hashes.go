@@ -0,0 +1,119 @@
+package evaluator
+
+import (
+	"github.com/waridh/go-monkey-interpreter/functools"
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+// Builtins over hashes. Hashes are never changed in place, so delete and
+// merge return new hashes.
+//
+// keys, values and entries list pairs in insertion order, which for hash
+// literals is the order the keys are written in.
+var hashBuiltins = map[string]*object.Builtin{
+	"keys": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			hash, err := hashArg("keys", args, 1)
+			if err != nil {
+				return err
+			}
+			result := functools.Map(hash.Pairs(), func(x object.HashPair) object.Object { return x.Key })
+			return track(env, &object.Array{Elements: result})
+		},
+	},
+	"values": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			hash, err := hashArg("values", args, 1)
+			if err != nil {
+				return err
+			}
+			result := functools.Map(hash.Pairs(), func(x object.HashPair) object.Object { return x.Value })
+			return track(env, &object.Array{Elements: result})
+		},
+	},
+	"entries": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			hash, err := hashArg("entries", args, 1)
+			if err != nil {
+				return err
+			}
+			result := make([]object.Object, hash.Len())
+			for i, pair := range hash.Pairs() {
+				result[i] = track(env, &object.Array{Elements: []object.Object{pair.Key, pair.Value}})
+				if isError(result[i]) {
+					return result[i]
+				}
+			}
+			return track(env, &object.Array{Elements: result})
+		},
+	},
+	"has": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			hash, err := hashArg("has", args, 2)
+			if err != nil {
+				return err
+			}
+			key, ok := object.AsHashable(args[1])
+			if !ok {
+				return newError("%s is not hashable", args[1].Type())
+			}
+			_, found := hash.Get(key)
+			return booleanObjectOfNativeBool(found)
+		},
+	},
+	"delete": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) < 1 {
+				return newError("wrong number of arguments for delete. got=0, want at least 1")
+			}
+			hash, err := hashArg("delete", args[:1], 1)
+			if err != nil {
+				return err
+			}
+			deleted := object.NewHash(len(args) - 1)
+			for _, arg := range args[1:] {
+				key, ok := object.AsHashable(arg)
+				if !ok {
+					return newError("%s is not hashable", arg.Type())
+				}
+				deleted.Set(key, TRUE)
+			}
+			result := object.NewHash(hash.Len())
+			for _, pair := range hash.Pairs() {
+				key := pair.Key.(object.Hashable)
+				if _, ok := deleted.Get(key); !ok {
+					result.Set(key, pair.Value)
+				}
+			}
+			return track(env, result)
+		},
+	},
+	"merge": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			result := &object.Hash{}
+			for _, arg := range args {
+				hash, ok := arg.(*object.Hash)
+				if !ok {
+					return newError("argument to `%s` not supported, got=%s", "merge", arg.Type())
+				}
+				// Later hashes win when keys clash, but keys stay where they
+				// were first inserted
+				for _, pair := range hash.Pairs() {
+					result.Set(pair.Key.(object.Hashable), pair.Value)
+				}
+			}
+			return track(env, result)
+		},
+	},
+}
+
+func hashArg(name string, args []object.Object, expected int) (*object.Hash, object.Object) {
+	if err := builtinLenCheck(name, expected, args); err != nil {
+		return nil, err
+	}
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return nil, newError("argument to `%s` not supported, got=%s", name, args[0].Type())
+	}
+	return hash, nil
+}
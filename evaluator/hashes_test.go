@@ -0,0 +1,72 @@
+package evaluator
+
+import "testing"
+
+func TestHashBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`len({})`, 0},
+		{`len({"a": 1, "b": 2})`, 2},
+		{`keys({"b": 2, "a": 1, "c": 3})`, []any{"b", "a", "c"}},
+		{`keys({"x": 1, 10: 2, true: 3, 2: 4, false: 5})`, []any{"x", 10, true, 2, false}},
+		{`values({"b": 2, "a": 1, "c": 3})`, []any{2, 1, 3}},
+		{`entries({"b": 2, "a": 1})`, []any{[]any{"b", 2}, []any{"a", 1}}},
+		{`keys({"a": 1, "b": 2, "a": 3})`, []any{"a", "b"}},
+		{`values({"a": 1, "b": 2, "a": 3})`, []any{3, 2}},
+		{`keys({})`, []any{}},
+		{`has({"a": 1}, "a")`, true},
+		{`has({"a": 1}, "b")`, false},
+		{`has({1: 1}, 1)`, true},
+		{`keys(delete({"a": 1, "b": 2, "c": 3}, "b"))`, []any{"a", "c"}},
+		{`keys(delete({"a": 1, "b": 2, "c": 3}, "a", "c", "z"))`, []any{"b"}},
+		{`keys(delete({"c": 1, "b": 2, "a": 3}, "b"))`, []any{"c", "a"}},
+		{`let h = {"a": 1}; delete(h, "a"); h["a"]`, 1},
+		{`values(merge({"a": 1, "b": 2}, {"b": 3, "c": 4}))`, []any{1, 3, 4}},
+		{`keys(merge({"b": 1, "a": 2}, {"c": 3, "b": 4}))`, []any{"b", "a", "c"}},
+		{`merge()["a"]`, nil},
+		{`let h = {"a": 1}; merge(h, {"a": 2}); h["a"]`, 1},
+	}
+	for _, tt := range tests {
+		testLiteralObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestHashBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`keys([1])`, "argument to `keys` not supported, got=ARRAY"},
+		{`keys({}, {})`, "wrong number of arguments for keys. got=2, want=1"},
+		{`has({}, [1, fn(x) { x }])`, "ARRAY is not hashable"},
+		{`delete({}, fn(x) { x })`, "FUNCTION is not hashable"},
+		{`delete()`, "wrong number of arguments for delete. got=0, want at least 1"},
+		{`merge({}, 1)`, "argument to `merge` not supported, got=INTEGER"},
+	}
+	for _, tt := range tests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestHashInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{}`, "{}"},
+		{`{"b": 2, "a": [1, 2], 3: true}`, "{b: 2, a: [1, 2], 3: true}"},
+		{`{"a": {"z": 1, "y": 2}}`, "{a: {z: 1, y: 2}}"},
+		{`merge({"a": 1}, {"b": 2}, {"a": 3})`, "{a: 3, b: 2}"},
+	}
+	for _, tt := range tests {
+		// Repeat, to catch orderings that only hold some of the time
+		for i := 0; i < 10; i++ {
+			if inspected := testEval(tt.input).Inspect(); inspected != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, inspected)
+				break
+			}
+		}
+	}
+}
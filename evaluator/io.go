@@ -0,0 +1,185 @@
+package evaluator
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/waridh/go-monkey-interpreter/functools"
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+// Builtins reading and writing files and the standard input. Files are only
+// reachable below the file root of the runtime, and are named by slash
+// separated paths relative to it. The builtins need capabilities that
+// registries disable by default, see builtinCapabilities.
+var ioBuiltins = map[string]*object.Builtin{
+	"read_file": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			strs, err := fixedStringArgs("read_file", args, 1)
+			if err != nil {
+				return err
+			}
+			content, err := readFile(env, "read_file", strs[0])
+			if err != nil {
+				return err
+			}
+			return track(env, &object.String{Value: content})
+		},
+	},
+	"read_lines": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			strs, err := fixedStringArgs("read_lines", args, 1)
+			if err != nil {
+				return err
+			}
+			content, err := readFile(env, "read_lines", strs[0])
+			if err != nil {
+				return err
+			}
+			if content == "" {
+				return track(env, &object.Array{Elements: []object.Object{}})
+			}
+			lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+			lines = functools.Map(lines, func(x string) string { return strings.TrimSuffix(x, "\r") })
+			return track(env, &object.Array{Elements: stringObjects(lines)})
+		},
+	},
+	"write_file": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			strs, err := fixedStringArgs("write_file", args, 2)
+			if err != nil {
+				return err
+			}
+			root, path, err := openPath(env, "write_file", strs[0])
+			if err != nil {
+				return err
+			}
+			defer root.Close()
+			file, openErr := root.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+			if openErr != nil {
+				return fileError("write_file", strs[0], openErr)
+			}
+			_, writeErr := file.WriteString(strs[1])
+			if closeErr := file.Close(); writeErr == nil {
+				writeErr = closeErr
+			}
+			if writeErr != nil {
+				return fileError("write_file", strs[0], writeErr)
+			}
+			return NULL
+		},
+	},
+	"list_dir": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) > 1 {
+				return newError("wrong number of arguments for list_dir. got=%d, want=0 or 1", len(args))
+			}
+			strs, err := stringArgs("list_dir", args)
+			if err != nil {
+				return err
+			}
+			// Without an argument, list the file root itself
+			name := "."
+			if len(strs) == 1 {
+				name = strs[0]
+			}
+			root, path, err := openPath(env, "list_dir", name)
+			if err != nil {
+				return err
+			}
+			defer root.Close()
+			entries, readErr := fs.ReadDir(root.FS(), filepath.ToSlash(path))
+			if readErr != nil {
+				return fileError("list_dir", name, readErr)
+			}
+			names := functools.Map(entries, func(x fs.DirEntry) string { return x.Name() })
+			return track(env, &object.Array{Elements: stringObjects(names)})
+		},
+	},
+	"read_line": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck("read_line", 0, args); err != nil {
+				return err
+			}
+			stdin := env.Runtime().Stdin
+			if stdin == nil {
+				return NULL
+			}
+			line, readErr := stdin.ReadString('\n')
+			if readErr == io.EOF && line == "" {
+				// The end of the input reads as null
+				return NULL
+			}
+			if readErr != nil && readErr != io.EOF {
+				return newError("read_line: %s", readErr)
+			}
+			line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+			return track(env, &object.String{Value: line})
+		},
+	},
+}
+
+// openPath opens the file root, and turns the slash separated path given to
+// the builtin name into a path within it. Paths that lead out of the root
+// are refused here, and os.Root refuses those that do so through symbolic
+// links, dangling ones included. The caller closes the root.
+func openPath(env *object.Environment, name, path string) (*os.Root, string, object.Object) {
+	dir := env.Runtime().FileRoot
+	if dir == "" {
+		return nil, "", newError("%s: no file root is configured", name)
+	}
+	local := filepath.FromSlash(path)
+	if !filepath.IsLocal(local) {
+		return nil, "", newError("%s: %q is outside of the file root", name, path)
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			err = pathErr.Err
+		}
+		return nil, "", newError("%s: cannot open the file root: %s", name, err)
+	}
+	return root, local, nil
+}
+
+// readFile reads the file at path, checking up front that its contents fit
+// in the memory limit
+func readFile(env *object.Environment, name, path string) (string, object.Object) {
+	root, local, errObj := openPath(env, name, path)
+	if errObj != nil {
+		return "", errObj
+	}
+	defer root.Close()
+	file, err := root.Open(local)
+	if err != nil {
+		return "", fileError(name, path, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", fileError(name, path, err)
+	}
+	if err := env.Runtime().Memory.Check(object.STRING_OBJ, object.StringSize(info.Size())); err != nil {
+		return "", newError("%s", err)
+	}
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return "", fileError(name, path, err)
+	}
+	return string(content), nil
+}
+
+// fileError reports err without the location of the file root, which scripts
+// have no need to know
+func fileError(name, path string, err error) object.Object {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	return newError("%s %s: %s", name, path, err)
+}
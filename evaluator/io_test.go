@@ -0,0 +1,148 @@
+package evaluator
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+// ioEnv creates an environment with the file and stdin builtins enabled,
+// rooted at a new directory holding files
+func ioEnv(t *testing.T, files map[string]string) (*object.Environment, string) {
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	env := object.NewEnvironment()
+	rt := env.Runtime()
+	rt.Builtins = NewBuiltins()
+	rt.Builtins.Enable(object.CapFileSystem)
+	rt.Builtins.Enable(object.CapStdin)
+	rt.FileRoot = root
+	rt.Stdin = bufio.NewReader(strings.NewReader("first\r\nsecond"))
+	return env, root
+}
+
+func TestIOBuiltins(t *testing.T) {
+	env, root := ioEnv(t, map[string]string{
+		"notes.txt":    "one\ntwo\r\nthree\n",
+		"data/a.json":  `{"n": 1}`,
+		"data/b.txt":   "",
+		"data/sub/c.x": "",
+	})
+
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`read_file("notes.txt")`, "one\ntwo\r\nthree\n"},
+		{`read_lines("notes.txt")`, []any{"one", "two", "three"}},
+		{`read_lines("data/b.txt")`, []any{}},
+		{`json_parse(read_file("data/a.json")).n`, 1},
+		{`list_dir("data")`, []any{"a.json", "b.txt", "sub"}},
+		{`list_dir()`, []any{"data", "notes.txt"}},
+		{`write_file("out.txt", "hello")`, nil},
+		{`read_file("out.txt")`, "hello"},
+		{`read_file("data/../notes.txt")`, "one\ntwo\r\nthree\n"},
+		{`read_line()`, "first"},
+		{`read_line()`, "second"},
+		{`read_line()`, nil},
+	}
+	for _, tt := range tests {
+		testLiteralObject(t, testEvalEnv(tt.input, env), tt.expected)
+	}
+
+	written, err := os.ReadFile(filepath.Join(root, "out.txt"))
+	if err != nil || string(written) != "hello" {
+		t.Errorf("Expected out.txt to hold hello, got %q (%v)", written, err)
+	}
+}
+
+func TestIOBuiltinErrors(t *testing.T) {
+	env, root := ioEnv(t, map[string]string{"a.txt": "a"})
+	outside := t.TempDir()
+	if err := os.WriteFile(filepath.Join(outside, "secret"), []byte("s"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Skipf("Cannot create symbolic links: %s", err)
+	}
+	// Links to files outside, whether or not they exist yet
+	if err := os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "secret")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "dangling"), filepath.Join(root, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`read_file("missing.txt")`, "read_file missing.txt: no such file or directory"},
+		{`read_file("../a.txt")`, `read_file: "../a.txt" is outside of the file root`},
+		{`read_file("/etc/passwd")`, `read_file: "/etc/passwd" is outside of the file root`},
+		{`read_file("link/secret")`, "read_file link/secret: path escapes from parent"},
+		{`write_file("link/new", "x")`, "write_file link/new: path escapes from parent"},
+		{`read_file("secret")`, "read_file secret: path escapes from parent"},
+		{`write_file("secret", "x")`, "write_file secret: path escapes from parent"},
+		{`write_file("dangling", "x")`, "write_file dangling: path escapes from parent"},
+		{`list_dir("link")`, "list_dir link: path escapes from parent"},
+		{`write_file("a.txt", 1)`, "argument to `write_file` not supported, got=INTEGER"},
+		{`list_dir("a.txt")`, "list_dir a.txt: not a directory"},
+		{`read_line(1)`, "wrong number of arguments for read_line. got=1, want=0"},
+	}
+	for _, tt := range tests {
+		testErrorObject(t, testEvalEnv(tt.input, env), tt.expected)
+	}
+
+	for _, name := range []string{"new", "dangling"} {
+		if _, err := os.Stat(filepath.Join(outside, name)); err == nil {
+			t.Errorf("Expected write_file not to write %s through a link", name)
+		}
+	}
+	if secret, _ := os.ReadFile(filepath.Join(outside, "secret")); string(secret) != "s" {
+		t.Errorf("Expected write_file not to overwrite secret through a link, got %q", secret)
+	}
+}
+
+func TestIOCapabilitiesDisabledByDefault(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`read_file("a.txt")`, "read_file is unavailable: the filesystem capability is disabled"},
+		{`list_dir()`, "list_dir is unavailable: the filesystem capability is disabled"},
+		{`read_line()`, "read_line is unavailable: the stdin capability is disabled"},
+	}
+	for _, tt := range tests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+
+	env := object.NewEnvironment()
+	env.Runtime().Builtins = NewBuiltins()
+	env.Runtime().Builtins.Enable(object.CapFileSystem)
+	testErrorObject(t, testEvalEnv(`read_file("a.txt")`, env), "read_file: no file root is configured")
+
+	env.Runtime().FileRoot = filepath.Join(t.TempDir(), "missing")
+	testErrorObject(t, testEvalEnv(`read_file("a.txt")`, env),
+		"read_file: cannot open the file root: no such file or directory")
+}
+
+func TestReadFileMemoryLimit(t *testing.T) {
+	env, _ := ioEnv(t, map[string]string{"big.txt": strings.Repeat("x", 4096)})
+	env.Runtime().Memory.Limit = 1024
+	testErrorObject(t, testEvalEnv(`read_file("big.txt")`, env),
+		"memory limit exceeded: allocating 4112 bytes for STRING with 23 of 1024 bytes in use")
+}
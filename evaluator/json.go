@@ -0,0 +1,222 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+// Builtins converting between objects and JSON. Objects in JSON documents
+// become hashes whose keys keep the order they were written in, and hashes
+// are written out in insertion order.
+var jsonBuiltins = map[string]*object.Builtin{
+	"json_parse": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			strs, err := fixedStringArgs("json_parse", args, 1)
+			if err != nil {
+				return err
+			}
+			dec := json.NewDecoder(strings.NewReader(strs[0]))
+			dec.UseNumber()
+			result, err := decodeJSON(env, dec)
+			if err != nil {
+				return err
+			}
+			if _, tokenErr := dec.Token(); tokenErr != io.EOF {
+				return newError("invalid JSON: unexpected data after the top-level value")
+			}
+			return result
+		},
+	},
+	"json_stringify": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments for json_stringify. got=%d, want=1 or 2", len(args))
+			}
+			var out bytes.Buffer
+			if err := encodeJSON(&out, args[0], map[object.Object]bool{}); err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				return track(env, &object.String{Value: out.String()})
+			}
+
+			// The indent is either a number of spaces, or the string to
+			// indent with
+			var indent string
+			switch arg := args[1].(type) {
+			case *object.Integer:
+				if arg.Value < 0 || arg.Value > 10 {
+					return newError("indent given to `json_stringify` must be between 0 and 10, got=%d", arg.Value)
+				}
+				indent = strings.Repeat(" ", int(arg.Value))
+			case *object.String:
+				indent = arg.Value
+			default:
+				return newError("argument to `%s` not supported, got=%s", "json_stringify", arg.Type())
+			}
+			var indented bytes.Buffer
+			if err := json.Indent(&indented, out.Bytes(), "", indent); err != nil {
+				return newError("%s", err)
+			}
+			return track(env, &object.String{Value: indented.String()})
+		},
+	},
+}
+
+// decodeJSON reads the next value from dec. Numbers without a fraction or
+// exponent that fit in an int64 become integers, and other numbers floats.
+func decodeJSON(env *object.Environment, dec *json.Decoder) (object.Object, object.Object) {
+	tok, err := dec.Token()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, newError("invalid JSON: unexpected end of input")
+		}
+		return nil, newError("invalid JSON: %s", err)
+	}
+
+	switch tok := tok.(type) {
+	case nil:
+		return NULL, nil
+	case bool:
+		return booleanObjectOfNativeBool(tok), nil
+	case string:
+		return trackedOrError(env, &object.String{Value: tok})
+	case json.Number:
+		if value, err := strconv.ParseInt(tok.String(), 10, 64); err == nil {
+			return &object.Integer{Value: value}, nil
+		}
+		value, err := tok.Float64()
+		if err != nil {
+			return nil, newError("invalid JSON: number %s is out of range", tok)
+		}
+		return &object.Float{Value: value}, nil
+	case json.Delim:
+		if tok == '[' {
+			elements := []object.Object{}
+			for dec.More() {
+				ele, err := decodeJSON(env, dec)
+				if err != nil {
+					return nil, err
+				}
+				elements = append(elements, ele)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, newError("invalid JSON: %s", err)
+			}
+			return trackedOrError(env, &object.Array{Elements: elements})
+		}
+
+		hash := &object.Hash{}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, newError("invalid JSON: %s", err)
+			}
+			key := &object.String{Value: keyTok.(string)}
+			value, errObj := decodeJSON(env, dec)
+			if errObj != nil {
+				return nil, errObj
+			}
+			hash.Set(key, value)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, newError("invalid JSON: %s", err)
+		}
+		return trackedOrError(env, hash)
+	default:
+		return nil, newError("invalid JSON: unexpected %v", tok)
+	}
+}
+
+// trackedOrError charges obj to the memory limit, splitting out the error
+func trackedOrError(env *object.Environment, obj object.Object) (object.Object, object.Object) {
+	tracked := track(env, obj)
+	if isError(tracked) {
+		return nil, tracked
+	}
+	return tracked, nil
+}
+
+// encodeJSON writes obj to out as compact JSON. Arrays and hashes that are
+// being written are kept in seen, so that cycles are reported rather than
+// followed forever.
+func encodeJSON(out *bytes.Buffer, obj object.Object, seen map[object.Object]bool) object.Object {
+	switch obj := obj.(type) {
+	case *object.Null:
+		out.WriteString("null")
+	case *object.Boolean:
+		out.WriteString(strconv.FormatBool(obj.Value))
+	case *object.Integer:
+		out.WriteString(strconv.FormatInt(obj.Value, 10))
+	case *object.Float:
+		if math.IsNaN(obj.Value) || math.IsInf(obj.Value, 0) {
+			return newError("cannot convert %s to JSON", obj.Inspect())
+		}
+		out.WriteString(obj.Inspect())
+	case *object.String:
+		encodeJSONString(out, obj.Value)
+	case *object.Array:
+		if seen[obj] {
+			return newError("cannot convert cyclic ARRAY to JSON")
+		}
+		seen[obj] = true
+		defer delete(seen, obj)
+
+		out.WriteByte('[')
+		for i, ele := range obj.Elements {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := encodeJSON(out, ele, seen); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	case *object.Hash:
+		if seen[obj] {
+			return newError("cannot convert cyclic HASH to JSON")
+		}
+		seen[obj] = true
+		defer delete(seen, obj)
+
+		out.WriteByte('{')
+		for i, pair := range obj.Pairs() {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			// JSON keys are always strings, so other scalar keys are written
+			// the way they are printed
+			switch key := pair.Key.(type) {
+			case *object.String:
+				encodeJSONString(out, key.Value)
+			case *object.Integer, *object.Float, *object.Boolean:
+				encodeJSONString(out, key.Inspect())
+			default:
+				return newError("cannot convert %s hash key to JSON", key.Type())
+			}
+			out.WriteByte(':')
+			if err := encodeJSON(out, pair.Value, seen); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	default:
+		return newError("cannot convert %s to JSON", obj.Type())
+	}
+	return nil
+}
+
+func encodeJSONString(out *bytes.Buffer, str string) {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.Encode(str)
+	// Encode ends every value with a newline
+	out.Truncate(out.Len() - 1)
+}
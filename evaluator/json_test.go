@@ -0,0 +1,102 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+// testEvalJSON evaluates input with the JSON document doc bound to `doc`, as
+// string literals cannot contain double quotes
+func testEvalJSON(input, doc string) object.Object {
+	env := object.NewEnvironment()
+	env.Set("doc", &object.String{Value: doc})
+	return testEvalEnv(input, env)
+}
+
+func TestJSONParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		doc      string
+		expected any
+	}{
+		{`json_parse(doc)`, `1`, 1},
+		{`json_parse(doc)`, `-2.5`, -2.5},
+		{`json_parse(doc)`, `1e3`, 1000.0},
+		{`json_parse(doc)`, `true`, true},
+		{`json_parse(doc)`, `null`, nil},
+		{`json_parse(doc)`, ` "hé\n" `, "hé\n"},
+		{`json_parse(doc)`, `[1, [2, 3], []]`, []any{1, []any{2, 3}, []any{}}},
+		{`json_parse(doc)["a"]`, `{"b": 1, "a": [true]}`, []any{true}},
+		{`keys(json_parse(doc))`, `{"z": 1, "a": 2, "m": 3}`, []any{"z", "a", "m"}},
+		{`json_parse(doc).a.b`, `{"a": {"b": null}}`, nil},
+		{`len(json_parse(doc))`, `{}`, 0},
+		{`json_parse(doc)`, `92233720368547758070`, 92233720368547758070.0},
+	}
+	for _, tt := range tests {
+		testLiteralObject(t, testEvalJSON(tt.input, tt.doc), tt.expected)
+	}
+}
+
+func TestJSONStringify(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`json_stringify(1)`, `1`},
+		{`json_stringify(2.0)`, `2.0`},
+		{`json_stringify("a <b> & c")`, `"a <b> & c"`},
+		{`json_stringify([1, "a", true, first([])])`, `[1,"a",true,null]`},
+		{`json_stringify({"z": 1, "a": [2]})`, `{"z":1,"a":[2]}`},
+		{`json_stringify({1: "one", true: "yes"})`, `{"1":"one","true":"yes"}`},
+		{`json_stringify({"a": [1]}, 2)`, "{\n  \"a\": [\n    1\n  ]\n}"},
+		{`json_stringify([1], "--")`, "[\n--1\n]"},
+		{`json_stringify([], 2)`, "[]"},
+	}
+	for _, tt := range tests {
+		testLiteralObject(t, testEval(tt.input), tt.expected)
+	}
+
+	// Documents survive a round trip unchanged, including the order of keys
+	doc := `{"b":[1,2.5,"x\"y"],"a":{},"c":null}`
+	testLiteralObject(t, testEvalJSON(`json_stringify(json_parse(doc))`, doc), doc)
+}
+
+func TestJSONErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		doc      string
+		expected string
+	}{
+		{`json_parse(doc)`, `{`, "invalid JSON: unexpected end of JSON input"},
+		{`json_parse(doc)`, `[1,]`, "invalid JSON: invalid character ',' looking for beginning of value"},
+		{`json_parse(doc)`, `1 2`, "invalid JSON: unexpected data after the top-level value"},
+		{`json_parse(doc)`, ``, "invalid JSON: unexpected end of input"},
+		{`json_parse(1)`, ``, "argument to `json_parse` not supported, got=INTEGER"},
+		{`json_stringify(fn(x) { x })`, ``, "cannot convert FUNCTION to JSON"},
+		{`json_stringify({"f": len})`, ``, "cannot convert BUILTIN to JSON"},
+		{`json_stringify({[1]: 1})`, ``, "cannot convert ARRAY hash key to JSON"},
+		{`json_stringify(1, 11)`, ``, "indent given to `json_stringify` must be between 0 and 10, got=11"},
+		{`json_stringify(1, [])`, ``, "argument to `json_stringify` not supported, got=ARRAY"},
+	}
+	for _, tt := range tests {
+		testErrorObject(t, testEvalJSON(tt.input, tt.doc), tt.expected)
+	}
+}
+
+func TestJSONCycles(t *testing.T) {
+	arr := &object.Array{}
+	arr.Elements = []object.Object{&object.Integer{Value: 1}, arr}
+	hash := &object.Hash{}
+	hash.Set(&object.String{Value: "self"}, hash)
+	shared := &object.Array{}
+
+	env := object.NewEnvironment()
+	env.Set("arr", arr)
+	env.Set("hash", hash)
+	env.Set("shared", shared)
+	testErrorObject(t, testEvalEnv(`json_stringify(arr)`, env), "cannot convert cyclic ARRAY to JSON")
+	testErrorObject(t, testEvalEnv(`json_stringify(hash)`, env), "cannot convert cyclic HASH to JSON")
+	// Sharing a value is not a cycle
+	testLiteralObject(t, testEvalEnv(`json_stringify([shared, shared])`, env), "[[],[]]")
+}
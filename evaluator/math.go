@@ -0,0 +1,371 @@
+package evaluator
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+var mathConstants = map[string]object.Object{
+	"math.PI": &object.Float{Value: math.Pi},
+	"math.E":  &object.Float{Value: math.E},
+}
+
+// Builtins of the math namespace. They accept integers and floats alike, and
+// keep integer results as integers where the operation allows it.
+var mathBuiltins = map[string]*object.Builtin{
+	"math.abs": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			nums, err := numberArgs("math.abs", args, 1)
+			if err != nil {
+				return err
+			}
+			if integer, ok := nums[0].(*object.Integer); ok {
+				if integer.Value == math.MinInt64 {
+					return newError("integer overflow in `math.abs`")
+				}
+				if integer.Value < 0 {
+					return &object.Integer{Value: -integer.Value}
+				}
+				return integer
+			}
+			return &object.Float{Value: math.Abs(toFloat(nums[0]))}
+		},
+	},
+	"math.min": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			return extremum("math.min", args, -1)
+		},
+	},
+	"math.max": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			return extremum("math.max", args, 1)
+		},
+	},
+	"math.pow": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			nums, err := numberArgs("math.pow", args, 2)
+			if err != nil {
+				return err
+			}
+			base, baseIsInt := nums[0].(*object.Integer)
+			exp, expIsInt := nums[1].(*object.Integer)
+			if baseIsInt && expIsInt && exp.Value >= 0 {
+				result, ok := intPow(base.Value, exp.Value)
+				if !ok {
+					return newError("integer overflow in `math.pow`")
+				}
+				return &object.Integer{Value: result}
+			}
+			return &object.Float{Value: math.Pow(toFloat(nums[0]), toFloat(nums[1]))}
+		},
+	},
+	"math.sqrt": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			nums, err := numberArgs("math.sqrt", args, 1)
+			if err != nil {
+				return err
+			}
+			value := toFloat(nums[0])
+			if value < 0 {
+				return newError("square root of negative number %s", nums[0].Inspect())
+			}
+			return &object.Float{Value: math.Sqrt(value)}
+		},
+	},
+	"math.floor": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			return roundToInteger("math.floor", args, math.Floor)
+		},
+	},
+	"math.ceil": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			return roundToInteger("math.ceil", args, math.Ceil)
+		},
+	},
+	"math.round": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return roundToInteger("math.round", args, math.Round)
+			}
+			// Rounding to a number of decimal places gives a float
+			nums, err := numberArgs("math.round", args[:1], 1)
+			if err != nil {
+				return err
+			}
+			digits, err := integerArgs("math.round", args[1:])
+			if err != nil {
+				return err
+			}
+			x, places := toFloat(nums[0]), digits[0]
+			switch {
+			case math.IsInf(x, 0) || math.IsNaN(x):
+				return &object.Float{Value: x}
+			case places < -308:
+				// Every float is closer to zero than to 10^309
+				return &object.Float{Value: math.Copysign(0, x)}
+			case places > 308:
+				// Beyond this, the scale is no longer a float
+				places = 308
+			}
+			scale := math.Pow(10, float64(places))
+			scaled := x * scale
+			if math.IsInf(scaled, 0) {
+				// x has no digits that far past the point
+				return &object.Float{Value: x}
+			}
+			return &object.Float{Value: math.Round(scaled) / scale}
+		},
+	},
+	"math.clamp": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			nums, err := numberArgs("math.clamp", args, 3)
+			if err != nil {
+				return err
+			}
+			value, lower, upper := nums[0], nums[1], nums[2]
+			if order, _ := compare(lower, upper); order > 0 {
+				return newError("lower bound %s of `math.clamp` is greater than upper bound %s", lower.Inspect(), upper.Inspect())
+			}
+			if order, _ := compare(value, lower); order < 0 {
+				return lower
+			}
+			if order, _ := compare(value, upper); order > 0 {
+				return upper
+			}
+			return value
+		},
+	},
+	"math.sum": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck("math.sum", 1, args); err != nil {
+				return err
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `%s` not supported, got=%s", "math.sum", args[0].Type())
+			}
+			nums, err := numberArgs("math.sum", arr.Elements, len(arr.Elements))
+			if err != nil {
+				return err
+			}
+			var result object.Object = &object.Integer{Value: 0}
+			for _, num := range nums {
+				result = evalInfixOperator("+", result, num)
+			}
+			return result
+		},
+	},
+	"math.mod": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			nums, err := numberArgs("math.mod", args, 2)
+			if err != nil {
+				return err
+			}
+			// Unlike Go's %, the result takes the sign of the divisor
+			a, aIsInt := nums[0].(*object.Integer)
+			b, bIsInt := nums[1].(*object.Integer)
+			if aIsInt && bIsInt {
+				if b.Value == 0 {
+					return newError("division by zero in `math.mod`")
+				}
+				result := a.Value % b.Value
+				if result != 0 && (result < 0) != (b.Value < 0) {
+					result += b.Value
+				}
+				return &object.Integer{Value: result}
+			}
+			divisor := toFloat(nums[1])
+			if divisor == 0 {
+				return newError("division by zero in `math.mod`")
+			}
+			result := math.Mod(toFloat(nums[0]), divisor)
+			if result != 0 && (result < 0) != (divisor < 0) {
+				result += divisor
+			}
+			return &object.Float{Value: result}
+		},
+	},
+	"math.gcd": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck("math.gcd", 2, args); err != nil {
+				return err
+			}
+			ints, err := integerArgs("math.gcd", args)
+			if err != nil {
+				return err
+			}
+			a, b := ints[0], ints[1]
+			for b != 0 {
+				a, b = b, a%b
+			}
+			if a == math.MinInt64 {
+				return newError("integer overflow in `math.gcd`")
+			}
+			if a < 0 {
+				a = -a
+			}
+			return &object.Integer{Value: a}
+		},
+	},
+	"math.rand_int": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments for math.rand_int. got=%d, want=1 or 2", len(args))
+			}
+			bounds, err := integerArgs("math.rand_int", args)
+			if err != nil {
+				return err
+			}
+			// Like range, rand_int(n) picks from [0, n) and rand_int(a, b)
+			// from [a, b)
+			low, high := int64(0), bounds[0]
+			if len(bounds) == 2 {
+				low, high = bounds[0], bounds[1]
+			}
+			if high <= low {
+				return newError("empty range given to `math.rand_int`")
+			}
+			// The size of the range can overflow an int64, but not a
+			// uint64, and adding the offset wraps around to the right value
+			size := uint64(high) - uint64(low)
+			var offset uint64
+			if size > math.MaxInt64 {
+				// At least half of all uint64 values are in range
+				offset = randOf(env).Uint64()
+				for offset >= size {
+					offset = randOf(env).Uint64()
+				}
+			} else {
+				offset = uint64(randOf(env).Int64N(int64(size)))
+			}
+			return &object.Integer{Value: low + int64(offset)}
+		},
+	},
+	"math.rand_float": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck("math.rand_float", 0, args); err != nil {
+				return err
+			}
+			return &object.Float{Value: randOf(env).Float64()}
+		},
+	},
+	"math.shuffle": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck("math.shuffle", 1, args); err != nil {
+				return err
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `%s` not supported, got=%s", "math.shuffle", args[0].Type())
+			}
+			result := make([]object.Object, len(arr.Elements))
+			copy(result, arr.Elements)
+			randOf(env).Shuffle(len(result), func(i, j int) {
+				result[i], result[j] = result[j], result[i]
+			})
+			return track(env, &object.Array{Elements: result})
+		},
+	},
+	"math.seed": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck("math.seed", 1, args); err != nil {
+				return err
+			}
+			seed, err := integerArgs("math.seed", args)
+			if err != nil {
+				return err
+			}
+			env.Runtime().Rand = object.NewRand(uint64(seed[0]))
+			return NULL
+		},
+	},
+}
+
+// numberArgs checks that args are expected integers or floats
+func numberArgs(name string, args []object.Object, expected int) ([]object.Object, object.Object) {
+	if err := builtinLenCheck(name, expected, args); err != nil {
+		return nil, err
+	}
+	for _, arg := range args {
+		if !isNumber(arg) {
+			return nil, newError("argument to `%s` not supported, got=%s", name, arg.Type())
+		}
+	}
+	return args, nil
+}
+
+// extremum finds the smallest number when sign is negative, and the largest
+// otherwise. It takes either numbers or a single array of numbers.
+func extremum(name string, args []object.Object, sign int) object.Object {
+	if len(args) == 1 {
+		if arr, ok := args[0].(*object.Array); ok {
+			args = arr.Elements
+		}
+	}
+	if len(args) == 0 {
+		return newError("`%s` needs at least one number", name)
+	}
+	nums, err := numberArgs(name, args, len(args))
+	if err != nil {
+		return err
+	}
+	result := nums[0]
+	for _, num := range nums[1:] {
+		if order, _ := compare(num, result); order*sign > 0 {
+			result = num
+		}
+	}
+	return result
+}
+
+// roundToInteger applies round to a number, giving an integer
+func roundToInteger(name string, args []object.Object, round func(float64) float64) object.Object {
+	nums, err := numberArgs(name, args, 1)
+	if err != nil {
+		return err
+	}
+	if integer, ok := nums[0].(*object.Integer); ok {
+		return integer
+	}
+	rounded := round(toFloat(nums[0]))
+	if math.IsNaN(rounded) || rounded < math.MinInt64 || rounded >= math.MaxInt64 {
+		return newError("%s cannot be converted to %s", nums[0].Inspect(), object.INTEGER_OBJ)
+	}
+	return &object.Integer{Value: int64(rounded)}
+}
+
+// intPow raises base to the non-negative power exp, reporting whether the
+// result fits in an int64
+func intPow(base, exp int64) (int64, bool) {
+	switch {
+	case exp == 0:
+		return 1, true
+	case base == 0 || base == 1:
+		return base, true
+	case base == -1:
+		return 1 - 2*(exp%2), true
+	}
+	// Any other base overflows within 63 multiplications, so this loop is
+	// short
+	result := int64(1)
+	for i := int64(0); i < exp; i++ {
+		next := result * base
+		if next/base != result {
+			return 0, false
+		}
+		result = next
+	}
+	return result, true
+}
+
+// randOf returns the random number generator of the run, creating one for
+// runtimes that were built without it
+func randOf(env *object.Environment) *rand.Rand {
+	rt := env.Runtime()
+	if rt.Rand == nil {
+		rt.Rand = object.NewRand(rand.Uint64())
+	}
+	return rt.Rand
+}
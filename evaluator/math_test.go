@@ -0,0 +1,124 @@
+package evaluator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+func TestMathBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`math.abs(-5)`, 5},
+		{`math.abs(-2.5)`, 2.5},
+		{`math.min(3, 1, 2)`, 1},
+		{`math.min([3, 1.5, 2])`, 1.5},
+		{`math.max(3, 1, 2)`, 3},
+		{`math.max(1, 2.5)`, 2.5},
+		{`math.pow(2, 10)`, 1024},
+		{`math.pow(-1, 99)`, -1},
+		{`math.pow(2, -1)`, 0.5},
+		{`math.pow(4, 0.5)`, 2.0},
+		{`math.sqrt(16)`, 4.0},
+		{`math.floor(2.7)`, 2},
+		{`math.floor(-2.5)`, -3},
+		{`math.ceil(2.1)`, 3},
+		{`math.ceil(4)`, 4},
+		{`math.round(2.5)`, 3},
+		{`math.round(-2.5)`, -3},
+		{`math.round(3.14159, 2)`, 3.14},
+		{`math.round(1234.5, -2)`, 1200.0},
+		{`math.round(2.5, 400)`, 2.5},
+		{`math.round(math.pow(10.0, 300), 100)`, math.Pow(10, 300)},
+		{`math.round(2.5, -400)`, 0.0},
+		{`math.abs(-9223372036854775807)`, 9223372036854775807},
+		{`math.clamp(5, 0, 3)`, 3},
+		{`math.clamp(-1, 0, 3)`, 0},
+		{`math.clamp(1.5, 0, 3)`, 1.5},
+		{`math.sum([1, 2, 3])`, 6},
+		{`math.sum([1, 2.5])`, 3.5},
+		{`math.sum([])`, 0},
+		{`math.mod(7, 3)`, 1},
+		{`math.mod(-7, 3)`, 2},
+		{`math.mod(7, -3)`, -2},
+		{`math.mod(5.5, 2)`, 1.5},
+		{`math.gcd(12, 18)`, 6},
+		{`math.gcd(-4, 6)`, 2},
+		{`math.gcd(0, 5)`, 5},
+		{`math.gcd(-9223372036854775807 - 1, 6)`, 2},
+		{`math.PI`, math.Pi},
+		{`math.E`, math.E},
+		{`let m = math; m.abs(-1)`, 1},
+	}
+	for _, tt := range tests {
+		testLiteralObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestMathBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`math.abs("a")`, "argument to `math.abs` not supported, got=STRING"},
+		{`math.abs(1, 2)`, "wrong number of arguments for math.abs. got=2, want=1"},
+		{`math.min()`, "`math.min` needs at least one number"},
+		{`math.max([1, "a"])`, "argument to `math.max` not supported, got=STRING"},
+		{`math.pow(10, 100)`, "integer overflow in `math.pow`"},
+		{`math.abs(-9223372036854775807 - 1)`, "integer overflow in `math.abs`"},
+		{`math.gcd(-9223372036854775807 - 1, 0)`, "integer overflow in `math.gcd`"},
+		{`math.gcd(-9223372036854775807 - 1, -9223372036854775807 - 1)`, "integer overflow in `math.gcd`"},
+		{`math.sqrt(-1)`, "square root of negative number -1"},
+		{`math.floor(math.pow(2.0, 70))`, "1.1805916207174113e+21 cannot be converted to INTEGER"},
+		{`math.clamp(1, 3, 0)`, "lower bound 3 of `math.clamp` is greater than upper bound 0"},
+		{`math.mod(1, 0)`, "division by zero in `math.mod`"},
+		{`math.gcd(1.5, 2)`, "argument to `math.gcd` not supported, got=FLOAT"},
+		{`math.rand_int(0)`, "empty range given to `math.rand_int`"},
+		{`math.nope(1)`, "module math has no member nope"},
+	}
+	for _, tt := range tests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestRandomBuiltins(t *testing.T) {
+	run := func(input string) string {
+		return testEval(`math.seed(42); ` + input).Inspect()
+	}
+
+	for _, input := range []string{
+		`[math.rand_int(100), math.rand_int(100), math.rand_int(100)]`,
+		`math.rand_float()`,
+		`math.shuffle(range(10))`,
+	} {
+		if first, second := run(input), run(input); first != second {
+			t.Errorf("Expected the same results for the same seed, got %s and %s", first, second)
+		}
+	}
+
+	env := object.NewEnvironment()
+	for i := 0; i < 100; i++ {
+		value := testEvalEnv(`math.rand_int(-3, 3)`, env).(*object.Integer).Value
+		if value < -3 || value >= 3 {
+			t.Fatalf("Expected a value in [-3, 3), got %d", value)
+		}
+		float := testEvalEnv(`math.rand_float()`, env).(*object.Float).Value
+		if float < 0 || float >= 1 {
+			t.Fatalf("Expected a value in [0, 1), got %f", float)
+		}
+		// Ranges wider than the largest integer
+		value = testEvalEnv(`math.rand_int(-9223372036854775807, 9223372036854775807)`, env).(*object.Integer).Value
+		if value == math.MaxInt64 {
+			t.Fatalf("Expected a value below the upper bound, got %d", value)
+		}
+		value = testEvalEnv(`math.rand_int(9223372036854775806, 9223372036854775807)`, env).(*object.Integer).Value
+		if value != math.MaxInt64-1 {
+			t.Fatalf("Expected the only value in range, got %d", value)
+		}
+	}
+
+	testLiteralObject(t, testEval(`sort(math.shuffle(range(5)))`), []any{0, 1, 2, 3, 4})
+}
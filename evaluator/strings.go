@@ -0,0 +1,307 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/waridh/go-monkey-interpreter/functools"
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+// Builtins over strings. Positions and lengths are counted in runes rather
+// than bytes, matching len, indexing and slicing.
+var stringBuiltins = map[string]*object.Builtin{
+	"split": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments for split. got=%d, want=1 or 2", len(args))
+			}
+			strs, err := stringArgs("split", args)
+			if err != nil {
+				return err
+			}
+			// Without a separator, split on runs of whitespace
+			var parts []string
+			if len(strs) == 1 {
+				parts = strings.Fields(strs[0])
+			} else {
+				parts = strings.Split(strs[0], strs[1])
+			}
+			return track(env, &object.Array{Elements: stringObjects(parts)})
+		},
+	},
+	"join": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments for join. got=%d, want=1 or 2", len(args))
+			}
+			arr, ok := args[0].(*object.Array)
+			if !ok {
+				return newError("argument to `%s` not supported, got=%s", "join", args[0].Type())
+			}
+			sep := ""
+			if len(args) == 2 {
+				strs, err := stringArgs("join", args[1:])
+				if err != nil {
+					return err
+				}
+				sep = strs[0]
+			}
+			parts := functools.Map(arr.Elements, func(x object.Object) string { return x.Inspect() })
+			return track(env, &object.String{Value: strings.Join(parts, sep)})
+		},
+	},
+	"trim": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) != 1 && len(args) != 2 {
+				return newError("wrong number of arguments for trim. got=%d, want=1 or 2", len(args))
+			}
+			strs, err := stringArgs("trim", args)
+			if err != nil {
+				return err
+			}
+			// The optional second argument lists the runes to trim, instead
+			// of whitespace
+			if len(strs) == 2 {
+				return track(env, &object.String{Value: strings.Trim(strs[0], strs[1])})
+			}
+			return track(env, &object.String{Value: strings.TrimSpace(strs[0])})
+		},
+	},
+	"upper": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			strs, err := fixedStringArgs("upper", args, 1)
+			if err != nil {
+				return err
+			}
+			return track(env, &object.String{Value: strings.ToUpper(strs[0])})
+		},
+	},
+	"lower": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			strs, err := fixedStringArgs("lower", args, 1)
+			if err != nil {
+				return err
+			}
+			return track(env, &object.String{Value: strings.ToLower(strs[0])})
+		},
+	},
+	"contains": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			strs, err := fixedStringArgs("contains", args, 2)
+			if err != nil {
+				return err
+			}
+			return booleanObjectOfNativeBool(strings.Contains(strs[0], strs[1]))
+		},
+	},
+	"starts_with": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			strs, err := fixedStringArgs("starts_with", args, 2)
+			if err != nil {
+				return err
+			}
+			return booleanObjectOfNativeBool(strings.HasPrefix(strs[0], strs[1]))
+		},
+	},
+	"ends_with": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			strs, err := fixedStringArgs("ends_with", args, 2)
+			if err != nil {
+				return err
+			}
+			return booleanObjectOfNativeBool(strings.HasSuffix(strs[0], strs[1]))
+		},
+	},
+	"replace": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			strs, err := fixedStringArgs("replace", args, 3)
+			if err != nil {
+				return err
+			}
+			// An empty old string matches at every rune boundary, so even a
+			// short string can grow a lot. Check the size before building it
+			count := int64(strings.Count(strs[0], strs[1]))
+			growth := int64(len(strs[2]) - len(strs[1]))
+			if growth > 0 && count > (math.MaxInt32-int64(len(strs[0])))/growth {
+				return newError("result of `replace` is too large")
+			}
+			size := object.StringSize(int64(len(strs[0])) + count*growth)
+			if err := env.Runtime().Memory.Check(object.STRING_OBJ, size); err != nil {
+				return newError("%s", err)
+			}
+			return track(env, &object.String{Value: strings.ReplaceAll(strs[0], strs[1], strs[2])})
+		},
+	},
+	"index_of": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			strs, err := fixedStringArgs("index_of", args, 2)
+			if err != nil {
+				return err
+			}
+			idx := strings.Index(strs[0], strs[1])
+			if idx > 0 {
+				idx = utf8.RuneCountInString(strs[0][:idx])
+			}
+			return &object.Integer{Value: int64(idx)}
+		},
+	},
+	"repeat": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck("repeat", 2, args); err != nil {
+				return err
+			}
+			str, ok := args[0].(*object.String)
+			if !ok {
+				return newError("argument to `%s` not supported, got=%s", "repeat", args[0].Type())
+			}
+			count, err := integerArgs("repeat", args[1:])
+			if err != nil {
+				return err
+			}
+			if count[0] < 0 {
+				return newError("repeat count cannot be negative")
+			}
+			// Check the size up front, as the result may be far too big to
+			// build
+			if len(str.Value) > 0 && count[0] > math.MaxInt32/int64(len(str.Value)) {
+				return newError("result of `repeat` is too large")
+			}
+			size := object.StringSize(int64(len(str.Value)) * count[0])
+			if err := env.Runtime().Memory.Check(object.STRING_OBJ, size); err != nil {
+				return newError("%s", err)
+			}
+			return track(env, &object.String{Value: strings.Repeat(str.Value, int(count[0]))})
+		},
+	},
+	"chars": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			strs, err := fixedStringArgs("chars", args, 1)
+			if err != nil {
+				return err
+			}
+			chars := functools.Map([]rune(strs[0]), func(x rune) string { return string(x) })
+			return track(env, &object.Array{Elements: stringObjects(chars)})
+		},
+	},
+	"format": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if len(args) == 0 {
+				return newError("wrong number of arguments for format. got=0, want at least 1")
+			}
+			strs, err := stringArgs("format", args[:1])
+			if err != nil {
+				return err
+			}
+			if err := checkFormat(strs[0], args[1:]); err != nil {
+				return err
+			}
+			values := functools.Map(args[1:], formatValue)
+			return track(env, &object.String{Value: fmt.Sprintf(strs[0], values...)})
+		},
+	},
+}
+
+func stringArgs(name string, args []object.Object) ([]string, object.Object) {
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		str, ok := arg.(*object.String)
+		if !ok {
+			return nil, newError("argument to `%s` not supported, got=%s", name, arg.Type())
+		}
+		strs[i] = str.Value
+	}
+	return strs, nil
+}
+
+// fixedStringArgs checks the arguments of builtins taking expected strings
+func fixedStringArgs(name string, args []object.Object, expected int) ([]string, object.Object) {
+	if err := builtinLenCheck(name, expected, args); err != nil {
+		return nil, err
+	}
+	return stringArgs(name, args)
+}
+
+func stringObjects(strs []string) []object.Object {
+	return functools.Map(strs, func(x string) object.Object { return &object.String{Value: x} })
+}
+
+// inspected formats an object the way puts prints it
+type inspected struct {
+	object.Object
+}
+
+func (i inspected) String() string { return i.Inspect() }
+
+// formatVerbs lists the verbs that can format each type of object, beyond %v
+// which formats anything. Objects of other types format as they are printed.
+var formatVerbs = map[object.ObjectType]string{
+	object.INTEGER_OBJ: "bcdoOqxXU",
+	object.FLOAT_OBJ:   "beEfFgGxX",
+	object.STRING_OBJ:  "sqxX",
+	object.BOOLEAN_OBJ: "t",
+}
+
+// checkFormat makes sure that format has a verb for each of args, and that
+// each verb can format its argument, rather than letting fmt write its
+// complaints into the result
+func checkFormat(format string, args []object.Object) object.Object {
+	verbs := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		if i < len(format) && format[i] == '%' {
+			continue
+		}
+		i += len(format[i:]) - len(strings.TrimLeft(format[i:], "+-# 0"))
+		i += len(format[i:]) - len(strings.TrimLeft(format[i:], "0123456789"))
+		if i < len(format) && format[i] == '.' {
+			i++
+			i += len(format[i:]) - len(strings.TrimLeft(format[i:], "0123456789"))
+		}
+		if i >= len(format) {
+			return newError("format ends with an incomplete verb")
+		}
+		verb, size := utf8.DecodeRuneInString(format[i:])
+		i += size - 1
+		if verb == '*' || verb == '[' {
+			return newError("format does not support %%%c", verb)
+		}
+		if verbs < len(args) && verb != 'v' {
+			arg := args[verbs]
+			allowed, ok := formatVerbs[arg.Type()]
+			if !ok {
+				allowed = "sqxX"
+			}
+			if !strings.ContainsRune(allowed, verb) {
+				return newError("format verb %%%c cannot format %s", verb, arg.Type())
+			}
+		}
+		verbs++
+	}
+	if verbs != len(args) {
+		return newError("format has %d verbs but got %d arguments", verbs, len(args))
+	}
+	return nil
+}
+
+// formatValue turns obj into the Go value that format hands to fmt, so that
+// verbs such as %d and %.2f work as they do in Go
+func formatValue(obj object.Object) any {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return obj.Value
+	case *object.Float:
+		return obj.Value
+	case *object.String:
+		return obj.Value
+	case *object.Boolean:
+		return obj.Value
+	default:
+		return inspected{obj}
+	}
+}
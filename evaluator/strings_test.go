@@ -0,0 +1,134 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+func TestStringIndexAndSlice(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`"hello"[1]`, "e"},
+		{`"hello"[-1]`, "o"},
+		{`"hello"[5]`, nil},
+		{`"héllo"[1]`, "é"},
+		{`"日本語"[-1]`, "語"},
+		{`"日本語"[-3]`, "日"},
+		{`"日本語"[-4]`, nil},
+		{`""[0]`, nil},
+		{`"hello"[1:3]`, "el"},
+		{`"hello"[:2]`, "he"},
+		{`"hello"[3:]`, "lo"},
+		{`"hello"[:]`, "hello"},
+		{`"hello"[-3:-1]`, "ll"},
+		{`"hello"[4:1]`, ""},
+		{`"日本語です"[1:3]`, "本語"},
+		{`[1, 2, 3, 4][1:3]`, []any{2, 3}},
+		{`[1, 2, 3, 4][2:]`, []any{3, 4}},
+		{`[1, 2, 3, 4][:-1]`, []any{1, 2, 3}},
+		{`[1, 2, 3][0:100]`, []any{1, 2, 3}},
+		{`let a = [1, 2, 3]; let n = 1; a[n:n + 1]`, []any{2}},
+		{`len("héllo")`, 5},
+	}
+	for _, tt := range tests {
+		testLiteralObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`split("a,b,c", ",")`, []any{"a", "b", "c"}},
+		{`split("  a  b c ")`, []any{"a", "b", "c"}},
+		{`split("héllo", "")`, []any{"h", "é", "l", "l", "o"}},
+		{`join(["a", "b", "c"], "-")`, "a-b-c"},
+		{`join([1, true, "x"])`, "1truex"},
+		{`join([], ",")`, ""},
+		{`trim("  hi  ")`, "hi"},
+		{`trim("xxhixx", "x")`, "hi"},
+		{`upper("héllo")`, "HÉLLO"},
+		{`lower("HeLLo")`, "hello"},
+		{`contains("monkey", "key")`, true},
+		{`contains("monkey", "lion")`, false},
+		{`starts_with("monkey", "mon")`, true},
+		{`ends_with("monkey", "mon")`, false},
+		{`replace("a-b-c", "-", "+")`, "a+b+c"},
+		{`replace("日本", "", "-")`, "-日-本-"},
+		{`index_of("héllo", "llo")`, 2},
+		{`index_of("hello", "h")`, 0},
+		{`index_of("hello", "z")`, -1},
+		{`repeat("ab", 3)`, "ababab"},
+		{`repeat("ab", 0)`, ""},
+		{`chars("日本")`, []any{"日", "本"}},
+		{`format("%s is %d", "x", 5)`, "x is 5"},
+		{`format("%05d|%-3s|%t", 42, "a", true)`, "00042|a  |true"},
+		{`format("%v and %s", [1, 2], {"a": 1})`, "[1, 2] and {a: 1}"},
+		{`format("100%%")`, "100%"},
+		{`format("%.2f|%8.3e|%x", 3.14159, 1.5, 255)`, "3.14|1.500e+00|ff"},
+		{`format("%v %v", 1.5, "a")`, "1.5 a"},
+		{`format("%s", [1])`, "[1]"},
+		{`join(map(split("a b", " "), upper), "")`, "AB"},
+	}
+	for _, tt := range tests {
+		testLiteralObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestStringBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`split(1, ",")`, "argument to `split` not supported, got=INTEGER"},
+		{`split("a", ",", 1)`, "wrong number of arguments for split. got=3, want=1 or 2"},
+		{`join("abc")`, "argument to `join` not supported, got=STRING"},
+		{`upper()`, "wrong number of arguments for upper. got=0, want=1"},
+		{`contains([1], 1)`, "argument to `contains` not supported, got=ARRAY"},
+		{`repeat("a", -1)`, "repeat count cannot be negative"},
+		{`repeat("ab", 9999999999)`, "result of `repeat` is too large"},
+		{`replace(repeat("a", 1000), "", repeat("b", 3000000))`, "result of `replace` is too large"},
+		{`format()`, "wrong number of arguments for format. got=0, want at least 1"},
+		{`format("%s and %s", "x")`, "format has 2 verbs but got 1 arguments"},
+		{`format("%d", 1, 2)`, "format has 1 verbs but got 2 arguments"},
+		{`format("%d", "x")`, "format verb %d cannot format STRING"},
+		{`format("%f", 1)`, "format verb %f cannot format INTEGER"},
+		{`format("50%")`, "format ends with an incomplete verb"},
+		{`format("%*d", 5, 1)`, "format does not support %*"},
+		{`"hello"["a"]`, "STRING can only be indexed using INTEGER"},
+		{`"hello"[1:"a"]`, "STRING can only be sliced using INTEGER, got=STRING"},
+		{`{"a": 1}[0:1]`, "HASH does not support slicing"},
+	}
+	for _, tt := range tests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestRepeatMemoryLimit(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Runtime().Memory.Limit = 1 << 10
+	testErrorObject(t, testEvalEnv(`repeat("ab", 1000)`, env),
+		"memory limit exceeded: allocating 2016 bytes for STRING with 18 of 1024 bytes in use")
+}
+
+func TestReplaceMemoryLimit(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Runtime().Memory.Limit = 1 << 10
+	testErrorObject(t, testEvalEnv(`replace(repeat("a", 100), "", "0123456789")`, env),
+		"memory limit exceeded: allocating 1126 bytes for STRING with 175 of 1024 bytes in use")
+}
+
+func TestStringIndexTracked(t *testing.T) {
+	literal := object.NewEnvironment()
+	testEvalEnv(`"abc"`, literal)
+	indexed := object.NewEnvironment()
+	testEvalEnv(`"abc"[1]`, indexed)
+
+	if objects := indexed.Runtime().Memory.Stats().Objects - literal.Runtime().Memory.Stats().Objects; objects != 1 {
+		t.Errorf("Expected indexing to track 1 object, got %d", objects)
+	}
+}
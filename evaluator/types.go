@@ -0,0 +1,139 @@
+package evaluator
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+// Builtins for asking what a value is, and for converting between types
+var typeBuiltins = map[string]*object.Builtin{
+	"type": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck("type", 1, args); err != nil {
+				return err
+			}
+			return &object.String{Value: string(args[0].Type())}
+		},
+	},
+	"int": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck("int", 1, args); err != nil {
+				return err
+			}
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return arg
+			case *object.Float:
+				// Floats are truncated towards zero
+				if math.IsNaN(arg.Value) || arg.Value < math.MinInt64 || arg.Value >= math.MaxInt64 {
+					return newError("cannot convert %s to %s", arg.Inspect(), object.INTEGER_OBJ)
+				}
+				return &object.Integer{Value: int64(arg.Value)}
+			case *object.String:
+				value, err := strconv.ParseInt(strings.TrimSpace(arg.Value), 10, 64)
+				if err != nil {
+					return newError("cannot convert %q to %s", arg.Value, object.INTEGER_OBJ)
+				}
+				return &object.Integer{Value: value}
+			case *object.Boolean:
+				if arg.Value {
+					return &object.Integer{Value: 1}
+				}
+				return &object.Integer{Value: 0}
+			default:
+				return newError("cannot convert %s to %s", arg.Type(), object.INTEGER_OBJ)
+			}
+		},
+	},
+	"float": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck("float", 1, args); err != nil {
+				return err
+			}
+			switch arg := args[0].(type) {
+			case *object.Integer, *object.Float:
+				return &object.Float{Value: toFloat(arg)}
+			case *object.String:
+				value, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+				if err != nil {
+					return newError("cannot convert %q to %s", arg.Value, object.FLOAT_OBJ)
+				}
+				return &object.Float{Value: value}
+			case *object.Boolean:
+				if arg.Value {
+					return &object.Float{Value: 1}
+				}
+				return &object.Float{Value: 0}
+			default:
+				return newError("cannot convert %s to %s", arg.Type(), object.FLOAT_OBJ)
+			}
+		},
+	},
+	"str": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck("str", 1, args); err != nil {
+				return err
+			}
+			if str, ok := args[0].(*object.String); ok {
+				return str
+			}
+			return track(env, &object.String{Value: args[0].Inspect()})
+		},
+	},
+	"bool": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck("bool", 1, args); err != nil {
+				return err
+			}
+			// The same rules as conditions in if expressions
+			return booleanObjectOfNativeBool(isTruthy(args[0]))
+		},
+	},
+	"array": {
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck("array", 1, args); err != nil {
+				return err
+			}
+			switch arg := args[0].(type) {
+			case *object.Array:
+				return arg
+			case *object.String:
+				return stringBuiltins["chars"].Fn(env, arg)
+			case *object.Hash:
+				return hashBuiltins["entries"].Fn(env, arg)
+			default:
+				return newError("cannot convert %s to %s", arg.Type(), object.ARRAY_OBJ)
+			}
+		},
+	},
+	"is_int":      typePredicate("is_int", object.INTEGER_OBJ),
+	"is_float":    typePredicate("is_float", object.FLOAT_OBJ),
+	"is_number":   typePredicate("is_number", object.INTEGER_OBJ, object.FLOAT_OBJ),
+	"is_string":   typePredicate("is_string", object.STRING_OBJ),
+	"is_bool":     typePredicate("is_bool", object.BOOLEAN_OBJ),
+	"is_array":    typePredicate("is_array", object.ARRAY_OBJ),
+	"is_hash":     typePredicate("is_hash", object.HASH_OBJ),
+	"is_null":     typePredicate("is_null", object.NULL_OBJ),
+	"is_function": typePredicate("is_function", object.FUNCTION_OBJ, object.BUILTIN_OBJ),
+}
+
+// typePredicate builds a builtin reporting whether its argument has one of
+// types
+func typePredicate(name string, types ...object.ObjectType) *object.Builtin {
+	return &object.Builtin{
+		Fn: func(env *object.Environment, args ...object.Object) object.Object {
+			if err := builtinLenCheck(name, 1, args); err != nil {
+				return err
+			}
+			for _, typ := range types {
+				if args[0].Type() == typ {
+					return TRUE
+				}
+			}
+			return FALSE
+		},
+	}
+}
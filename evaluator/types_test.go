@@ -0,0 +1,75 @@
+package evaluator
+
+import "testing"
+
+func TestTypeBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected any
+	}{
+		{`type(1)`, "INTEGER"},
+		{`type(1.5)`, "FLOAT"},
+		{`type("a")`, "STRING"},
+		{`type([])`, "ARRAY"},
+		{`type({})`, "HASH"},
+		{`type(first([]))`, "NULL"},
+		{`type(fn(x) { x })`, "FUNCTION"},
+		{`type(len)`, "BUILTIN"},
+		{`type(math)`, "MODULE"},
+		{`int(" 42 ")`, 42},
+		{`int("-7")`, -7},
+		{`int(3.9)`, 3},
+		{`int(-3.9)`, -3},
+		{`int(true)`, 1},
+		{`float("2.5")`, 2.5},
+		{`float(2)`, 2.0},
+		{`float(false)`, 0.0},
+		{`str(12)`, "12"},
+		{`str(1.0)`, "1.0"},
+		{`str([1, "a"])`, "[1, a]"},
+		{`str("a")`, "a"},
+		{`bool(0)`, true},
+		{`bool(first([]))`, false},
+		{`bool(false)`, false},
+		{`array("ab")`, []any{"a", "b"}},
+		{`array({"a": 1})`, []any{[]any{"a", 1}}},
+		{`array([1])`, []any{1}},
+		{`is_int(1)`, true},
+		{`is_int(1.0)`, false},
+		{`is_float(1.0)`, true},
+		{`is_number(1)`, true},
+		{`is_number("1")`, false},
+		{`is_string("1")`, true},
+		{`is_bool(false)`, true},
+		{`is_array([])`, true},
+		{`is_hash({})`, true},
+		{`is_null(first([]))`, true},
+		{`is_function(len)`, true},
+		{`is_function(fn() { 1 })`, true},
+		{`is_function(1)`, false},
+		{`int(str(int("12")) + "3") + 1`, 124},
+	}
+	for _, tt := range tests {
+		testLiteralObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestTypeBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`int("abc")`, `cannot convert "abc" to INTEGER`},
+		{`int("1.5")`, `cannot convert "1.5" to INTEGER`},
+		{`int([1])`, "cannot convert ARRAY to INTEGER"},
+		{`int(math.pow(2.0, 64))`, "cannot convert 1.8446744073709552e+19 to INTEGER"},
+		{`float("x")`, `cannot convert "x" to FLOAT`},
+		{`float({})`, "cannot convert HASH to FLOAT"},
+		{`array(1)`, "cannot convert INTEGER to ARRAY"},
+		{`type()`, "wrong number of arguments for type. got=0, want=1"},
+		{`is_int(1, 2)`, "wrong number of arguments for is_int. got=2, want=1"},
+	}
+	for _, tt := range tests {
+		testErrorObject(t, testEval(tt.input), tt.expected)
+	}
+}
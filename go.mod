@@ -1,3 +1,3 @@
 module github.com/waridh/go-monkey-interpreter
 
-go 1.22.1
+go 1.24
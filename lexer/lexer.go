@@ -1,6 +1,8 @@
 package lexer
 
 import (
+	"strings"
+
 	"github.com/waridh/go-monkey-interpreter/token"
 )
 
@@ -9,6 +11,13 @@ type Lexer struct {
 	position     int
 	readPosition int
 	ch           byte // This is the character currently being pointed to
+
+	// Where tokens are, which locate works out by scanning forward
+	line      int
+	lineStart int // Offset of the first character of line
+	scanned   int // Offset up to which newlines have been counted
+
+	comments []token.Token
 }
 
 // New is the base constructor for the Lexer struct
@@ -17,6 +26,7 @@ func New(input string) *Lexer {
 		input:        input,
 		position:     0,
 		readPosition: 0,
+		line:         1,
 	}
 	l.readChar() // setup the struct
 	return l
@@ -58,15 +68,26 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readNumber() string {
+// readNumber reads an integer, or a float when the digits are followed by a
+// '.' and more digits. Requiring a digit after the '.' keeps member access
+// such as `x.y` working.
+func (l *Lexer) readNumber() (token.TokenType, string) {
 	position := l.position
+	tokenType := token.TokenType(token.INT)
 
 	for isDigit(l.ch) {
 		l.readChar()
 	}
+	if l.ch == '.' && isDigit(l.peakAhead()) {
+		tokenType = token.FLOAT
+		l.readChar()
+		for isDigit(l.ch) {
+			l.readChar()
+		}
+	}
 	l.readPosition -= 1
 
-	return l.input[position:l.position]
+	return tokenType, l.input[position:l.position]
 }
 
 func (l *Lexer) skipWhiteSpace() {
@@ -75,6 +96,34 @@ func (l *Lexer) skipWhiteSpace() {
 	}
 }
 
+// readComment reads a `//` comment up to the end of the line
+func (l *Lexer) readComment() string {
+	position := l.position
+	for l.ch != '\n' && l.ch != 0 {
+		l.readChar()
+	}
+	return strings.TrimRight(l.input[position:l.position], " \t\r")
+}
+
+// locate returns the line and column of the character at offset. Tokens are
+// located in order, so newlines only need to be counted once.
+func (l *Lexer) locate(offset int) (int, int) {
+	offset = min(offset, len(l.input))
+	for ; l.scanned < offset; l.scanned++ {
+		if l.input[l.scanned] == '\n' {
+			l.line++
+			l.lineStart = l.scanned + 1
+		}
+	}
+	return l.line, offset - l.lineStart + 1
+}
+
+// Comments lists the comments read so far, in order. They are not returned
+// by NextToken, so that the parser never sees them.
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
+
 func (l *Lexer) readString() string {
 	l.readChar()
 	position := l.position
@@ -88,6 +137,13 @@ func (l *Lexer) NextToken() token.Token {
 	// Handle the single charcters first
 	var tok token.Token
 	l.skipWhiteSpace()
+	for l.ch == '/' && l.peakAhead() == '/' {
+		line, column := l.locate(l.position)
+		comment := token.Token{Type: token.COMMENT, Literal: l.readComment(), Line: line, Column: column}
+		l.comments = append(l.comments, comment)
+		l.skipWhiteSpace()
+	}
+	line, column := l.locate(l.position)
 
 	switch l.ch {
 	case 0:
@@ -122,6 +178,8 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.SEMICOLON, l.ch)
 	case ':':
 		tok = newToken(token.COLON, l.ch)
+	case '.':
+		tok = newToken(token.DOT, l.ch)
 	case '!':
 		if l.peakAhead() == '=' {
 			tok = token.Token{Type: token.NOT_EQ, Literal: "!="}
@@ -144,12 +202,13 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
 		} else if isDigit(l.ch) {
-			tok = token.Token{Type: token.INT, Literal: l.readNumber()}
+			tok.Type, tok.Literal = l.readNumber()
 		} else {
 			tok = newToken(token.ILLEGAL, l.ch)
 		}
 	}
 
+	tok.Line, tok.Column = line, column
 	l.readChar()
 	return tok
 }
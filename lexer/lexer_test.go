@@ -202,3 +202,104 @@ func TestNextToken8(t *testing.T) {
 	}
 	runLexerTest(t, tests, input)
 }
+
+func TestNextToken9(t *testing.T) {
+	input := `math.abs(x);`
+
+	tests := []lexerTests{
+		{token.IDENT, "math"},
+		{token.DOT, "."},
+		{token.IDENT, "abs"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+	}
+	runLexerTest(t, tests, input)
+}
+
+func TestNextToken10(t *testing.T) {
+	input := `3.14 + 2. - a.b;`
+
+	tests := []lexerTests{
+		{token.FLOAT, "3.14"},
+		{token.PLUS, "+"},
+		{token.INT, "2"},
+		{token.DOT, "."},
+		{token.MINUS, "-"},
+		{token.IDENT, "a"},
+		{token.DOT, "."},
+		{token.IDENT, "b"},
+		{token.SEMICOLON, ";"},
+	}
+	runLexerTest(t, tests, input)
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading
+let x = 10 / 2; // trailing  
+// last`
+
+	tests := []lexerTests{
+		{token.LET, "let"},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "10"},
+		{token.SLASH, "/"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, "\x00"},
+	}
+	runLexerTest(t, tests, input)
+
+	l := New(input)
+	for l.NextToken().Type != token.EOF {
+	}
+	expected := []token.Token{
+		{Type: token.COMMENT, Literal: "// leading", Line: 1, Column: 1},
+		{Type: token.COMMENT, Literal: "// trailing", Line: 2, Column: 17},
+		{Type: token.COMMENT, Literal: "// last", Line: 3, Column: 1},
+	}
+	comments := l.Comments()
+	if len(comments) != len(expected) {
+		t.Fatalf("Expected %d comments, got %d", len(expected), len(comments))
+	}
+	for i, comment := range comments {
+		if comment != expected[i] {
+			t.Errorf("comments[%d] - expected %+v, got %+v", i, expected[i], comment)
+		}
+	}
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := "let five = 5;\n\nif (five) {\n  \"str\" == 2.5\n}"
+
+	expected := []struct {
+		literal      string
+		line, column int
+	}{
+		{"let", 1, 1},
+		{"five", 1, 5},
+		{"=", 1, 10},
+		{"5", 1, 12},
+		{";", 1, 13},
+		{"if", 3, 1},
+		{"(", 3, 4},
+		{"five", 3, 5},
+		{")", 3, 9},
+		{"{", 3, 11},
+		{"str", 4, 3},
+		{"==", 4, 9},
+		{"2.5", 4, 12},
+		{"}", 5, 1},
+	}
+
+	l := New(input)
+	for i, tt := range expected {
+		tok := l.NextToken()
+		if tok.Literal != tt.literal || tok.Line != tt.line || tok.Column != tt.column {
+			t.Errorf("tests[%d] - expected %q at %d:%d, got %q at %d:%d",
+				i, tt.literal, tt.line, tt.column, tok.Literal, tok.Line, tok.Column)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/waridh/go-monkey-interpreter/evaluator"
+	"github.com/waridh/go-monkey-interpreter/lexer"
+	"github.com/waridh/go-monkey-interpreter/lint"
+	"github.com/waridh/go-monkey-interpreter/parser"
+	"github.com/waridh/go-monkey-interpreter/test"
+)
+
+// lintCommand reports the findings of the linter for each file, failing if
+// there are any
+func lintCommand(args []string) int {
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey lint files")
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	builtins := evaluator.NewBuiltins()
+	// Test files can also use the assertions of the test runner
+	testBuiltins := builtins.Clone()
+	test.Register(testBuiltins)
+	status := 0
+	for _, path := range flags.Args() {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+			continue
+		}
+		p := parser.New(lexer.New(string(src)))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			for _, msg := range p.Errors() {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, msg)
+			}
+			status = 1
+			continue
+		}
+		known, tests := builtins, map[[2]int]bool{}
+		if strings.HasSuffix(path, test.Suffix) {
+			known = testBuiltins
+			for _, let := range test.Functions(program) {
+				tests[[2]int{let.Name.Token.Line, let.Name.Token.Column}] = true
+			}
+		}
+		for _, finding := range lint.Check(program, known) {
+			// Tests are used by the test runner
+			if finding.Rule == lint.Unused && tests[[2]int{finding.Line, finding.Column}] {
+				continue
+			}
+			fmt.Printf("%s:%s\n", path, finding)
+			status = 1
+		}
+	}
+	return status
+}
@@ -0,0 +1,411 @@
+// Package lint finds mistakes in Monkey programs without running them, such
+// as names that are never defined and bindings that are never used.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/waridh/go-monkey-interpreter/ast"
+	"github.com/waridh/go-monkey-interpreter/object"
+	"github.com/waridh/go-monkey-interpreter/token"
+)
+
+// The rules that findings are reported under
+const (
+	Undefined       = "undefined"        // A name that is not bound anywhere
+	Unused          = "unused"           // A binding that is never read
+	ShadowedBuiltin = "shadowed-builtin" // A binding named like a builtin
+	Unreachable     = "unreachable"      // A statement after a return
+	NotCallable     = "not-callable"     // A call of a literal that is not a function
+)
+
+var rulesByID = map[string]bool{
+	Undefined:       true,
+	Unused:          true,
+	ShadowedBuiltin: true,
+	Unreachable:     true,
+	NotCallable:     true,
+}
+
+// Finding is a problem found in a program
+type Finding struct {
+	Rule    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%d:%d: %s (%s)", f.Line, f.Column, f.Message, f.Rule)
+}
+
+// Check lints program, in order of position. Names that builtins knows
+// about count as defined, whether or not their capabilities are enabled.
+//
+// Findings can be silenced with a `// lint:ignore rule, ...` comment on the
+// line of the finding or on the line above it. Without any rules, the
+// comment silences every finding.
+//
+// Bindings whose names start with an underscore are never reported as
+// unused.
+func Check(program *ast.Program, builtins *object.Builtins) []Finding {
+	l := &linter{builtins: builtins}
+	l.scope = newScope(nil)
+	l.statements(program.Statements)
+	l.closeScope()
+	l.notCallable(program)
+
+	findings := suppress(l.findings, program.Comments)
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+		return findings[i].Column < findings[j].Column
+	})
+	return findings
+}
+
+// Resolve finds the binding that each identifier in program refers to.
+// Identifiers that make a binding refer to it too, and those naming builtins
+// or nothing at all map to nil. When a function uses a name that is bound
+// more than once around it, the last of the bindings is taken.
+func Resolve(program *ast.Program) map[*ast.Identifier]*Binding {
+	l := &linter{builtins: object.NewBuiltins(), refs: make(map[*ast.Identifier]*binding)}
+	l.scope = newScope(nil)
+	l.statements(program.Statements)
+	l.closeScope()
+
+	refs := make(map[*ast.Identifier]*Binding, len(l.refs))
+	for ident, b := range l.refs {
+		if b == nil {
+			refs[ident] = nil
+			continue
+		}
+		refs[ident] = &b.Binding
+	}
+	return refs
+}
+
+// Binding is a name made by a let statement, a function parameter or an
+// import
+type Binding struct {
+	Name  *ast.Identifier
+	Kind  string         // One of "variable", "parameter" or "import"
+	Value ast.Expression // The value of let statements
+}
+
+type binding struct {
+	Binding
+	exported bool
+	used     bool
+}
+
+// A use of a name that its own scope does not bind
+type use struct {
+	name *ast.Identifier
+	// Whether the use happens when a function is called, rather than when the
+	// scope is evaluated, so that bindings made later in the scope count
+	deferred bool
+}
+
+// scope holds the bindings of a program or function body. Blocks of if
+// expressions bind in the scope they are in, as they do when evaluated.
+type scope struct {
+	parent   *scope
+	bindings map[string][]*binding
+	order    []*binding
+	free     []use
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{parent: parent, bindings: make(map[string][]*binding)}
+}
+
+type linter struct {
+	builtins *object.Builtins
+	scope    *scope
+	findings []Finding
+	refs     map[*ast.Identifier]*binding // Kept when resolving, and nil otherwise
+}
+
+// refer records that ident refers to b
+func (l *linter) refer(ident *ast.Identifier, b *binding) {
+	if l.refs != nil {
+		l.refs[ident] = b
+	}
+}
+
+func (l *linter) report(rule string, tok token.Token, format string, a ...any) {
+	l.findings = append(l.findings, Finding{
+		Rule:    rule,
+		Line:    tok.Line,
+		Column:  tok.Column,
+		Message: fmt.Sprintf(format, a...),
+	})
+}
+
+func (l *linter) isBuiltin(name string) bool {
+	if _, ok := l.builtins.Lookup(name); ok {
+		return true
+	}
+	_, ok := l.builtins.Disabled(name)
+	return ok
+}
+
+// bind adds a binding to the current scope
+func (l *linter) bind(name *ast.Identifier, kind string, value ast.Expression, exported bool) {
+	if l.isBuiltin(name.Value) {
+		l.report(ShadowedBuiltin, name.Token, "%s %s shadows the builtin %s", kind, name.Value, name.Value)
+	}
+	b := &binding{Binding: Binding{Name: name, Kind: kind, Value: value}, exported: exported}
+	l.refer(name, b)
+	l.scope.bindings[name.Value] = append(l.scope.bindings[name.Value], b)
+	l.scope.order = append(l.scope.order, b)
+}
+
+// resolve marks the binding that name refers to as used. Names the current
+// scope has not bound yet are looked up once the scope is closed.
+func (l *linter) resolve(name *ast.Identifier) {
+	if bindings := l.scope.bindings[name.Value]; len(bindings) != 0 {
+		bindings[len(bindings)-1].used = true
+		l.refer(name, bindings[len(bindings)-1])
+		return
+	}
+	l.scope.free = append(l.scope.free, use{name: name})
+}
+
+// closeScope reports the unused bindings of the current scope, and passes
+// the names it could not resolve on to the enclosing scope. Names that no
+// scope binds must be builtins.
+func (l *linter) closeScope() {
+	s := l.scope
+	free := []use{}
+	for _, u := range s.free {
+		if bindings := s.bindings[u.name.Value]; u.deferred && len(bindings) != 0 {
+			// Which of the bindings is used depends on when the function is
+			// called, so they all are
+			for _, b := range bindings {
+				b.used = true
+			}
+			l.refer(u.name, bindings[len(bindings)-1])
+			continue
+		}
+		free = append(free, u)
+	}
+
+	for _, b := range s.order {
+		if !b.used && !b.exported && !strings.HasPrefix(b.Name.Value, "_") {
+			l.report(Unused, b.Name.Token, "%s %s is never used", b.Kind, b.Name.Value)
+		}
+	}
+
+	l.scope = s.parent
+	if l.scope == nil {
+		for _, u := range free {
+			l.refer(u.name, nil)
+			if !l.isBuiltin(u.name.Value) {
+				l.report(Undefined, u.name.Token, "undefined: %s", u.name.Value)
+			}
+		}
+		return
+	}
+	for _, u := range free {
+		l.scope.free = append(l.scope.free, use{name: u.name, deferred: true})
+	}
+}
+
+func (l *linter) statements(stmts []ast.Statement) {
+	for i, stmt := range stmts {
+		l.statement(stmt)
+		if _, ok := stmt.(*ast.ReturnStatement); ok && i+1 < len(stmts) {
+			l.report(Unreachable, ast.StartOf(stmts[i+1]), "unreachable code")
+			for _, rest := range stmts[i+1:] {
+				l.statement(rest)
+			}
+			return
+		}
+	}
+}
+
+func (l *linter) statement(stmt ast.Statement) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		// The value is evaluated before the name is bound
+		l.expression(stmt.Value)
+		l.bind(stmt.Name, "variable", stmt.Value, stmt.Exported)
+	case *ast.ReturnStatement:
+		l.expression(stmt.ReturnValue)
+	case *ast.ExpressionStatement:
+		l.expression(stmt.Expression)
+	case *ast.ImportStatement:
+		if stmt.Alias != nil {
+			l.bind(stmt.Alias, "import", nil, false)
+		}
+		for _, name := range stmt.Names {
+			l.bind(name, "import", nil, false)
+		}
+	case *ast.BlockStatement:
+		l.statements(stmt.Statements)
+	}
+}
+
+func (l *linter) expression(expr ast.Expression) {
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		l.resolve(expr)
+	case *ast.PrefixExpression:
+		l.expression(expr.Right)
+	case *ast.InfixExpression:
+		l.expression(expr.Left)
+		l.expression(expr.Right)
+	case *ast.IfExpression:
+		l.expression(expr.Condition)
+		l.statements(expr.Consequence.Statements)
+		if expr.Alternative != nil {
+			l.statements(expr.Alternative.Statements)
+		}
+	case *ast.FunctionLiteral:
+		l.scope = newScope(l.scope)
+		for _, param := range expr.Parameter {
+			l.bind(param, "parameter", nil, false)
+		}
+		l.statements(expr.Body.Statements)
+		l.closeScope()
+	case *ast.ArrayLiteral:
+		l.expressions(expr.Elements)
+	case *ast.HashLiteral:
+		for _, key := range expr.Keys {
+			l.expression(key)
+			l.expression(expr.Pairs[key])
+		}
+	case *ast.CallExpression:
+		l.expression(expr.Function)
+		l.expressions(expr.Arguments)
+	case *ast.IndexExpression:
+		l.expression(expr.Left)
+		l.expression(expr.Index)
+	case *ast.SliceExpression:
+		l.expression(expr.Left)
+		if expr.Start != nil {
+			l.expression(expr.Start)
+		}
+		if expr.End != nil {
+			l.expression(expr.End)
+		}
+	case *ast.MemberExpression:
+		// Members are looked up in what is on the left, not in scope
+		l.expression(expr.Left)
+	}
+}
+
+func (l *linter) expressions(exprs []ast.Expression) {
+	for _, expr := range exprs {
+		l.expression(expr)
+	}
+}
+
+// notCallable reports the calls of literals that are not functions. Scopes
+// do not matter to it, so it is a pass of its own.
+func (l *linter) notCallable(program *ast.Program) {
+	ast.Inspect(program, func(node ast.Node) bool {
+		call, ok := node.(*ast.CallExpression)
+		if !ok {
+			return true
+		}
+		if typ, ok := literalType(call.Function); ok {
+			l.report(NotCallable, startOfLiteral(call.Function), "cannot call %s, it is not a function", typ)
+		}
+		return true
+	})
+}
+
+// literalType returns the type of values that expr evaluates to when it is
+// a literal of something other than a function
+func literalType(expr ast.Expression) (object.ObjectType, bool) {
+	switch expr.(type) {
+	case *ast.IntegerLiteral:
+		return object.INTEGER_OBJ, true
+	case *ast.FloatLiteral:
+		return object.FLOAT_OBJ, true
+	case *ast.StringLiteral:
+		return object.STRING_OBJ, true
+	case *ast.Boolean:
+		return object.BOOLEAN_OBJ, true
+	case *ast.ArrayLiteral:
+		return object.ARRAY_OBJ, true
+	case *ast.HashLiteral:
+		return object.HASH_OBJ, true
+	default:
+		return "", false
+	}
+}
+
+// startOfLiteral returns the first token of the literal expr
+func startOfLiteral(expr ast.Expression) token.Token {
+	switch expr := expr.(type) {
+	case *ast.IntegerLiteral:
+		return expr.Token
+	case *ast.FloatLiteral:
+		return expr.Token
+	case *ast.StringLiteral:
+		return expr.Token
+	case *ast.Boolean:
+		return expr.Token
+	case *ast.ArrayLiteral:
+		return expr.Token
+	case *ast.HashLiteral:
+		return expr.Token
+	default:
+		return token.Token{}
+	}
+}
+
+// suppress drops the findings silenced by `// lint:ignore` comments
+func suppress(findings []Finding, comments []token.Token) []Finding {
+	// The rules silenced on each line, where an empty rule silences all
+	ignored := map[int][]string{}
+	for _, comment := range comments {
+		text := strings.TrimSpace(strings.TrimPrefix(comment.Literal, "//"))
+		rest, ok := strings.CutPrefix(text, "lint:ignore")
+		if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+			continue
+		}
+		// The rules come first, and anything after them is the reason
+		rules := []string{}
+		for _, field := range strings.FieldsFunc(rest, isSeparator) {
+			if !rulesByID[field] {
+				break
+			}
+			rules = append(rules, field)
+		}
+		if len(rules) == 0 {
+			rules = []string{""}
+		}
+		for _, line := range []int{comment.Line, comment.Line + 1} {
+			ignored[line] = append(ignored[line], rules...)
+		}
+	}
+
+	kept := []Finding{}
+	for _, finding := range findings {
+		if !isIgnored(finding, ignored[finding.Line]) {
+			kept = append(kept, finding)
+		}
+	}
+	return kept
+}
+
+func isSeparator(r rune) bool {
+	return r == ',' || r == ' ' || r == '\t'
+}
+
+func isIgnored(finding Finding, rules []string) bool {
+	for _, rule := range rules {
+		if rule == "" || rule == finding.Rule {
+			return true
+		}
+	}
+	return false
+}
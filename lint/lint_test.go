@@ -0,0 +1,160 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/ast"
+	"github.com/waridh/go-monkey-interpreter/evaluator"
+	"github.com/waridh/go-monkey-interpreter/lexer"
+	"github.com/waridh/go-monkey-interpreter/parser"
+)
+
+func testCheck(t *testing.T, input string) []string {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors for %q: %v", input, p.Errors())
+	}
+
+	findings := []string{}
+	for _, finding := range Check(program, evaluator.NewBuiltins()) {
+		findings = append(findings, finding.String())
+	}
+	return findings
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; puts(x);", []string{}},
+		{"puts(y);", []string{"1:6: undefined: y (undefined)"}},
+		{"puts(x); let x = 1; x", []string{"1:6: undefined: x (undefined)"}},
+		{"let x = 1;", []string{"1:5: variable x is never used (unused)"}},
+		{"export let x = 1; let _y = 2;", []string{}},
+		{
+			"let f = fn(a, b) { a }; f(1, 2);",
+			[]string{"1:15: parameter b is never used (unused)"},
+		},
+		// Functions may use bindings made after them, and themselves
+		{"let f = fn(x) { g(x) + f(x) }; let g = fn(x) { x }; f(1)", []string{}},
+		{"let f = fn() { let y = 1; y }; f(); y", []string{"1:37: undefined: y (undefined)"}},
+		// Blocks bind in the enclosing function
+		{"let f = fn(c) { if (c) { let y = 1; } y }; f(true)", []string{}},
+		{"let x = 1; let x = x + 1; x", []string{}},
+		{
+			"let len = fn(puts) { puts }; len(1)",
+			[]string{
+				"1:5: variable len shadows the builtin len (shadowed-builtin)",
+				"1:14: parameter puts shadows the builtin puts (shadowed-builtin)",
+			},
+		},
+		{"import \"m\" as math; math.abs(1)", []string{"1:15: import math shadows the builtin math (shadowed-builtin)"}},
+		{"import { a, b } from \"m\"; a", []string{"1:13: import b is never used (unused)"}},
+		{"math.abs(-1); read_file(\"x\")", []string{}},
+		{"{\"a\": 1}.a", []string{}},
+		{
+			"let f = fn(x) {\n  return x;\n  puts(x);\n  x\n}; f(1)",
+			[]string{"3:3: unreachable code (unreachable)"},
+		},
+		{
+			"5(1); \"f\"(); [1](); fn(x) { x }(1);",
+			[]string{
+				"1:1: cannot call INTEGER, it is not a function (not-callable)",
+				"1:7: cannot call STRING, it is not a function (not-callable)",
+				"1:14: cannot call ARRAY, it is not a function (not-callable)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		findings := testCheck(t, tt.input)
+		if len(findings) != len(tt.expected) {
+			t.Errorf("Check(%q) - expected %q, got %q", tt.input, tt.expected, findings)
+			continue
+		}
+		for i, finding := range findings {
+			if finding != tt.expected[i] {
+				t.Errorf("Check(%q)[%d] - expected %q, got %q", tt.input, i, tt.expected[i], finding)
+			}
+		}
+	}
+}
+
+func TestSuppression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"let x = 1; // lint:ignore unused", []string{}},
+		{"// lint:ignore unused because it is a demo\nlet x = 1;", []string{}},
+		{"// lint:ignore\nlet len = y;", []string{}},
+		{"let len = y; // lint:ignore unused, shadowed-builtin", []string{"1:11: undefined: y (undefined)"}},
+		{
+			"let x = 1; // lint:ignore undefined",
+			[]string{"1:5: variable x is never used (unused)"},
+		},
+		{
+			"// lint:ignore unused\n\nlet x = 1;",
+			[]string{"3:5: variable x is never used (unused)"},
+		},
+	}
+
+	for _, tt := range tests {
+		findings := testCheck(t, tt.input)
+		if len(findings) != len(tt.expected) {
+			t.Errorf("Check(%q) - expected %q, got %q", tt.input, tt.expected, findings)
+			continue
+		}
+		for i, finding := range findings {
+			if finding != tt.expected[i] {
+				t.Errorf("Check(%q)[%d] - expected %q, got %q", tt.input, i, tt.expected[i], finding)
+			}
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	input := "let x = 1;\nlet f = fn(y) { x + y + g() };\nlet g = fn() { 2 };\nf(x) + len(z)"
+	program := parser.New(lexer.New(input)).ParseProgram()
+	refs := Resolve(program)
+
+	// Every identifier, by position, and where the binding it refers to is
+	expected := map[[2]int][2]int{
+		{1, 5}:  {1, 5},
+		{2, 5}:  {2, 5},
+		{2, 12}: {2, 12},
+		{2, 17}: {1, 5},
+		{2, 21}: {2, 12},
+		{2, 25}: {3, 5},
+		{3, 5}:  {3, 5},
+		{4, 1}:  {2, 5},
+		{4, 3}:  {1, 5},
+		{4, 8}:  {0, 0},
+		{4, 12}: {0, 0},
+	}
+	if len(refs) != len(expected) {
+		t.Fatalf("Expected %d identifiers, got %d", len(expected), len(refs))
+	}
+	for ident, b := range refs {
+		at := [2]int{ident.Token.Line, ident.Token.Column}
+		want, ok := expected[at]
+		if !ok {
+			t.Errorf("Unexpected identifier %s at %v", ident.Value, at)
+			continue
+		}
+		got := [2]int{}
+		if b != nil {
+			got = [2]int{b.Name.Token.Line, b.Name.Token.Column}
+		}
+		if got != want {
+			t.Errorf("Expected %s at %v to refer to %v, got %v", ident.Value, at, want, got)
+		}
+	}
+
+	f := refs[program.Statements[1].(*ast.LetStatement).Name]
+	if f.Kind != "variable" || f.Value == nil || f.Value.String() == "" {
+		t.Errorf("Expected f to be bound to its function, got %+v", f)
+	}
+}
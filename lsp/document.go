@@ -0,0 +1,217 @@
+package lsp
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/waridh/go-monkey-interpreter/ast"
+	"github.com/waridh/go-monkey-interpreter/lexer"
+	"github.com/waridh/go-monkey-interpreter/lint"
+	"github.com/waridh/go-monkey-interpreter/object"
+	"github.com/waridh/go-monkey-interpreter/parser"
+)
+
+// document is an open file, along with what is known about its source
+type document struct {
+	uri   string
+	text  string
+	lines []string
+
+	diagnostics []Diagnostic
+	// The syntax tree and the bindings of its names, which are only there
+	// when the text parses
+	program *ast.Program
+	refs    map[*ast.Identifier]*lint.Binding
+}
+
+func newDocument(uri, text string, builtins *object.Builtins) *document {
+	d := &document{uri: uri, text: text, lines: strings.Split(text, "\n"), diagnostics: []Diagnostic{}}
+
+	p := parser.New(lexer.New(text))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		for i, msg := range p.Errors() {
+			tok := p.ErrorTokens()[i]
+			d.diagnostics = append(d.diagnostics, Diagnostic{
+				Range: Range{
+					Start: d.position(tok.Line, tok.Column),
+					End:   d.position(tok.Line, tok.Column+len(tok.Literal)),
+				},
+				Severity: SeverityError,
+				Source:   "monkey",
+				Message:  msg,
+			})
+		}
+		return d
+	}
+
+	d.program = program
+	d.refs = lint.Resolve(program)
+	for _, finding := range lint.Check(program, builtins) {
+		d.diagnostics = append(d.diagnostics, Diagnostic{
+			Range: Range{
+				Start: d.position(finding.Line, finding.Column),
+				End:   d.position(finding.Line, d.wordEnd(finding.Line, finding.Column)),
+			},
+			Severity: SeverityWarning,
+			Code:     finding.Rule,
+			Source:   "monkey lint",
+			Message:  finding.Message,
+		})
+	}
+	return d
+}
+
+// position converts a line and byte column, both counted from 1, to a
+// position of the protocol
+func (d *document) position(line, column int) Position {
+	if line < 1 {
+		return Position{}
+	}
+	if line > len(d.lines) {
+		last := d.lines[len(d.lines)-1]
+		return Position{Line: len(d.lines) - 1, Character: utf16Len(last)}
+	}
+	text := d.lines[line-1]
+	end := min(max(column-1, 0), len(text))
+	return Position{Line: line - 1, Character: utf16Len(text[:end])}
+}
+
+// location converts a position of the protocol back to a line and byte
+// column
+func (d *document) location(pos Position) (int, int) {
+	if pos.Line < 0 || pos.Line >= len(d.lines) {
+		return pos.Line + 1, 1
+	}
+	units := 0
+	for i, r := range d.lines[pos.Line] {
+		if units >= pos.Character {
+			return pos.Line + 1, i + 1
+		}
+		units += runeLen16(r)
+	}
+	return pos.Line + 1, len(d.lines[pos.Line]) + 1
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeLen16(r)
+	}
+	return n
+}
+
+// runeLen16 returns the number of UTF-16 code units that encode r
+func runeLen16(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// wordEnd returns the column just after the name that starts at line and
+// column
+func (d *document) wordEnd(line, column int) int {
+	if line < 1 || line > len(d.lines) {
+		return column
+	}
+	text := d.lines[line-1]
+	end := column - 1
+	for end < len(text) && isNameByte(text[end]) {
+		end++
+	}
+	return end + 1
+}
+
+func isNameByte(ch byte) bool {
+	return ch == '_' || ch == '.' || ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') || ('0' <= ch && ch <= '9')
+}
+
+func (d *document) identRange(ident *ast.Identifier) Range {
+	return Range{
+		Start: d.position(ident.Token.Line, ident.Token.Column),
+		End:   d.position(ident.Token.Line, ident.Token.Column+len(ident.Value)),
+	}
+}
+
+// identAt finds the identifier at pos. A position just after an identifier
+// counts as on it, unless another identifier starts there.
+func (d *document) identAt(pos Position) *ast.Identifier {
+	line, column := d.location(pos)
+	var found *ast.Identifier
+	for ident := range d.refs {
+		start := ident.Token.Column
+		if ident.Token.Line != line || column < start || column > start+len(ident.Value) {
+			continue
+		}
+		if found == nil || start > found.Token.Column {
+			found = ident
+		}
+	}
+	return found
+}
+
+// references lists the identifiers referring to b, in order of position
+func (d *document) references(b *lint.Binding) []*ast.Identifier {
+	idents := []*ast.Identifier{}
+	for ident, ref := range d.refs {
+		if ref == b {
+			idents = append(idents, ident)
+		}
+	}
+	sort.Slice(idents, func(i, j int) bool {
+		if idents[i].Token.Line != idents[j].Token.Line {
+			return idents[i].Token.Line < idents[j].Token.Line
+		}
+		return idents[i].Token.Column < idents[j].Token.Column
+	})
+	return idents
+}
+
+// symbols lists the let statements of stmts, with the let statements of
+// function bodies as their children
+func (d *document) symbols(stmts []ast.Statement) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, stmt := range stmts {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok {
+			continue
+		}
+		symbol := DocumentSymbol{
+			Name:           let.Name.Value,
+			Kind:           SymbolVariable,
+			SelectionRange: d.identRange(let.Name),
+		}
+		symbol.Range = Range{Start: d.position(let.Token.Line, let.Token.Column), End: symbol.SelectionRange.End}
+		if fn, ok := let.Value.(*ast.FunctionLiteral); ok {
+			symbol.Kind = SymbolFunction
+			symbol.Detail = signature(fn)
+			symbol.Range.End = d.position(fn.Body.End.Line, fn.Body.End.Column+1)
+			symbol.Children = d.symbols(fn.Body.Statements)
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// signature describes how fn is called, such as `fn(a, b)`
+func signature(fn *ast.FunctionLiteral) string {
+	params := make([]string, len(fn.Parameter))
+	for i, param := range fn.Parameter {
+		params[i] = param.Value
+	}
+	return "fn(" + strings.Join(params, ", ") + ")"
+}
+
+// describe gives the source that made b, for hovers
+func describe(b *lint.Binding) string {
+	switch b.Kind {
+	case "variable":
+		if fn, ok := b.Value.(*ast.FunctionLiteral); ok {
+			return "let " + b.Name.Value + " = " + signature(fn)
+		}
+		return "let " + b.Name.Value
+	default:
+		return b.Kind + " " + b.Name.Value
+	}
+}
@@ -0,0 +1,313 @@
+// Package lsp is a Language Server Protocol server for Monkey, giving editors
+// diagnostics, navigation, hovers, completion and formatting.
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/waridh/go-monkey-interpreter/ast"
+	"github.com/waridh/go-monkey-interpreter/evaluator"
+	"github.com/waridh/go-monkey-interpreter/lint"
+	"github.com/waridh/go-monkey-interpreter/message"
+	"github.com/waridh/go-monkey-interpreter/object"
+	"github.com/waridh/go-monkey-interpreter/printer"
+	"github.com/waridh/go-monkey-interpreter/token"
+)
+
+type server struct {
+	out       io.Writer
+	documents map[string]*document
+	builtins  *object.Builtins
+}
+
+// handlers answer the methods of the protocol that the server supports.
+// Notifications are answered with a nil result, which is never sent.
+var handlers = map[string]func(s *server, params json.RawMessage) (any, *responseError){
+	"initialize":                  (*server).initialize,
+	"initialized":                 ignore,
+	"shutdown":                    ignore,
+	"textDocument/didOpen":        (*server).didOpen,
+	"textDocument/didChange":      (*server).didChange,
+	"textDocument/didClose":       (*server).didClose,
+	"textDocument/definition":     (*server).definition,
+	"textDocument/references":     (*server).references,
+	"textDocument/hover":          (*server).hover,
+	"textDocument/documentSymbol": (*server).documentSymbol,
+	"textDocument/completion":     (*server).completion,
+	"textDocument/formatting":     (*server).formatting,
+}
+
+// Serve speaks the protocol over in and out until the client sends exit, or
+// in runs out
+func Serve(in io.Reader, out io.Writer) error {
+	s := &server{out: out, documents: make(map[string]*document), builtins: evaluator.NewBuiltins()}
+	reader := bufio.NewReader(in)
+	for {
+		body, err := message.Read(reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			if err := s.reply(json.RawMessage("null"), nil, &responseError{Code: codeParseError, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+
+		handler, ok := handlers[req.Method]
+		if !ok {
+			// Notifications that are not understood are ignored
+			if req.ID != nil {
+				if err := s.reply(req.ID, nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		result, rpcErr := handler(s, req.Params)
+		if req.ID == nil {
+			continue
+		}
+		if err := s.reply(req.ID, result, rpcErr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) reply(id json.RawMessage, result any, rpcErr *responseError) error {
+	if rpcErr != nil {
+		return message.Write(s.out, errorResponse{JSONRPC: "2.0", ID: id, Error: rpcErr})
+	}
+	return message.Write(s.out, response{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (s *server) notify(method string, params any) {
+	message.Write(s.out, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+// decode reads the parameters of a method
+func decode[T any](params json.RawMessage) (T, *responseError) {
+	var value T
+	if err := json.Unmarshal(params, &value); err != nil {
+		return value, &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return value, nil
+}
+
+func ignore(s *server, params json.RawMessage) (any, *responseError) {
+	return nil, nil
+}
+
+func (s *server) initialize(params json.RawMessage) (any, *responseError) {
+	return map[string]any{
+		"capabilities": map[string]any{
+			// Documents are sent whole on every change
+			"textDocumentSync":           1,
+			"definitionProvider":         true,
+			"referencesProvider":         true,
+			"hoverProvider":              true,
+			"documentSymbolProvider":     true,
+			"completionProvider":         map[string]any{"triggerCharacters": []string{"."}},
+			"documentFormattingProvider": true,
+		},
+		"serverInfo": map[string]any{"name": "monkey"},
+	}, nil
+}
+
+// open analyses the text of a document, and publishes its diagnostics
+func (s *server) open(uri, text string) {
+	doc := newDocument(uri, text, s.builtins)
+	s.documents[uri] = doc
+	s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: uri, Diagnostics: doc.diagnostics})
+}
+
+func (s *server) didOpen(params json.RawMessage) (any, *responseError) {
+	p, err := decode[DidOpenTextDocumentParams](params)
+	if err != nil {
+		return nil, err
+	}
+	s.open(p.TextDocument.URI, p.TextDocument.Text)
+	return nil, nil
+}
+
+func (s *server) didChange(params json.RawMessage) (any, *responseError) {
+	p, err := decode[DidChangeTextDocumentParams](params)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.ContentChanges) != 0 {
+		s.open(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+	}
+	return nil, nil
+}
+
+func (s *server) didClose(params json.RawMessage) (any, *responseError) {
+	p, err := decode[DocumentParams](params)
+	if err != nil {
+		return nil, err
+	}
+	delete(s.documents, p.TextDocument.URI)
+	s.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: p.TextDocument.URI, Diagnostics: []Diagnostic{}})
+	return nil, nil
+}
+
+func (s *server) definition(params json.RawMessage) (any, *responseError) {
+	p, err := decode[TextDocumentPositionParams](params)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := s.documents[p.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+	ident := doc.identAt(p.Position)
+	if ident == nil || doc.refs[ident] == nil {
+		return nil, nil
+	}
+	return Location{URI: doc.uri, Range: doc.identRange(doc.refs[ident].Name)}, nil
+}
+
+func (s *server) references(params json.RawMessage) (any, *responseError) {
+	p, err := decode[ReferenceParams](params)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := s.documents[p.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+	ident := doc.identAt(p.Position)
+	if ident == nil || doc.refs[ident] == nil {
+		return []Location{}, nil
+	}
+
+	b := doc.refs[ident]
+	locations := []Location{}
+	for _, ref := range doc.references(b) {
+		if ref == b.Name && !p.Context.IncludeDeclaration {
+			continue
+		}
+		locations = append(locations, Location{URI: doc.uri, Range: doc.identRange(ref)})
+	}
+	return locations, nil
+}
+
+func (s *server) hover(params json.RawMessage) (any, *responseError) {
+	p, err := decode[TextDocumentPositionParams](params)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := s.documents[p.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+	ident := doc.identAt(p.Position)
+	if ident == nil {
+		return nil, nil
+	}
+
+	var text string
+	if b := doc.refs[ident]; b != nil {
+		text = describe(b)
+	} else if _, ok := s.builtins.Lookup(ident.Value); ok {
+		text = "builtin " + ident.Value
+	} else if _, ok := s.builtins.Disabled(ident.Value); ok {
+		text = "builtin " + ident.Value
+	} else {
+		return nil, nil
+	}
+	identRange := doc.identRange(ident)
+	return Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: "```monkey\n" + text + "\n```"},
+		Range:    &identRange,
+	}, nil
+}
+
+func (s *server) documentSymbol(params json.RawMessage) (any, *responseError) {
+	p, err := decode[DocumentParams](params)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := s.documents[p.TextDocument.URI]
+	if !ok || doc.program == nil {
+		return []DocumentSymbol{}, nil
+	}
+	return doc.symbols(doc.program.Statements), nil
+}
+
+// completion offers every name bound in the document, the builtins and the
+// keywords, leaving it to the editor to filter them by what was typed
+func (s *server) completion(params json.RawMessage) (any, *responseError) {
+	p, err := decode[TextDocumentPositionParams](params)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []CompletionItem{}
+	seen := map[string]bool{}
+	add := func(item CompletionItem) {
+		if !seen[item.Label] {
+			seen[item.Label] = true
+			items = append(items, item)
+		}
+	}
+
+	if doc, ok := s.documents[p.TextDocument.URI]; ok {
+		bindings := []*lint.Binding{}
+		for ident, b := range doc.refs {
+			if b != nil && b.Name == ident {
+				bindings = append(bindings, b)
+			}
+		}
+		sort.Slice(bindings, func(i, j int) bool {
+			return bindings[i].Name.Value < bindings[j].Name.Value
+		})
+		for _, b := range bindings {
+			item := CompletionItem{Label: b.Name.Value, Kind: CompletionVariable, Detail: describe(b)}
+			if _, ok := b.Value.(*ast.FunctionLiteral); ok {
+				item.Kind = CompletionFunction
+			}
+			add(item)
+		}
+	}
+	for _, name := range s.builtins.Names() {
+		add(CompletionItem{Label: name, Kind: CompletionFunction, Detail: "builtin"})
+	}
+	for _, keyword := range token.Keywords() {
+		add(CompletionItem{Label: keyword, Kind: CompletionKeyword})
+	}
+	return items, nil
+}
+
+func (s *server) formatting(params json.RawMessage) (any, *responseError) {
+	p, err := decode[DocumentParams](params)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := s.documents[p.TextDocument.URI]
+	if !ok {
+		return nil, nil
+	}
+	formatted, formatErr := printer.Format([]byte(doc.text))
+	if formatErr != nil {
+		// Text that does not parse is left as it is
+		return nil, nil
+	}
+	if bytes.Equal(formatted, []byte(doc.text)) {
+		return []TextEdit{}, nil
+	}
+	whole := Range{End: doc.position(len(doc.lines)+1, 1)}
+	return []TextEdit{{Range: whole, NewText: string(formatted)}}, nil
+}
@@ -0,0 +1,306 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/message"
+)
+
+const uri = "file:///main.mk"
+
+// session sends requests to a server, numbering those that are not
+// notifications, and returns what the server sent back by request number.
+// Notifications from the server are returned under their method.
+func session(t *testing.T, msgs ...map[string]any) map[string]json.RawMessage {
+	var in bytes.Buffer
+	for i, msg := range msgs {
+		msg["jsonrpc"] = "2.0"
+		if _, ok := msg["notify"]; ok {
+			delete(msg, "notify")
+		} else {
+			msg["id"] = i
+		}
+		if err := message.Write(&in, msg); err != nil {
+			t.Fatalf("could not write %v: %s", msg, err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := Serve(&in, &out); err != nil {
+		t.Fatalf("Serve failed: %s", err)
+	}
+
+	replies := map[string]json.RawMessage{}
+	reader := bufio.NewReader(&out)
+	for {
+		body, err := message.Read(reader)
+		if err != nil {
+			break
+		}
+		var reply struct {
+			ID     *int            `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+			Result json.RawMessage `json:"result"`
+			Error  json.RawMessage `json:"error"`
+		}
+		if err := json.Unmarshal(body, &reply); err != nil {
+			t.Fatalf("invalid message %s: %s", body, err)
+		}
+		switch {
+		case reply.Method != "":
+			replies[reply.Method] = reply.Params
+		case reply.Error != nil:
+			replies["error"] = reply.Error
+		default:
+			replies[string(rune('0'+*reply.ID))] = reply.Result
+		}
+	}
+	return replies
+}
+
+func open(text string) map[string]any {
+	return map[string]any{
+		"notify": true,
+		"method": "textDocument/didOpen",
+		"params": map[string]any{"textDocument": map[string]any{"uri": uri, "text": text}},
+	}
+}
+
+func at(method string, line, character int) map[string]any {
+	return map[string]any{
+		"method": method,
+		"params": map[string]any{
+			"textDocument": map[string]any{"uri": uri},
+			"position":     map[string]any{"line": line, "character": character},
+			"context":      map[string]any{"includeDeclaration": true},
+		},
+	}
+}
+
+func decodeAs[T any](t *testing.T, raw json.RawMessage) T {
+	var value T
+	if err := json.Unmarshal(raw, &value); err != nil {
+		t.Fatalf("could not decode %s: %s", raw, err)
+	}
+	return value
+}
+
+const source = `let add = fn(a, b) {
+  let sum = a + b;
+  sum
+};
+let total = add(1, 2);
+puts(total);
+`
+
+func TestInitialize(t *testing.T) {
+	replies := session(t, map[string]any{"method": "initialize", "params": map[string]any{}})
+	result := decodeAs[map[string]map[string]any](t, replies["0"])
+	for _, capability := range []string{"definitionProvider", "referencesProvider", "hoverProvider",
+		"documentSymbolProvider", "completionProvider", "documentFormattingProvider"} {
+		if _, ok := result["capabilities"][capability]; !ok {
+			t.Errorf("Expected the %s capability", capability)
+		}
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	replies := session(t, open("let x = 1;\nlet = 2;"))
+	params := decodeAs[PublishDiagnosticsParams](t, replies["textDocument/publishDiagnostics"])
+	if len(params.Diagnostics) == 0 {
+		t.Fatalf("Expected diagnostics for the parser errors")
+	}
+	diagnostic := params.Diagnostics[0]
+	expected := Range{Start: Position{1, 4}, End: Position{1, 5}}
+	if diagnostic.Severity != SeverityError || diagnostic.Range != expected {
+		t.Errorf("Expected an error at %+v, got %+v", expected, diagnostic)
+	}
+
+	replies = session(t, open("let x = 1;"))
+	params = decodeAs[PublishDiagnosticsParams](t, replies["textDocument/publishDiagnostics"])
+	if len(params.Diagnostics) != 1 || params.Diagnostics[0].Code != "unused" {
+		t.Fatalf("Expected an unused warning, got %+v", params.Diagnostics)
+	}
+	expected = Range{Start: Position{0, 4}, End: Position{0, 5}}
+	if params.Diagnostics[0].Range != expected {
+		t.Errorf("Expected the warning at %+v, got %+v", expected, params.Diagnostics[0].Range)
+	}
+}
+
+func TestNavigation(t *testing.T) {
+	replies := session(t,
+		open(source),
+		at("textDocument/definition", 4, 13),
+		at("textDocument/references", 1, 12),
+		at("textDocument/definition", 5, 1),
+		at("textDocument/definition", 1, 3),
+	)
+
+	definition := decodeAs[Location](t, replies["1"])
+	expected := Range{Start: Position{0, 4}, End: Position{0, 7}}
+	if definition.URI != uri || definition.Range != expected {
+		t.Errorf("Expected add to be defined at %+v, got %+v", expected, definition)
+	}
+
+	references := decodeAs[[]Location](t, replies["2"])
+	expectedRefs := []Position{{0, 13}, {1, 12}}
+	if len(references) != len(expectedRefs) {
+		t.Fatalf("Expected %d references of a, got %+v", len(expectedRefs), references)
+	}
+	for i, ref := range references {
+		if ref.Range.Start != expectedRefs[i] {
+			t.Errorf("references[%d] - expected %+v, got %+v", i, expectedRefs[i], ref.Range.Start)
+		}
+	}
+
+	if string(replies["3"]) != "null" {
+		t.Errorf("Expected builtins to have no definition, got %s", replies["3"])
+	}
+	if string(replies["4"]) != "null" {
+		t.Errorf("Expected keywords to have no definition, got %s", replies["4"])
+	}
+}
+
+func TestHover(t *testing.T) {
+	replies := session(t,
+		open(source),
+		at("textDocument/hover", 4, 13),
+		at("textDocument/hover", 0, 14),
+		at("textDocument/hover", 5, 2),
+	)
+
+	tests := []struct {
+		reply    string
+		expected string
+	}{
+		{"1", "let add = fn(a, b)"},
+		{"2", "parameter a"},
+		{"3", "builtin puts"},
+	}
+	for _, tt := range tests {
+		hover := decodeAs[Hover](t, replies[tt.reply])
+		if !strings.Contains(hover.Contents.Value, tt.expected) {
+			t.Errorf("Expected the hover to show %q, got %q", tt.expected, hover.Contents.Value)
+		}
+	}
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	replies := session(t,
+		open(source),
+		map[string]any{"method": "textDocument/documentSymbol", "params": map[string]any{"textDocument": map[string]any{"uri": uri}}},
+	)
+
+	symbols := decodeAs[[]DocumentSymbol](t, replies["1"])
+	if len(symbols) != 2 {
+		t.Fatalf("Expected 2 symbols, got %+v", symbols)
+	}
+	add := symbols[0]
+	if add.Name != "add" || add.Kind != SymbolFunction || add.Detail != "fn(a, b)" {
+		t.Errorf("Unexpected symbol for add %+v", add)
+	}
+	if add.Range.End != (Position{3, 1}) {
+		t.Errorf("Expected add to end at 3:1, got %+v", add.Range.End)
+	}
+	if len(add.Children) != 1 || add.Children[0].Name != "sum" {
+		t.Errorf("Expected sum inside add, got %+v", add.Children)
+	}
+	if symbols[1].Name != "total" || symbols[1].Kind != SymbolVariable {
+		t.Errorf("Unexpected symbol for total %+v", symbols[1])
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	replies := session(t, open(source), at("textDocument/completion", 5, 0))
+
+	items := decodeAs[[]CompletionItem](t, replies["1"])
+	labels := map[string]int{}
+	for _, item := range items {
+		labels[item.Label] = item.Kind
+	}
+	expected := map[string]int{
+		"add":      CompletionFunction,
+		"total":    CompletionVariable,
+		"len":      CompletionFunction,
+		"math.abs": CompletionFunction,
+		"let":      CompletionKeyword,
+		"return":   CompletionKeyword,
+	}
+	for label, kind := range expected {
+		if labels[label] != kind {
+			t.Errorf("Expected %s to be completed with kind %d, got %d", label, kind, labels[label])
+		}
+	}
+}
+
+func TestFormatting(t *testing.T) {
+	formatting := map[string]any{
+		"method": "textDocument/formatting",
+		"params": map[string]any{"textDocument": map[string]any{"uri": uri}},
+	}
+	replies := session(t, open("let x=1\nputs(x)"), formatting)
+
+	edits := decodeAs[[]TextEdit](t, replies["1"])
+	if len(edits) != 1 {
+		t.Fatalf("Expected one edit, got %+v", edits)
+	}
+	if edits[0].NewText != "let x = 1;\nputs(x);\n" {
+		t.Errorf("Unexpected formatting %q", edits[0].NewText)
+	}
+	if edits[0].Range.End != (Position{1, 7}) {
+		t.Errorf("Expected the edit to cover the document, got %+v", edits[0].Range)
+	}
+
+	replies = session(t, open("let x ="), formatting)
+	if string(replies["1"]) != "null" {
+		t.Errorf("Expected no edits for source with errors, got %s", replies["1"])
+	}
+}
+
+func TestProtocol(t *testing.T) {
+	replies := session(t,
+		map[string]any{"method": "unknown/method"},
+		map[string]any{"notify": true, "method": "$/cancelRequest"},
+		map[string]any{"method": "shutdown"},
+		map[string]any{"notify": true, "method": "exit"},
+		map[string]any{"method": "initialize"},
+	)
+	if !strings.Contains(string(replies["error"]), "-32601") {
+		t.Errorf("Expected unknown methods to be reported, got %s", replies["error"])
+	}
+	if string(replies["2"]) != "null" {
+		t.Errorf("Expected a null result for shutdown, got %s", replies["2"])
+	}
+	if _, ok := replies["4"]; ok {
+		t.Errorf("Expected the server to stop at exit")
+	}
+}
+
+func TestPositions(t *testing.T) {
+	// é takes two bytes and one code unit, and 𝄞 four bytes and two units
+	d := newDocument(uri, "let é = \"𝄞\"; é", nil)
+	tests := []struct {
+		column   int
+		expected int
+	}{
+		{1, 0},
+		{5, 4},
+		{7, 5},
+		{10, 8},
+		{15, 11},
+	}
+	for _, tt := range tests {
+		pos := d.position(1, tt.column)
+		if pos.Character != tt.expected {
+			t.Errorf("position(1, %d) - expected character %d, got %d", tt.column, tt.expected, pos.Character)
+		}
+		if _, column := d.location(pos); column != tt.column {
+			t.Errorf("location(%+v) - expected column %d, got %d", pos, tt.column, column)
+		}
+	}
+}
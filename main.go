@@ -8,7 +8,29 @@ import (
 	"github.com/waridh/go-monkey-interpreter/repl"
 )
 
+// commands are the subcommands of monkey. Without one, monkey starts the
+// REPL.
+var commands = map[string]func(args []string) int{
+	"run":   runCommand,
+	"fmt":   fmtCommand,
+	"lint":  lintCommand,
+	"lsp":   lspCommand,
+	"debug": debugCommand,
+	"test":  testCommand,
+	"parse": parseCommand,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		command, ok := commands[os.Args[1]]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "monkey: unknown command %q\n", os.Args[1])
+			fmt.Fprintln(os.Stderr, "usage: monkey [run|fmt|lint|lsp|debug|test|parse] [arguments]")
+			os.Exit(2)
+		}
+		os.Exit(command(os.Args[2:]))
+	}
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
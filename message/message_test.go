@@ -0,0 +1,54 @@
+package message
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadWrite(t *testing.T) {
+	var buf bytes.Buffer
+	for _, msg := range []any{map[string]int{"id": 1}, "é"} {
+		if err := Write(&buf, msg); err != nil {
+			t.Fatalf("Write(%v) failed: %s", msg, err)
+		}
+	}
+	if !strings.HasPrefix(buf.String(), "Content-Length: 8\r\n\r\n{\"id\":1}") {
+		t.Fatalf("Unexpected encoding %q", buf.String())
+	}
+
+	r := bufio.NewReader(&buf)
+	for _, expected := range []string{`{"id":1}`, `"é"`} {
+		body, err := Read(r)
+		if err != nil {
+			t.Fatalf("Read failed: %s", err)
+		}
+		if string(body) != expected {
+			t.Errorf("Expected %q, got %q", expected, body)
+		}
+	}
+	if _, err := Read(r); err != io.EOF {
+		t.Errorf("Expected io.EOF at the end, got %v", err)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Content-Type: json\r\n\r\n{}", "message without a Content-Length header"},
+		{"Content-Length: x\r\n\r\n{}", `invalid Content-Length "x"`},
+		{"Content-Length: 10\r\n\r\n{}", "unexpected EOF"},
+		{"Content-Length: 2\r\n", "unexpected EOF"},
+	}
+
+	for _, tt := range tests {
+		_, err := Read(bufio.NewReader(strings.NewReader(tt.input)))
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("Read(%q) - expected error %q, got %v", tt.input, tt.expected, err)
+		}
+	}
+}
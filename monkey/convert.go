@@ -0,0 +1,477 @@
+package monkey
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/waridh/go-monkey-interpreter/evaluator"
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+var (
+	objectType = reflect.TypeOf((*object.Object)(nil)).Elem()
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// ToObject converts a Go value into the equivalent Monkey object. Maps are
+// converted into hashes with their keys in sorted order. Structs are
+// converted into hashes keyed by field name, which can be overridden with a
+// `monkey:"name"` field tag. A tag of "-" skips the field.
+func ToObject(v any) (object.Object, error) {
+	if obj, ok := v.(object.Object); ok {
+		return obj, nil
+	}
+	return toObject(reflect.ValueOf(v), nil)
+}
+
+// toObject converts v, charging the strings, arrays and hashes it builds
+// against mem unless it is nil
+func toObject(v reflect.Value, mem *object.Memory) (object.Object, error) {
+	if !v.IsValid() {
+		return evaluator.NULL, nil
+	}
+	if v.Type().Implements(objectType) && !(v.Kind() == reflect.Pointer && v.IsNil()) {
+		return v.Interface().(object.Object), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return evaluator.TRUE, nil
+		}
+		return evaluator.FALSE, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: v.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := v.Uint()
+		if u > 1<<63-1 {
+			return nil, fmt.Errorf("cannot convert %d to %s: out of range", u, object.INTEGER_OBJ)
+		}
+		return &object.Integer{Value: int64(u)}, nil
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: v.Float()}, nil
+	case reflect.String:
+		return charge(mem, &object.String{Value: v.String()})
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return evaluator.NULL, nil
+		}
+		return toObject(v.Elem(), mem)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return evaluator.NULL, nil
+		}
+		elements := make([]object.Object, v.Len())
+		for i := range elements {
+			ele, err := toObject(v.Index(i), mem)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = ele
+		}
+		return charge(mem, &object.Array{Elements: elements})
+	case reflect.Map:
+		if v.IsNil() {
+			return evaluator.NULL, nil
+		}
+		hash := object.NewHash(v.Len())
+		for _, key := range sortedKeys(v) {
+			if err := setPair(hash, key, v.MapIndex(key), mem); err != nil {
+				return nil, err
+			}
+		}
+		return charge(mem, hash)
+	case reflect.Struct:
+		hash := &object.Hash{}
+		for _, field := range structFields(v.Type()) {
+			value, err := v.FieldByIndexErr(field.index)
+			if err != nil {
+				// Promoted through a nil embedded pointer, so there is no value
+				continue
+			}
+			if err := setPair(hash, reflect.ValueOf(field.name), value, mem); err != nil {
+				return nil, err
+			}
+		}
+		return charge(mem, hash)
+	default:
+		return nil, fmt.Errorf("cannot convert Go value of type %s to a Monkey object", v.Type())
+	}
+}
+
+// sortedKeys lists the keys of the map v in order, so that the hash built
+// from it has the same order every time
+func sortedKeys(v reflect.Value) []reflect.Value {
+	keys := v.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keyLess(keys[i], keys[j]) })
+	return keys
+}
+
+// keyLess orders map keys by kind, and then by value
+func keyLess(a, b reflect.Value) bool {
+	for a.Kind() == reflect.Interface && !a.IsNil() {
+		a = a.Elem()
+	}
+	for b.Kind() == reflect.Interface && !b.IsNil() {
+		b = b.Elem()
+	}
+	if a.Kind() != b.Kind() {
+		return a.Kind() < b.Kind()
+	}
+	switch a.Kind() {
+	case reflect.Bool:
+		return !a.Bool() && b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.String:
+		return a.String() < b.String()
+	default:
+		return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+	}
+}
+
+// charge tracks obj in mem, when there is one
+func charge(mem *object.Memory, obj object.Object) (object.Object, error) {
+	if mem == nil {
+		return obj, nil
+	}
+	if err := mem.Track(obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+func setPair(hash *object.Hash, key, value reflect.Value, mem *object.Memory) error {
+	keyObj, err := toObject(key, mem)
+	if err != nil {
+		return err
+	}
+	hashable, ok := object.AsHashable(keyObj)
+	if !ok {
+		return fmt.Errorf("cannot use %s as a hash key", keyObj.Type())
+	}
+	valueObj, err := toObject(value, mem)
+	if err != nil {
+		return err
+	}
+	hash.Set(hashable, valueObj)
+	return nil
+}
+
+// FromObject stores the Go equivalent of obj in the value pointed to by
+// target. When the target is an interface, integers become int64, floats
+// become float64, arrays become []any, and hashes become map[string]any, or
+// map[any]any if they have keys that are not strings.
+func FromObject(obj object.Object, target any) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return errors.New("FromObject target must be a non-nil pointer")
+	}
+	return fromObject(obj, v.Elem())
+}
+
+func fromObject(obj object.Object, v reflect.Value) error {
+	isAny := v.Kind() == reflect.Interface && v.NumMethod() == 0
+	if !isAny && reflect.TypeOf(obj).AssignableTo(v.Type()) {
+		v.Set(reflect.ValueOf(obj))
+		return nil
+	}
+	if obj.Type() == object.NULL_OBJ {
+		switch v.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			v.SetZero()
+			return nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if !isAny {
+			break
+		}
+		native, err := nativeValue(obj)
+		if err != nil {
+			return err
+		}
+		if native == nil {
+			v.SetZero()
+		} else {
+			v.Set(reflect.ValueOf(native))
+		}
+		return nil
+	case reflect.Bool:
+		if b, ok := obj.(*object.Boolean); ok {
+			v.SetBool(b.Value)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i, ok := obj.(*object.Integer); ok {
+			if v.OverflowInt(i.Value) {
+				return fmt.Errorf("cannot convert %d to %s: out of range", i.Value, v.Type())
+			}
+			v.SetInt(i.Value)
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if i, ok := obj.(*object.Integer); ok {
+			if i.Value < 0 || v.OverflowUint(uint64(i.Value)) {
+				return fmt.Errorf("cannot convert %d to %s: out of range", i.Value, v.Type())
+			}
+			v.SetUint(uint64(i.Value))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch n := obj.(type) {
+		case *object.Float:
+			v.SetFloat(n.Value)
+			return nil
+		case *object.Integer:
+			v.SetFloat(float64(n.Value))
+			return nil
+		}
+	case reflect.String:
+		if s, ok := obj.(*object.String); ok {
+			v.SetString(s.Value)
+			return nil
+		}
+	case reflect.Pointer:
+		ptr := reflect.New(v.Type().Elem())
+		if err := fromObject(obj, ptr.Elem()); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	case reflect.Slice:
+		if arr, ok := obj.(*object.Array); ok {
+			slice := reflect.MakeSlice(v.Type(), len(arr.Elements), len(arr.Elements))
+			for i, ele := range arr.Elements {
+				if err := fromObject(ele, slice.Index(i)); err != nil {
+					return fmt.Errorf("index %d: %w", i, err)
+				}
+			}
+			v.Set(slice)
+			return nil
+		}
+	case reflect.Array:
+		if arr, ok := obj.(*object.Array); ok {
+			if len(arr.Elements) != v.Len() {
+				return fmt.Errorf("cannot convert %s of length %d to %s", object.ARRAY_OBJ, len(arr.Elements), v.Type())
+			}
+			for i, ele := range arr.Elements {
+				if err := fromObject(ele, v.Index(i)); err != nil {
+					return fmt.Errorf("index %d: %w", i, err)
+				}
+			}
+			return nil
+		}
+	case reflect.Map:
+		if hash, ok := obj.(*object.Hash); ok {
+			m := reflect.MakeMapWithSize(v.Type(), hash.Len())
+			for _, pair := range hash.Pairs() {
+				key := reflect.New(v.Type().Key()).Elem()
+				if err := fromObject(pair.Key, key); err != nil {
+					return fmt.Errorf("key %s: %w", pair.Key.Inspect(), err)
+				}
+				if !key.Comparable() {
+					return fmt.Errorf("key %s: cannot use %s as a Go map key", pair.Key.Inspect(), pair.Key.Type())
+				}
+				value := reflect.New(v.Type().Elem()).Elem()
+				if err := fromObject(pair.Value, value); err != nil {
+					return fmt.Errorf("key %s: %w", pair.Key.Inspect(), err)
+				}
+				m.SetMapIndex(key, value)
+			}
+			v.Set(m)
+			return nil
+		}
+	case reflect.Struct:
+		if hash, ok := obj.(*object.Hash); ok {
+			for _, field := range structFields(v.Type()) {
+				pair, ok := hash.Get(&object.String{Value: field.name})
+				if !ok {
+					continue
+				}
+				value, err := fieldForSet(v, field.index)
+				if err != nil {
+					return fmt.Errorf("field %s: %w", field.name, err)
+				}
+				if err := fromObject(pair.Value, value); err != nil {
+					return fmt.Errorf("field %s: %w", field.name, err)
+				}
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("cannot convert %s to Go value of type %s", obj.Type(), v.Type())
+}
+
+// nativeValue converts obj into the most natural Go type for it
+func nativeValue(obj object.Object) (any, error) {
+	switch obj := obj.(type) {
+	case *object.Null:
+		return nil, nil
+	case *object.Boolean:
+		return obj.Value, nil
+	case *object.Integer:
+		return obj.Value, nil
+	case *object.Float:
+		return obj.Value, nil
+	case *object.String:
+		return obj.Value, nil
+	case *object.Array:
+		elements := make([]any, len(obj.Elements))
+		for i, ele := range obj.Elements {
+			native, err := nativeValue(ele)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = native
+		}
+		return elements, nil
+	case *object.Hash:
+		strKeys := map[string]any{}
+		anyKeys := map[any]any{}
+		for _, pair := range obj.Pairs() {
+			key, err := nativeValue(pair.Key)
+			if err != nil {
+				return nil, err
+			}
+			value, err := nativeValue(pair.Value)
+			if err != nil {
+				return nil, err
+			}
+			if str, ok := key.(string); ok {
+				strKeys[str] = value
+			} else if !reflect.ValueOf(key).Comparable() {
+				return nil, fmt.Errorf("key %s: cannot use %s as a Go map key", pair.Key.Inspect(), pair.Key.Type())
+			}
+			anyKeys[key] = value
+		}
+		if len(strKeys) == len(anyKeys) {
+			return strKeys, nil
+		}
+		return anyKeys, nil
+	default:
+		return obj, nil
+	}
+}
+
+type structField struct {
+	name  string
+	index []int
+}
+
+// fieldForSet finds the nested field of v at index, allocating the embedded
+// pointers on the way that are nil
+func fieldForSet(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set nil embedded pointer to unexported %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+// structFields lists the exported fields of t, along with the hash key they
+// are converted to
+func structFields(t reflect.Type) []structField {
+	fields := []structField{}
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("monkey"); ok {
+			tag, _, _ = strings.Cut(tag, ",")
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		fields = append(fields, structField{name: name, index: field.Index})
+	}
+	return fields
+}
+
+// Func wraps the Go function fn into a builtin. Arguments are converted with
+// FromObject and checked against the signature of fn, and results are
+// converted with ToObject and charged against the memory limit of the run.
+// fn may return nothing, a value, an error, or a value and an error. Returned
+// errors become Monkey error objects.
+func Func(name string, fn any) (*object.Builtin, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return nil, fmt.Errorf("%s: expected a function, got %T", name, fn)
+	}
+	t := v.Type()
+	switch {
+	case t.NumOut() > 2,
+		t.NumOut() == 2 && t.Out(1) != errorType:
+		return nil, fmt.Errorf("%s: functions must return at most a value and an error, got %s", name, t)
+	}
+
+	wrapped := func(env *object.Environment, args ...object.Object) object.Object {
+		numIn := t.NumIn()
+		if t.IsVariadic() {
+			if len(args) < numIn-1 {
+				return newError("wrong number of arguments for %s. got=%d, want at least %d", name, len(args), numIn-1)
+			}
+		} else if len(args) != numIn {
+			return newError("wrong number of arguments for %s. got=%d, want=%d", name, len(args), numIn)
+		}
+
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			var argType reflect.Type
+			if t.IsVariadic() && i >= numIn-1 {
+				argType = t.In(numIn - 1).Elem()
+			} else {
+				argType = t.In(i)
+			}
+			in[i] = reflect.New(argType).Elem()
+			if err := fromObject(arg, in[i]); err != nil {
+				return newError("argument %d to `%s`: %s", i+1, name, err)
+			}
+		}
+
+		out := v.Call(in)
+		if len(out) > 0 && out[len(out)-1].Type() == errorType {
+			if err, _ := out[len(out)-1].Interface().(error); err != nil {
+				return newError("%s: %s", name, err)
+			}
+			out = out[:len(out)-1]
+		}
+		if len(out) == 0 {
+			return evaluator.NULL
+		}
+
+		result, err := toObject(out[0], env.Runtime().Memory)
+		if err != nil {
+			return newError("%s: %s", name, err)
+		}
+		return result
+	}
+
+	return &object.Builtin{Fn: wrapped, Name: name}, nil
+}
+
+func newError(format string, a ...any) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
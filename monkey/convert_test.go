@@ -0,0 +1,307 @@
+package monkey
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+type person struct {
+	Name    string `monkey:"name"`
+	Age     int    `monkey:"age"`
+	Email   string
+	Secret  string `monkey:"-"`
+	private int
+}
+
+type Base struct {
+	ID int
+}
+
+type embedded struct {
+	Tag string
+}
+
+type derived struct {
+	*Base
+	*embedded
+	Name string
+}
+
+func TestConvertEmbeddedPointers(t *testing.T) {
+	obj, err := ToObject(derived{Name: "a"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if obj.Inspect() != "{Name: a}" {
+		t.Errorf("Expected fields behind nil pointers to be skipped, got %s", obj.Inspect())
+	}
+	obj, err = ToObject(derived{Base: &Base{ID: 1}, Name: "a"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if obj.Inspect() != "{ID: 1, Name: a}" {
+		t.Errorf("Expected promoted fields, got %s", obj.Inspect())
+	}
+
+	in := New()
+	hash, _ := in.Eval(`{"ID": 2, "Name": "b"}`)
+	var d derived
+	if err := FromObject(hash, &d); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if d.Base == nil || d.ID != 2 || d.Name != "b" || d.embedded != nil {
+		t.Errorf("Expected the embedded pointer to be allocated, got %+v", d)
+	}
+
+	hash, _ = in.Eval(`{"Tag": "x"}`)
+	if err := FromObject(hash, &d); err == nil {
+		t.Errorf("Expected an error setting a field behind an unexported nil pointer")
+	}
+}
+
+func TestToObject(t *testing.T) {
+	tests := []struct {
+		input    any
+		expected string
+	}{
+		{nil, "null"},
+		{5, "5"},
+		{uint8(7), "7"},
+		{2.5, "2.5"},
+		{float32(1), "1.0"},
+		{"hello", "hello"},
+		{true, "true"},
+		{[]int{1, 2, 3}, "[1, 2, 3]"},
+		{[2]string{"a", "b"}, "[a, b]"},
+		{[]any{1, "two", nil, false}, "[1, two, null, false]"},
+		{(*int)(nil), "null"},
+		{&object.Integer{Value: 9}, "9"},
+	}
+
+	for _, tt := range tests {
+		obj, err := ToObject(tt.input)
+		if err != nil {
+			t.Errorf("Unexpected error converting %v: %s", tt.input, err)
+			continue
+		}
+		if obj.Inspect() != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, obj.Inspect())
+		}
+	}
+}
+
+func TestToObjectHashes(t *testing.T) {
+	obj, err := ToObject([]map[string]any{{"id": 1, "tags": []string{"a"}}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	in := New()
+	in.Set("rows", obj)
+	result, err := in.Eval(`rows[0]["tags"][0]`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if result.Inspect() != "a" {
+		t.Errorf("Expected a, got %s", result.Inspect())
+	}
+
+	obj, err = ToObject(person{Name: "Ada", Age: 36, Email: "ada@example.com", Secret: "x"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	hash, ok := obj.(*object.Hash)
+	if !ok {
+		t.Fatalf("Expected a Hash, got %T", obj)
+	}
+	if hash.Inspect() != "{name: Ada, age: 36, Email: ada@example.com}" {
+		t.Errorf("Unexpected hash %s", hash.Inspect())
+	}
+	for key, expected := range map[string]string{"name": "Ada", "age": "36", "Email": "ada@example.com"} {
+		pair, ok := hash.Get(&object.String{Value: key})
+		if !ok {
+			t.Errorf("Missing key %q", key)
+			continue
+		}
+		if pair.Value.Inspect() != expected {
+			t.Errorf("Expected %s to be %q, got %q", key, expected, pair.Value.Inspect())
+		}
+	}
+
+	for range 10 {
+		obj, err = ToObject(map[any]int{"b": 1, "a": 2, 3: 3, 1: 4, true: 5, false: 6})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if obj.Inspect() != "{false: 6, true: 5, 1: 4, 3: 3, a: 2, b: 1}" {
+			t.Fatalf("Expected map keys in sorted order, got %s", obj.Inspect())
+		}
+	}
+
+	if _, err := ToObject(make(chan int)); err == nil {
+		t.Errorf("Expected channels to be unsupported")
+	}
+	if _, err := ToObject(map[[2]int]int{}); err != nil {
+		t.Errorf("Unexpected error for empty map: %s", err)
+	}
+}
+
+func TestFromObject(t *testing.T) {
+	in := New()
+
+	var n int
+	obj, _ := in.Eval("40 + 2")
+	if err := FromObject(obj, &n); err != nil || n != 42 {
+		t.Errorf("Expected 42, got %d (%v)", n, err)
+	}
+
+	var f float64
+	if err := FromObject(obj, &f); err != nil || f != 42 {
+		t.Errorf("Expected 42.0, got %f (%v)", f, err)
+	}
+
+	var small int8
+	obj, _ = in.Eval("1000")
+	if err := FromObject(obj, &small); err == nil {
+		t.Errorf("Expected overflow error, got %d", small)
+	}
+
+	var strs []string
+	obj, _ = in.Eval(`["a", "b"]`)
+	if err := FromObject(obj, &strs); err != nil || !reflect.DeepEqual(strs, []string{"a", "b"}) {
+		t.Errorf("Expected [a b], got %v (%v)", strs, err)
+	}
+
+	var p person
+	obj, _ = in.Eval(`{"name": "Grace", "age": 85, "Email": "grace@example.com", "extra": true}`)
+	if err := FromObject(obj, &p); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if p.Name != "Grace" || p.Age != 85 || p.Email != "grace@example.com" {
+		t.Errorf("Unexpected struct %+v", p)
+	}
+
+	var m map[string]int
+	obj, _ = in.Eval(`{"one": 1, "two": 2}`)
+	if err := FromObject(obj, &m); err != nil || !reflect.DeepEqual(m, map[string]int{"one": 1, "two": 2}) {
+		t.Errorf("Unexpected map %v (%v)", m, err)
+	}
+
+	var native any
+	obj, _ = in.Eval(`[1, "two", {"three": [true]}, first([])]`)
+	if err := FromObject(obj, &native); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := []any{int64(1), "two", map[string]any{"three": []any{true}}, nil}
+	if !reflect.DeepEqual(native, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, native)
+	}
+
+	obj, _ = in.Eval(`{[1, 2]: "pair"}`)
+	if err := FromObject(obj, &native); err == nil {
+		t.Errorf("Expected an error converting array keys, got %#v", native)
+	}
+	var keyed map[any]string
+	if err := FromObject(obj, &keyed); err == nil {
+		t.Errorf("Expected an error converting array keys, got %#v", keyed)
+	}
+
+	obj, _ = in.Eval(`[1, "two", {"three": [true]}, first([])]`)
+	var raw object.Object
+	if err := FromObject(obj, &raw); err != nil || raw != obj {
+		t.Errorf("Expected the object itself, got %v (%v)", raw, err)
+	}
+
+	if err := FromObject(obj, &n); err == nil {
+		t.Errorf("Expected an error converting ARRAY to int")
+	}
+	if err := FromObject(obj, n); err == nil {
+		t.Errorf("Expected an error for a non-pointer target")
+	}
+}
+
+func TestRegisterFunc(t *testing.T) {
+	in := New()
+	if err := in.RegisterFunc("add", func(a, b int) int { return a + b }); err != nil {
+		t.Fatal(err)
+	}
+	if err := in.RegisterFunc("join", func(sep string, parts ...string) string {
+		return strings.Join(parts, sep)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := in.RegisterFunc("check", func(n int) (bool, error) {
+		if n < 0 {
+			return false, errors.New("negative input")
+		}
+		return n%2 == 0, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := in.RegisterFunc("bad", func() (int, int) { return 1, 2 }); err == nil {
+		t.Errorf("Expected an error for a function with two results")
+	}
+	if err := in.RegisterFunc("bad", 5); err == nil {
+		t.Errorf("Expected an error registering a non function")
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+		isError  bool
+	}{
+		{"add(1, 2)", "3", false},
+		{`join("-", "a", "b", "c")`, "a-b-c", false},
+		{`join("-")`, "", false},
+		{"check(4)", "true", false},
+		{"if (check(3)) { 1 } else { 2 }", "2", false},
+		{"add(1)", "wrong number of arguments for add. got=1, want=2", true},
+		{`add(1, "2")`, "argument 2 to `add`: cannot convert STRING to Go value of type int", true},
+		{"join()", "wrong number of arguments for join. got=0, want at least 1", true},
+		{"check(-1)", "check: negative input", true},
+	}
+
+	for _, tt := range tests {
+		result, err := in.Eval(tt.input)
+		if tt.isError {
+			if err == nil || err.Error() != tt.expected {
+				t.Errorf("Expected error %q, got %v", tt.expected, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %s", tt.input, err)
+			continue
+		}
+		if result.Inspect() != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, result.Inspect())
+		}
+	}
+}
+
+func TestConvertedValuesTracked(t *testing.T) {
+	in := New(WithMemoryLimit(256))
+	if err := in.RegisterFunc("big", func() []string { return make([]string, 100) }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := in.Eval("big()"); err == nil || !strings.Contains(err.Error(), "memory limit exceeded") {
+		t.Errorf("Expected big() to exceed the memory limit, got %v", err)
+	}
+
+	in = New(WithMemoryLimit(256))
+	if err := in.SetValue("small", []int{1, 2, 3}); err != nil {
+		t.Fatalf("Unexpected error setting small: %s", err)
+	}
+	if in.MemoryStats().Objects != 1 {
+		t.Errorf("Expected 1 object to be tracked, got %d", in.MemoryStats().Objects)
+	}
+	if err := in.SetValue("large", map[string]string{"key": strings.Repeat("x", 300)}); err == nil {
+		t.Errorf("Expected SetValue to exceed the memory limit")
+	}
+	if _, ok := in.Get("large"); ok {
+		t.Errorf("Expected large not to be bound")
+	}
+}
@@ -0,0 +1,40 @@
+package monkey
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError is returned when the source could not be parsed. It holds every
+// error the parser ran into.
+type ParseError struct {
+	File   string // Empty when the source did not come from a file
+	Errors []string
+}
+
+func (e *ParseError) Error() string {
+	var out strings.Builder
+
+	if e.File != "" {
+		out.WriteString(e.File + ": ")
+	}
+	out.WriteString("parser errors:")
+	for _, msg := range e.Errors {
+		out.WriteString("\n\t" + msg)
+	}
+
+	return out.String()
+}
+
+// RuntimeError is returned when evaluation produced an error object
+type RuntimeError struct {
+	File    string // Empty when the source did not come from a file
+	Message string
+}
+
+func (e *RuntimeError) Error() string {
+	if e.File != "" {
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+	return e.Message
+}
@@ -0,0 +1,239 @@
+// Package monkey is the entry point for Go programs embedding the Monkey
+// interpreter. It hides the lexer, parser and evaluator behind a single
+// Interpreter, and reports failures as Go errors.
+package monkey
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"github.com/waridh/go-monkey-interpreter/ast"
+	"github.com/waridh/go-monkey-interpreter/evaluator"
+	"github.com/waridh/go-monkey-interpreter/lexer"
+	"github.com/waridh/go-monkey-interpreter/object"
+	"github.com/waridh/go-monkey-interpreter/parser"
+)
+
+// Interpreter evaluates Monkey source code. Global bindings are kept between
+// calls to Eval, so an Interpreter behaves like a long running REPL session.
+type Interpreter struct {
+	env *object.Environment
+}
+
+// Option configures an Interpreter when it is created
+type Option func(*Interpreter)
+
+// WithStdout sets the writer that puts writes to
+func WithStdout(w io.Writer) Option {
+	return func(in *Interpreter) { in.env.Runtime().Stdout = w }
+}
+
+// WithStderr sets the writer that eputs writes warnings and diagnostics to
+func WithStderr(w io.Writer) Option {
+	return func(in *Interpreter) { in.env.Runtime().Stderr = w }
+}
+
+// WithMemoryLimit caps the number of bytes scripts may allocate over the
+// lifetime of the interpreter
+func WithMemoryLimit(limit int64) Option {
+	return func(in *Interpreter) { in.env.Runtime().Memory.Limit = limit }
+}
+
+// WithSeed seeds the random number generator behind math.rand_int and the
+// other random builtins, so that runs can be reproduced
+func WithSeed(seed int64) Option {
+	return func(in *Interpreter) { in.env.Runtime().Rand = object.NewRand(uint64(seed)) }
+}
+
+// WithModuleLoader sets where imported modules are loaded from
+func WithModuleLoader(loader object.ModuleLoader) Option {
+	return func(in *Interpreter) { in.env.Runtime().Modules.Loader = loader }
+}
+
+// WithFileSystem enables read_file, write_file, read_lines and list_dir,
+// confined to the directory root and everything below it. Symbolic links
+// that lead out of root cannot be followed.
+func WithFileSystem(root string) Option {
+	return func(in *Interpreter) {
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+		in.env.Runtime().FileRoot = root
+		in.Builtins().Enable(object.CapFileSystem)
+	}
+}
+
+// WithStdin enables read_line, which reads lines from r
+func WithStdin(r io.Reader) Option {
+	return func(in *Interpreter) {
+		in.env.Runtime().Stdin = bufio.NewReader(r)
+		in.Builtins().Enable(object.CapStdin)
+	}
+}
+
+// WithoutCapabilities disables the builtins that need any of caps, such as
+// puts for object.CapOutput
+func WithoutCapabilities(caps ...object.Capability) Option {
+	return func(in *Interpreter) {
+		for _, capability := range caps {
+			in.Builtins().Disable(capability)
+		}
+	}
+}
+
+// WithObserver has observer told what evaluation does, such as a
+// trace.Tracer printing every call. It may be given more than once.
+func WithObserver(observer object.Observer) Option {
+	return func(in *Interpreter) {
+		rt := in.env.Runtime()
+		if rt.Observer != nil {
+			observer = observers{rt.Observer, observer}
+		}
+		rt.Observer = observer
+	}
+}
+
+// observers tells each of several observers in turn
+type observers []object.Observer
+
+func (o observers) Enter(node ast.Node, env *object.Environment) {
+	for _, observer := range o {
+		observer.Enter(node, env)
+	}
+}
+
+func (o observers) Exit(node ast.Node, result object.Object) {
+	for _, observer := range o {
+		observer.Exit(node, result)
+	}
+}
+
+func (o observers) Call(fn object.Object, args []object.Object) {
+	for _, observer := range o {
+		observer.Call(fn, args)
+	}
+}
+
+func (o observers) Return(fn object.Object, result object.Object) {
+	for _, observer := range o {
+		observer.Return(fn, result)
+	}
+}
+
+func (o observers) Bind(name string, value object.Object, env *object.Environment) {
+	for _, observer := range o {
+		observer.Bind(name, value, env)
+	}
+}
+
+func (o observers) Error(node ast.Node, err *object.Error) {
+	for _, observer := range o {
+		observer.Error(node, err)
+	}
+}
+
+// New creates an interpreter with its own copy of the standard builtins
+func New(opts ...Option) *Interpreter {
+	in := &Interpreter{env: object.NewEnvironment()}
+	in.env.Runtime().Builtins = evaluator.NewBuiltins()
+	for _, opt := range opts {
+		opt(in)
+	}
+	return in
+}
+
+// Eval parses and evaluates src in the global environment of the
+// interpreter, returning the value of the last statement.
+func (in *Interpreter) Eval(src string) (object.Object, error) {
+	return in.eval("", src)
+}
+
+// EvalFile reads and evaluates the Monkey source file at path. Unless a
+// module loader was configured, imports are loaded relative to the directory
+// of the file.
+func (in *Interpreter) EvalFile(path string) (object.Object, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if modules := in.env.Runtime().Modules; modules.Loader == nil {
+		modules.Loader = object.NewDirLoader(filepath.Dir(path))
+	}
+	return in.eval(path, string(src))
+}
+
+func (in *Interpreter) eval(file, src string) (object.Object, error) {
+	p := parser.New(lexer.New(src))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, &ParseError{File: file, Errors: p.Errors()}
+	}
+
+	result := evaluator.Eval(program, in.env)
+	if result == nil {
+		return evaluator.NULL, nil
+	}
+	if err, ok := result.(*object.Error); ok {
+		return nil, &RuntimeError{File: file, Message: err.Message}
+	}
+	return result, nil
+}
+
+// Set binds name to value in the global environment
+func (in *Interpreter) Set(name string, value object.Object) {
+	in.env.Set(name, value)
+}
+
+// SetValue converts the Go value v with ToObject and binds it to name in the
+// global environment. The objects built are charged against the memory limit,
+// and an error is returned if they would exceed it.
+func (in *Interpreter) SetValue(name string, v any) error {
+	obj, err := toObject(reflect.ValueOf(v), in.env.Runtime().Memory)
+	if err != nil {
+		return err
+	}
+	in.env.Set(name, obj)
+	return nil
+}
+
+// Get looks up a global binding
+func (in *Interpreter) Get(name string) (object.Object, bool) {
+	return in.env.Get(name)
+}
+
+// RegisterBuiltin makes fn callable from scripts under name, replacing any
+// builtin of the same name. Dotted names such as "text.upper" are grouped
+// into namespaces.
+func (in *Interpreter) RegisterBuiltin(name string, fn object.BuiltinFunction) {
+	in.Builtins().Register(name, fn)
+}
+
+// Builtins is the registry of builtins used by this interpreter only
+func (in *Interpreter) Builtins() *object.Builtins {
+	return in.env.Runtime().Builtins
+}
+
+// RegisterFunc makes the Go function fn callable from scripts under name,
+// converting its arguments and results as described by Func
+func (in *Interpreter) RegisterFunc(name string, fn any) error {
+	builtin, err := Func(name, fn)
+	if err != nil {
+		return err
+	}
+	in.Builtins().Define(name, builtin)
+	return nil
+}
+
+// Environment exposes the global environment, for hosts that need to drive
+// the evaluator directly.
+func (in *Interpreter) Environment() *object.Environment {
+	return in.env
+}
+
+// MemoryStats summarises the memory allocated by scripts so far
+func (in *Interpreter) MemoryStats() object.MemoryStats {
+	return in.env.Runtime().Memory.Stats()
+}
@@ -0,0 +1,270 @@
+package monkey
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"5 + 5", "10"},
+		{`"Hello" + " " + "World"`, "Hello World"},
+		{"let a = 5;", "5"},
+		{"", "null"},
+		{"[1, 2, 3][1]", "2"},
+	}
+
+	for _, tt := range tests {
+		in := New()
+		result, err := in.Eval(tt.input)
+		if err != nil {
+			t.Errorf("Unexpected error for %q: %s", tt.input, err)
+			continue
+		}
+		if result.Inspect() != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, result.Inspect())
+		}
+	}
+}
+
+func TestEvalKeepsGlobals(t *testing.T) {
+	in := New()
+	if _, err := in.Eval("let add = fn(x, y) { x + y };"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	result, err := in.Eval("add(2, 3)")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if result.Inspect() != "5" {
+		t.Errorf("Expected 5, got %s", result.Inspect())
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	in := New()
+
+	_, err := in.Eval("let = 5;")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a ParseError, got %T (%v)", err, err)
+	}
+	if len(parseErr.Errors) == 0 {
+		t.Errorf("Expected the ParseError to hold parser messages")
+	}
+
+	_, err = in.Eval("5 + true")
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("Expected a RuntimeError, got %T (%v)", err, err)
+	}
+	if runtimeErr.Message != "type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("Unexpected message %q", runtimeErr.Message)
+	}
+
+	_, err = in.Eval("1 / 0")
+	if !errors.As(err, &runtimeErr) {
+		t.Fatalf("Expected a RuntimeError, got %T (%v)", err, err)
+	}
+	if runtimeErr.Message != "division by zero" {
+		t.Errorf("Unexpected message %q", runtimeErr.Message)
+	}
+}
+
+func TestEvalFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.mk")
+	if err := os.WriteFile(path, []byte("let x = 2; x * 21"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := New().EvalFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if result.Inspect() != "42" {
+		t.Errorf("Expected 42, got %s", result.Inspect())
+	}
+
+	_, err = New().EvalFile(filepath.Join(t.TempDir(), "missing.mk"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected a not exist error, got %v", err)
+	}
+}
+
+func TestSetGet(t *testing.T) {
+	in := New()
+	in.Set("answer", &object.Integer{Value: 42})
+
+	result, err := in.Eval("answer + 1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if result.Inspect() != "43" {
+		t.Errorf("Expected 43, got %s", result.Inspect())
+	}
+
+	in.Eval(`let greeting = "hi";`)
+	value, ok := in.Get("greeting")
+	if !ok {
+		t.Fatalf("Expected greeting to be bound")
+	}
+	if value.Inspect() != "hi" {
+		t.Errorf("Expected hi, got %s", value.Inspect())
+	}
+	if _, ok := in.Get("missing"); ok {
+		t.Errorf("Expected missing to be unbound")
+	}
+}
+
+func TestRegisterBuiltin(t *testing.T) {
+	in := New()
+	in.RegisterBuiltin("double", func(env *object.Environment, args ...object.Object) object.Object {
+		return &object.Integer{Value: args[0].(*object.Integer).Value * 2}
+	})
+
+	result, err := in.Eval("double(21)")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if result.Inspect() != "42" {
+		t.Errorf("Expected 42, got %s", result.Inspect())
+	}
+}
+
+func TestStdout(t *testing.T) {
+	var out bytes.Buffer
+	in := New(WithStdout(&out))
+
+	if _, err := in.Eval(`puts("hello", 5)`); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if out.String() != "hello\n5\n" {
+		t.Errorf("Expected output %q, got %q", "hello\n5\n", out.String())
+	}
+}
+
+func TestStderr(t *testing.T) {
+	var out, errs bytes.Buffer
+	in := New(WithStdout(&out), WithStderr(&errs))
+
+	if _, err := in.Eval(`puts("result"); eputs("warning", 1)`); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if out.String() != "result\n" || errs.String() != "warning\n1\n" {
+		t.Errorf("Expected %q and %q, got %q and %q", "result\n", "warning\n1\n", out.String(), errs.String())
+	}
+}
+
+func TestMemoryLimit(t *testing.T) {
+	in := New(WithMemoryLimit(64))
+
+	_, err := in.Eval(`"a string that is far too long to fit in the limit"`)
+	if err == nil {
+		t.Fatalf("Expected the memory limit to be enforced")
+	}
+	if in.MemoryStats().Limit != 64 {
+		t.Errorf("Expected limit of 64, got %d", in.MemoryStats().Limit)
+	}
+}
+
+func TestIOCapabilities(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := New().Eval(`write_file("a.txt", "x")`); err == nil {
+		t.Errorf("Expected the file system to be disabled by default")
+	}
+
+	in := New(WithFileSystem(dir), WithStdin(strings.NewReader("typed\n")))
+	result, err := in.Eval(`write_file("a.txt", read_line()); read_file("a.txt")`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if result.Inspect() != "typed" {
+		t.Errorf("Expected typed, got %s", result.Inspect())
+	}
+	if _, err := in.Eval(`read_file("../a.txt")`); err == nil {
+		t.Errorf("Expected paths outside of the root to be refused")
+	}
+}
+
+func TestSeed(t *testing.T) {
+	const src = `math.shuffle(range(20))`
+	first, err := New(WithSeed(7)).Eval(src)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	second, err := New(WithSeed(7)).Eval(src)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if first.Inspect() != second.Inspect() {
+		t.Errorf("Expected the same shuffle for the same seed, got %s and %s", first.Inspect(), second.Inspect())
+	}
+}
+
+func TestBuiltinsPerInstance(t *testing.T) {
+	var out bytes.Buffer
+	quiet := New(WithoutCapabilities(object.CapOutput))
+	loud := New(WithStdout(&out))
+	quiet.RegisterBuiltin("len", func(env *object.Environment, args ...object.Object) object.Object {
+		return &object.Integer{Value: -1}
+	})
+
+	if _, err := quiet.Eval(`puts("hi")`); err == nil {
+		t.Errorf("Expected puts to be disabled")
+	}
+	if _, err := loud.Eval(`puts("hi")`); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if out.String() != "hi\n" {
+		t.Errorf("Expected output %q, got %q", "hi\n", out.String())
+	}
+
+	overridden, _ := quiet.Eval(`len("abc")`)
+	standard, _ := loud.Eval(`len("abc")`)
+	if overridden.Inspect() != "-1" || standard.Inspect() != "3" {
+		t.Errorf("Expected the override to only apply to its interpreter, got %s and %s",
+			overridden.Inspect(), standard.Inspect())
+	}
+}
+
+func TestModules(t *testing.T) {
+	lib := fstest.MapFS{
+		"lib/math.mk": {Data: []byte(`
+      export let square = fn(x) { x * x };
+      let secret = 42;
+      `)},
+	}
+	in := New(WithModuleLoader(&object.FSLoader{FS: lib}))
+
+	result, err := in.Eval(`import "lib/math.mk" as m; m.square(7)`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if result.Inspect() != "49" {
+		t.Errorf("Expected 49, got %s", result.Inspect())
+	}
+	if _, err := in.Eval(`m.secret`); err == nil {
+		t.Errorf("Expected unexported names to be hidden")
+	}
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "util.mk"), []byte(`export let twice = fn(x) { x + x };`), 0o644)
+	os.WriteFile(filepath.Join(dir, "main.mk"), []byte(`import { twice } from "util.mk"; twice(21)`), 0o644)
+	result, err = New().EvalFile(filepath.Join(dir, "main.mk"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if result.Inspect() != "42" {
+		t.Errorf("Expected 42, got %s", result.Inspect())
+	}
+}
@@ -0,0 +1,165 @@
+package object
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Capability names a group of builtins that reach outside of the
+// interpreter, so that hosts can switch them off as a whole.
+type Capability string
+
+const (
+	CapOutput     Capability = "output"     // Writing to the standard output
+	CapFileSystem Capability = "filesystem" // Reading and writing files under Runtime.FileRoot
+	CapStdin      Capability = "stdin"      // Reading from the standard input
+)
+
+type builtinEntry struct {
+	value        Object
+	capabilities []Capability
+}
+
+// Builtins is a registry of the values that every script can use without
+// binding them, such as `len` and `puts`. Names containing a dot are grouped
+// into namespaces, so "math.abs" is reached from scripts as `math.abs`.
+type Builtins struct {
+	entries  map[string]builtinEntry
+	disabled map[Capability]bool
+
+	// Namespaces built by Lookup, until the next change. Lookup runs while
+	// the registry is shared between runs, so the cache has a lock of its own.
+	mu      sync.Mutex
+	modules map[string]*Module
+}
+
+func NewBuiltins() *Builtins {
+	return &Builtins{
+		entries:  make(map[string]builtinEntry),
+		disabled: make(map[Capability]bool),
+		modules:  make(map[string]*Module),
+	}
+}
+
+// Register makes fn available under name, provided that every one of caps
+// is enabled. Registering an existing name replaces it.
+func (b *Builtins) Register(name string, fn BuiltinFunction, caps ...Capability) {
+	b.Define(name, &Builtin{Name: name, Fn: fn}, caps...)
+}
+
+// Define is like Register, for values that are not functions. Builtins
+// without a name are known by name.
+func (b *Builtins) Define(name string, value Object, caps ...Capability) {
+	if builtin, ok := value.(*Builtin); ok && builtin.Name == "" {
+		named := *builtin
+		named.Name = name
+		value = &named
+	}
+	b.entries[name] = builtinEntry{value: value, capabilities: caps}
+	b.clearModules()
+}
+
+func (b *Builtins) Remove(name string) {
+	delete(b.entries, name)
+	b.clearModules()
+}
+
+// Disable hides every builtin that requires capability
+func (b *Builtins) Disable(capability Capability) {
+	b.disabled[capability] = true
+	b.clearModules()
+}
+
+func (b *Builtins) Enable(capability Capability) {
+	delete(b.disabled, capability)
+	b.clearModules()
+}
+
+func (b *Builtins) clearModules() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	clear(b.modules)
+}
+
+func (b *Builtins) Enabled(capability Capability) bool {
+	return !b.disabled[capability]
+}
+
+// Lookup finds the builtin or namespace called name. Builtins that require a
+// disabled capability are not found. Lookup is safe to call from several
+// goroutines at once, as long as none of them changes the registry.
+func (b *Builtins) Lookup(name string) (Object, bool) {
+	if entry, ok := b.entries[name]; ok {
+		if _, disabled := b.missingCapability(entry); disabled {
+			return nil, false
+		}
+		return entry.value, true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if module, ok := b.modules[name]; ok {
+		return module, true
+	}
+	prefix := name + "."
+	members := map[string]Object{}
+	for key, entry := range b.entries {
+		member, ok := strings.CutPrefix(key, prefix)
+		if !ok || strings.Contains(member, ".") {
+			continue
+		}
+		if _, disabled := b.missingCapability(entry); !disabled {
+			members[member] = entry.value
+		}
+	}
+	if len(members) == 0 {
+		return nil, false
+	}
+	module := &Module{Name: name, Members: members}
+	b.modules[name] = module
+	return module, true
+}
+
+// Disabled reports whether name is registered but hidden, along with the
+// capability it is missing
+func (b *Builtins) Disabled(name string) (Capability, bool) {
+	entry, ok := b.entries[name]
+	if !ok {
+		return "", false
+	}
+	return b.missingCapability(entry)
+}
+
+func (b *Builtins) missingCapability(entry builtinEntry) (Capability, bool) {
+	for _, capability := range entry.capabilities {
+		if b.disabled[capability] {
+			return capability, true
+		}
+	}
+	return "", false
+}
+
+// Names lists the names of the enabled builtins, in sorted order
+func (b *Builtins) Names() []string {
+	names := []string{}
+	for name, entry := range b.entries {
+		if _, disabled := b.missingCapability(entry); !disabled {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Clone returns a copy of the registry that can be changed independently
+func (b *Builtins) Clone() *Builtins {
+	clone := NewBuiltins()
+	for name, entry := range b.entries {
+		clone.entries[name] = entry
+	}
+	for capability := range b.disabled {
+		clone.disabled[capability] = true
+	}
+	return clone
+}
@@ -0,0 +1,70 @@
+package object
+
+import (
+	"reflect"
+	"testing"
+)
+
+func noop(env *Environment, args ...Object) Object { return nil }
+
+func TestBuiltinsLookup(t *testing.T) {
+	b := NewBuiltins()
+	b.Register("len", noop)
+	b.Register("math.abs", noop)
+	b.Define("math.PI", &Integer{Value: 3})
+
+	if _, ok := b.Lookup("len"); !ok {
+		t.Errorf("Expected len to be registered")
+	}
+	if _, ok := b.Lookup("abs"); ok {
+		t.Errorf("Expected abs to only be reachable through its namespace")
+	}
+
+	obj, ok := b.Lookup("math")
+	if !ok {
+		t.Fatalf("Expected the math namespace to exist")
+	}
+	module, ok := obj.(*Module)
+	if !ok {
+		t.Fatalf("Expected a Module, got %T", obj)
+	}
+	if module.Inspect() != "module math {PI, abs}" {
+		t.Errorf("Unexpected module %q", module.Inspect())
+	}
+
+	b.Remove("math.PI")
+	obj, _ = b.Lookup("math")
+	if _, ok := obj.(*Module).Members["PI"]; ok {
+		t.Errorf("Expected PI to be removed from the namespace")
+	}
+}
+
+func TestBuiltinsCapabilities(t *testing.T) {
+	b := NewBuiltins()
+	b.Register("puts", noop, CapOutput)
+	b.Register("io.write", noop, CapOutput)
+	b.Register("len", noop)
+
+	b.Disable(CapOutput)
+	if _, ok := b.Lookup("puts"); ok {
+		t.Errorf("Expected puts to be hidden")
+	}
+	if _, ok := b.Lookup("io"); ok {
+		t.Errorf("Expected the io namespace to be hidden")
+	}
+	if capability, ok := b.Disabled("puts"); !ok || capability != CapOutput {
+		t.Errorf("Expected puts to be disabled by %q, got %q", CapOutput, capability)
+	}
+	if !reflect.DeepEqual(b.Names(), []string{"len"}) {
+		t.Errorf("Unexpected names %v", b.Names())
+	}
+
+	clone := b.Clone()
+	clone.Enable(CapOutput)
+	if _, ok := clone.Lookup("puts"); !ok {
+		t.Errorf("Expected puts to be enabled in the clone")
+	}
+	if _, ok := b.Lookup("puts"); ok {
+		t.Errorf("Expected enabling the clone to leave the original alone")
+	}
+}
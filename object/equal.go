@@ -0,0 +1,92 @@
+package object
+
+import "math"
+
+// Equal reports whether a and b hold the same value. Integers and floats are
+// equal when they hold the same number, and objects of other different types
+// are never equal. Arrays are compared element by element, hashes are
+// equal when they have equal values for the same keys, whatever their order,
+// and objects without a value of their own, such as functions, are equal only
+// to themselves.
+func Equal(a, b Object) bool {
+	if a == b {
+		return true
+	}
+	if equal, ok := NumbersEqual(a, b); ok {
+		return equal
+	}
+	switch a := a.(type) {
+	case *Boolean:
+		b, ok := b.(*Boolean)
+		return ok && a.Value == b.Value
+	case *String:
+		b, ok := b.(*String)
+		return ok && a.Value == b.Value
+	case *Null:
+		_, ok := b.(*Null)
+		return ok
+	case *Array:
+		b, ok := b.(*Array)
+		if !ok || len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		for i := range a.Elements {
+			if !Equal(a.Elements[i], b.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case *Hash:
+		b, ok := b.(*Hash)
+		if !ok || a.Len() != b.Len() {
+			return false
+		}
+		for _, pair := range a.pairs {
+			other, ok := b.Get(pair.Key.(Hashable))
+			if !ok || !Equal(pair.Value, other.Value) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// NumbersEqual reports whether a and b hold the same number, exactly, when
+// both are integers or floats. ok is false when either is not a number.
+func NumbersEqual(a, b Object) (equal, ok bool) {
+	switch a := a.(type) {
+	case *Integer:
+		switch b := b.(type) {
+		case *Integer:
+			return a.Value == b.Value, true
+		case *Float:
+			return integerEqualsFloat(a.Value, b.Value), true
+		}
+	case *Float:
+		switch b := b.(type) {
+		case *Integer:
+			return integerEqualsFloat(b.Value, a.Value), true
+		case *Float:
+			return a.Value == b.Value, true
+		}
+	}
+	return false, false
+}
+
+// integerEqualsFloat compares without rounding i to a float, which would make
+// large integers equal to their neighbours
+func integerEqualsFloat(i int64, f float64) bool {
+	fi, ok := floatAsInteger(f)
+	return ok && fi == i
+}
+
+// floatAsInteger converts f to the integer holding the same number, if there
+// is one
+func floatAsInteger(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
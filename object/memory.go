@@ -0,0 +1,117 @@
+package object
+
+import (
+	"fmt"
+	"math"
+)
+
+// Rough sizes, in bytes, of the Go values backing our objects on a 64-bit
+// platform. These are only estimates, but they scale with the size of the
+// value, which is what matters when bounding a script.
+const (
+	stringHeaderSize = 16
+	sliceHeaderSize  = 24
+	interfaceSize    = 16
+	mapHeaderSize    = 48
+	hashEntrySize    = 64
+)
+
+// SizeOf estimates the number of bytes held directly by obj. Nested objects
+// are not included, as they are accounted for when they are allocated.
+func SizeOf(obj Object) int64 {
+	switch obj := obj.(type) {
+	case *String:
+		return StringSize(int64(len(obj.Value)))
+	case *Array:
+		return ArraySize(int64(len(obj.Elements)))
+	case *Hash:
+		return mapHeaderSize + hashEntrySize*int64(obj.Len())
+	default:
+		return 0
+	}
+}
+
+// MaxArrayLen is the most elements an array can be sized for, beyond which
+// ArraySize would overflow
+const MaxArrayLen = (math.MaxInt64 - sliceHeaderSize) / interfaceSize
+
+// StringSize estimates the size of a string of n bytes
+func StringSize(n int64) int64 {
+	return stringHeaderSize + n
+}
+
+// ArraySize estimates the size of an array of n elements, so that large
+// arrays can be refused before they are built
+func ArraySize(n int64) int64 {
+	return sliceHeaderSize + interfaceSize*n
+}
+
+// Memory accounts for the objects allocated during a single run, and
+// enforces an optional ceiling on the total allocated. Objects are never
+// released during a run, so the total only grows.
+type Memory struct {
+	Limit int64 // Maximum number of bytes a run may allocate, zero for none
+
+	allocated int64
+	objects   int64
+	largest   int64
+}
+
+func NewMemory(limit int64) *Memory {
+	return &Memory{Limit: limit}
+}
+
+// Track charges obj against the memory of the run, returning an error if
+// doing so would exceed the limit.
+func (m *Memory) Track(obj Object) error {
+	size := SizeOf(obj)
+	if size == 0 {
+		return nil
+	}
+	if err := m.Check(obj.Type(), size); err != nil {
+		return err
+	}
+
+	m.allocated += size
+	m.objects++
+	if size > m.largest {
+		m.largest = size
+	}
+	return nil
+}
+
+// Check returns an error if allocating size more bytes for an object of type
+// typ would exceed the limit, without charging for them
+func (m *Memory) Check(typ ObjectType, size int64) error {
+	if m.Limit > 0 && m.allocated+size > m.Limit {
+		return fmt.Errorf("memory limit exceeded: allocating %d bytes for %s with %d of %d bytes in use",
+			size, typ, m.allocated, m.Limit)
+	}
+	return nil
+}
+
+func (m *Memory) Stats() MemoryStats {
+	return MemoryStats{
+		Limit:     m.Limit,
+		Allocated: m.allocated,
+		Objects:   m.objects,
+		Largest:   m.largest,
+	}
+}
+
+// MemoryStats is the summary of the memory used by a run
+type MemoryStats struct {
+	Limit     int64
+	Allocated int64 // Total bytes allocated by the run
+	Objects   int64
+	Largest   int64 // Size of the largest single object
+}
+
+func (s MemoryStats) String() string {
+	limit := "unlimited"
+	if s.Limit > 0 {
+		limit = fmt.Sprintf("%d bytes", s.Limit)
+	}
+	return fmt.Sprintf("allocated %d bytes in %d objects (largest %d bytes, limit %s)",
+		s.Allocated, s.Objects, s.Largest, limit)
+}
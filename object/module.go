@@ -0,0 +1,98 @@
+package object
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// ModuleLoader finds the source code of the module imported under name
+type ModuleLoader interface {
+	Load(name string) (string, error)
+}
+
+// FSLoader loads modules from a file system, such as an embed.FS. Module
+// names are slash separated paths relative to the root of the file system.
+type FSLoader struct {
+	FS fs.FS
+}
+
+// NewDirLoader loads modules from the directory dir on disk
+func NewDirLoader(dir string) *FSLoader {
+	return &FSLoader{FS: os.DirFS(dir)}
+}
+
+func (l *FSLoader) Load(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", fmt.Errorf("invalid module path %q", name)
+	}
+	src, err := fs.ReadFile(l.FS, name)
+	if err != nil {
+		return "", err
+	}
+	return string(src), nil
+}
+
+// MapLoader serves modules from memory, keyed by module name
+type MapLoader map[string]string
+
+func (l MapLoader) Load(name string) (string, error) {
+	src, ok := l[name]
+	if !ok {
+		return "", fmt.Errorf("module %q not found", name)
+	}
+	return src, nil
+}
+
+// Modules caches the modules imported during a run, so that each module is
+// evaluated at most once, and detects import cycles.
+type Modules struct {
+	Loader ModuleLoader // Imports fail when nil
+
+	loaded  map[string]*Module
+	loading []string // The chain of imports currently being evaluated
+}
+
+func NewModules(loader ModuleLoader) *Modules {
+	return &Modules{Loader: loader, loaded: make(map[string]*Module)}
+}
+
+// ModuleName resolves an import path found in the module called importer,
+// or in the main program when importer is "". Paths are relative to the
+// directory of the importing module, and are cleaned up so that every
+// spelling of a path maps to the same module.
+func ModuleName(importer, importPath string) string {
+	return path.Join(path.Dir(importer), importPath)
+}
+
+func (m *Modules) Get(name string) (*Module, bool) {
+	module, ok := m.loaded[name]
+	return module, ok
+}
+
+// Begin marks name as being loaded, failing if this would be an import cycle
+func (m *Modules) Begin(name string) error {
+	if m.Loader == nil {
+		return errors.New("cannot import " + name + ": no module loader is configured")
+	}
+	for i, loading := range m.loading {
+		if loading == name {
+			cycle := append(append([]string{}, m.loading[i:]...), name)
+			return errors.New("import cycle: " + strings.Join(cycle, " -> "))
+		}
+	}
+	m.loading = append(m.loading, name)
+	return nil
+}
+
+// End marks the module begun last as done, caching it if it loaded
+func (m *Modules) End(module *Module) {
+	name := m.loading[len(m.loading)-1]
+	m.loading = m.loading[:len(m.loading)-1]
+	if module != nil {
+		m.loaded[name] = module
+	}
+}
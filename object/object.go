@@ -1,9 +1,17 @@
 package object
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"hash/fnv"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/waridh/go-monkey-interpreter/ast"
@@ -12,11 +20,12 @@ import (
 
 type (
 	ObjectType      string
-	BuiltinFunction func(args ...Object) Object
+	BuiltinFunction func(env *Environment, args ...Object) Object
 )
 
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
@@ -26,17 +35,36 @@ const (
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	HASH_OBJ         = "HASH"
+	MODULE_OBJ       = "MODULE"
 )
 
 type Hashable interface {
+	Object
 	HashKey() HashKey
 }
 
+// HashKey is a digest of a hashable object. Distinct objects may share a
+// HashKey, so hashes compare the keys themselves when their HashKeys match.
 type HashKey struct {
 	Type  ObjectType
 	Value uint64
 }
 
+// AsHashable returns obj as a hash key. Arrays implement Hashable, but are
+// only usable as keys when all of their elements are.
+func AsHashable(obj Object) (Hashable, bool) {
+	if arr, ok := obj.(*Array); ok {
+		for _, ele := range arr.Elements {
+			if _, ok := AsHashable(ele); !ok {
+				return nil, false
+			}
+		}
+		return arr, true
+	}
+	hashable, ok := obj.(Hashable)
+	return hashable, ok
+}
+
 type Object interface {
 	Type() ObjectType
 	Inspect() string
@@ -52,6 +80,30 @@ func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: INTEGER_OBJ, Value: uint64(i.Value)}
 }
 
+type Float struct {
+	Value float64
+}
+
+// Inspect always includes a decimal point or exponent, so that floats can be
+// told apart from integers.
+func (f *Float) Inspect() string {
+	str := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(str, ".eIN") {
+		str += ".0"
+	}
+	return str
+}
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+
+// HashKey of a float holding a whole number is that of the equal integer,
+// as equal keys must share a HashKey. This covers -0.0 and 0.0 too.
+func (f *Float) HashKey() HashKey {
+	if i, ok := floatAsInteger(f.Value); ok {
+		return HashKey{Type: INTEGER_OBJ, Value: uint64(i)}
+	}
+	return HashKey{Type: FLOAT_OBJ, Value: math.Float64bits(f.Value)}
+}
+
 type Boolean struct {
 	Value bool
 }
@@ -84,6 +136,7 @@ func (rv *ReturnValue) Type() ObjectType { return RETURN_VALUE_OBJ }
 
 type Error struct {
 	Message string
+	Node    ast.Node // The node whose evaluation made the error
 }
 
 func (er *Error) Inspect() string  { return "ERROR: " + er.Message }
@@ -93,6 +146,7 @@ type Function struct {
 	Parameter []*ast.Identifier
 	Body      *ast.BlockStatement
 	Env       *Environment
+	Name      string // The name the function was first bound to by let, if any
 }
 
 func (fn *Function) Inspect() string {
@@ -110,6 +164,16 @@ func (fn *Function) Inspect() string {
 }
 func (fn *Function) Type() ObjectType { return FUNCTION_OBJ }
 
+// Describe shows obj on one line, giving functions by their parameters
+// rather than their whole source
+func Describe(obj Object) string {
+	if fn, ok := obj.(*Function); ok {
+		params := functools.Map(fn.Parameter, func(x *ast.Identifier) string { return x.Value })
+		return "fn(" + strings.Join(params, ", ") + ")"
+	}
+	return strings.ReplaceAll(obj.Inspect(), "\n", " ")
+}
+
 type String struct {
 	Value string
 }
@@ -138,24 +202,85 @@ func (arr *Array) Inspect() string {
 	return out.String()
 }
 
+// HashKey combines the HashKeys of the elements, which must all be hashable
+func (arr *Array) HashKey() HashKey {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, ele := range arr.Elements {
+		key := ele.(Hashable).HashKey()
+		h.Write([]byte(key.Type))
+		binary.LittleEndian.PutUint64(buf[:], key.Value)
+		h.Write(buf[:])
+	}
+	return HashKey{Type: ARRAY_OBJ, Value: h.Sum64()}
+}
+
 type HashPair struct {
 	Key   Object
 	Value Object
 }
 
+// Hash keeps its pairs in insertion order, so that iterating over a hash and
+// inspecting it are deterministic. The zero value is an empty hash.
+//
+// Pairs are bucketed by HashKey, and keys within a bucket are told apart with
+// Equal, so keys whose HashKeys collide do not overwrite each other.
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	pairs []HashPair
+	index map[HashKey][]int // Positions in pairs of the keys with each HashKey
+}
+
+// NewHash creates an empty hash with room for size pairs
+func NewHash(size int) *Hash {
+	return &Hash{pairs: make([]HashPair, 0, size), index: make(map[HashKey][]int, size)}
+}
+
+// Set binds key to value. Keys that are already present keep their
+// position, but take on the new value.
+func (hash *Hash) Set(key Hashable, value Object) {
+	if hash.index == nil {
+		hash.index = make(map[HashKey][]int)
+	}
+	hashKey := key.HashKey()
+	if idx, ok := hash.find(hashKey, key); ok {
+		hash.pairs[idx] = HashPair{Key: key, Value: value}
+		return
+	}
+	hash.index[hashKey] = append(hash.index[hashKey], len(hash.pairs))
+	hash.pairs = append(hash.pairs, HashPair{Key: key, Value: value})
 }
 
+func (hash *Hash) Get(key Hashable) (HashPair, bool) {
+	idx, ok := hash.find(key.HashKey(), key)
+	if !ok {
+		return HashPair{}, false
+	}
+	return hash.pairs[idx], true
+}
+
+// find returns the position of key in pairs
+func (hash *Hash) find(hashKey HashKey, key Hashable) (int, bool) {
+	for _, idx := range hash.index[hashKey] {
+		if Equal(hash.pairs[idx].Key, key) {
+			return idx, true
+		}
+	}
+	return 0, false
+}
+
+func (hash *Hash) Len() int { return len(hash.pairs) }
+
+// Pairs lists the pairs of the hash in insertion order. The returned slice
+// must not be modified.
+func (hash *Hash) Pairs() []HashPair { return hash.pairs }
+
 func (hash *Hash) Type() ObjectType { return HASH_OBJ }
 func (hash *Hash) Inspect() string {
 	var out bytes.Buffer
 
-	pairs := make([]string, len(hash.Pairs))
-
-	for _, value := range hash.Pairs {
-		pairs = append(pairs, value.Key.Inspect()+": "+value.Key.Inspect())
-	}
+	pairs := functools.Map(hash.pairs, func(x HashPair) string {
+		return x.Key.Inspect() + ": " + x.Value.Inspect()
+	})
 
 	out.WriteString("{")
 	out.WriteString(strings.Join(pairs, ", "))
@@ -164,20 +289,139 @@ func (hash *Hash) Inspect() string {
 	return out.String()
 }
 
+// Module is a namespace of named values, whose members are reached with the
+// dot operator
+type Module struct {
+	Name    string
+	Members map[string]Object
+}
+
+func (m *Module) Type() ObjectType { return MODULE_OBJ }
+func (m *Module) Inspect() string {
+	names := make([]string, 0, len(m.Members))
+	for name := range m.Members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return "module " + m.Name + " {" + strings.Join(names, ", ") + "}"
+}
+
 type Builtin struct {
-	Fn BuiltinFunction
+	Fn   BuiltinFunction
+	Name string // The name the builtin was registered under
 }
 
 func (bi *Builtin) Inspect() string  { return "builtin function" }
 func (bi *Builtin) Type() ObjectType { return BUILTIN_OBJ }
 
+// Runtime holds the state shared by all the environments of a single run
+type Runtime struct {
+	Memory   *Memory
+	Builtins *Builtins // The standard builtins are used when nil
+	Modules  *Modules
+	Rand     *rand.Rand    // Random numbers for scripts, reseeded by math.seed
+	FileRoot string        // The directory the file builtins are confined to
+	Stdin    *bufio.Reader // Where read_line reads from
+	Stdout   io.Writer     // Where scripts write their output
+	Stderr   io.Writer     // Where eputs writes warnings and diagnostics
+	Debugger Debugger      // Told where evaluation is when set
+	Observer Observer      // Told what evaluation does when set
+}
+
+// Debugger follows evaluation from statement to statement. Its methods are
+// called on the goroutine doing the evaluation, which a debugger can pause
+// by not returning.
+type Debugger interface {
+	// Statement is called before stmt is evaluated in env
+	Statement(stmt ast.Statement, env *Environment)
+	// Call is called before the body of fn is evaluated in env, and Return
+	// once it has been
+	Call(fn *Function, env *Environment)
+	Return(fn *Function, result Object)
+}
+
+// Observer is told what evaluation does, for tracing and auditing. Its
+// methods are called on the goroutine doing the evaluation.
+type Observer interface {
+	// Enter is called before node is evaluated in env, and Exit with what
+	// it evaluated to
+	Enter(node ast.Node, env *Environment)
+	Exit(node ast.Node, result Object)
+	// Call is called before a *Function or *Builtin is applied to args, and
+	// Return with what it returned
+	Call(fn Object, args []Object)
+	Return(fn Object, result Object)
+	// Bind is called when name is bound to value in env, by let, import or
+	// the parameters of a function
+	Bind(name string, value Object, env *Environment)
+	// Error is called when evaluating node made err, rather than passing on
+	// an error from one of its parts
+	Error(node ast.Node, err *Error)
+}
+
+// NewRand creates a random number generator that always produces the same
+// numbers for the same seed
+func NewRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, 0))
+}
+
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store   map[string]Object
+	outer   *Environment
+	runtime *Runtime
+	module  string // The module being evaluated, empty for the main program
 }
 
 func NewEnvironment() *Environment {
-	return &Environment{store: make(map[string]Object), outer: nil}
+	return NewEnvironmentWithRuntime(&Runtime{
+		Memory:  NewMemory(0),
+		Modules: NewModules(nil),
+		Rand:    NewRand(rand.Uint64()),
+		Stdin:   bufio.NewReader(os.Stdin),
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+	})
+}
+
+// NewEnvironmentWithRuntime creates a global environment taking part in an
+// existing run, such as the environment of an imported module
+func NewEnvironmentWithRuntime(rt *Runtime) *Environment {
+	return &Environment{store: make(map[string]Object), outer: nil, runtime: rt}
+}
+
+// NewModuleEnvironment creates the global environment of the module called
+// name, which imports within it are resolved against
+func NewModuleEnvironment(rt *Runtime, name string) *Environment {
+	env := NewEnvironmentWithRuntime(rt)
+	env.module = name
+	return env
+}
+
+func (e *Environment) Runtime() *Runtime {
+	return e.runtime
+}
+
+// Module is the name of the module that e belongs to, or "" when e belongs
+// to the main program
+func (e *Environment) Module() string {
+	return e.module
+}
+
+// Outer returns the environment that e is enclosed in, or nil when e is a
+// global environment
+func (e *Environment) Outer() *Environment {
+	return e.outer
+}
+
+// Names lists the names bound in e itself, in sorted order
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
@@ -195,7 +439,10 @@ func (e *Environment) Set(name string, obj Object) Object {
 }
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
-	env := NewEnvironment()
-	env.outer = outer
-	return env
+	return &Environment{
+		store:   make(map[string]Object),
+		outer:   outer,
+		runtime: outer.runtime,
+		module:  outer.module,
+	}
 }
@@ -1,6 +1,9 @@
 package object
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func TestStringHashKey(t *testing.T) {
 	hello1 := &String{Value: "Hello World"}
@@ -52,3 +55,201 @@ func TestBooleanHashKey(t *testing.T) {
 		t.Errorf("Expected %t and %t to have different hash key. (%+v), (%+v)", hello1.Value, diff2.Value, hello1, diff2)
 	}
 }
+
+func TestFloatHashKey(t *testing.T) {
+	// Equal numbers must share a HashKey
+	same := [][2]Hashable{
+		{&Float{Value: 1}, &Integer{Value: 1}},
+		{&Float{Value: math.Copysign(0, -1)}, &Integer{Value: 0}},
+		{&Float{Value: math.MinInt64}, &Integer{Value: math.MinInt64}},
+		{&Array{Elements: []Object{&Float{Value: 2}}}, &Array{Elements: []Object{&Integer{Value: 2}}}},
+	}
+	for _, pair := range same {
+		if pair[0].HashKey() != pair[1].HashKey() {
+			t.Errorf("Expected %s and %s to have the same hash key", pair[0].Inspect(), pair[1].Inspect())
+		}
+	}
+	if (&Float{Value: 1.5}).HashKey().Type != FLOAT_OBJ {
+		t.Errorf("Expected 1.5 to have a FLOAT hash key")
+	}
+}
+
+func TestSizeOf(t *testing.T) {
+	tests := []struct {
+		input    Object
+		expected int64
+	}{
+		{&Integer{Value: 5}, 0},
+		{&String{Value: ""}, 16},
+		{&String{Value: "hello"}, 21},
+		{&Array{Elements: []Object{}}, 24},
+		{&Array{Elements: []Object{&Integer{Value: 1}, &Integer{Value: 2}}}, 56},
+		{NewHash(0), 48},
+	}
+
+	for _, tt := range tests {
+		if size := SizeOf(tt.input); size != tt.expected {
+			t.Errorf("Expected size of %s to be %d, got %d", tt.input.Inspect(), tt.expected, size)
+		}
+	}
+}
+
+func TestMemoryTrack(t *testing.T) {
+	mem := NewMemory(40)
+
+	if err := mem.Track(&String{Value: "hello"}); err != nil {
+		t.Fatalf("Expected first allocation to succeed, got %q", err)
+	}
+	if err := mem.Track(&Integer{Value: 1}); err != nil {
+		t.Fatalf("Expected integers to be free, got %q", err)
+	}
+	err := mem.Track(&String{Value: "hello world"})
+	if err == nil {
+		t.Fatalf("Expected allocation over the limit to fail")
+	}
+	expected := "memory limit exceeded: allocating 27 bytes for STRING with 21 of 40 bytes in use"
+	if err.Error() != expected {
+		t.Errorf("Expected %q, got %q", expected, err.Error())
+	}
+
+	stats := mem.Stats()
+	if stats.Allocated != 21 || stats.Objects != 1 || stats.Largest != 21 {
+		t.Errorf("Unexpected stats after failed allocation: %+v", stats)
+	}
+}
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		input    float64
+		expected string
+	}{
+		{1, "1.0"},
+		{2.5, "2.5"},
+		{-0.125, "-0.125"},
+		{1e21, "1e+21"},
+	}
+
+	for _, tt := range tests {
+		f := &Float{Value: tt.input}
+		if f.Inspect() != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, f.Inspect())
+		}
+	}
+}
+
+func TestHashInsertionOrder(t *testing.T) {
+	hash := &Hash{}
+	hash.Set(&String{Value: "b"}, &Integer{Value: 1})
+	hash.Set(&Integer{Value: 10}, &Integer{Value: 2})
+	hash.Set(&String{Value: "a"}, &Integer{Value: 3})
+	hash.Set(&String{Value: "b"}, &Integer{Value: 4})
+
+	if hash.Len() != 3 {
+		t.Errorf("Expected 3 pairs, got %d", hash.Len())
+	}
+	if hash.Inspect() != "{b: 4, 10: 2, a: 3}" {
+		t.Errorf("Unexpected Inspect() %q", hash.Inspect())
+	}
+	pair, ok := hash.Get(&String{Value: "a"})
+	if !ok || pair.Value.Inspect() != "3" {
+		t.Errorf("Expected a to be 3, got %+v", pair)
+	}
+	if _, ok := hash.Get(&String{Value: "c"}); ok {
+		t.Errorf("Expected c to be missing")
+	}
+}
+
+// collidingKey has the same HashKey as every other collidingKey
+type collidingKey struct {
+	String
+}
+
+func (c *collidingKey) HashKey() HashKey { return HashKey{Type: STRING_OBJ, Value: 1} }
+
+func TestHashKeyCollisions(t *testing.T) {
+	a := &collidingKey{String{Value: "a"}}
+	b := &collidingKey{String{Value: "b"}}
+	hash := &Hash{}
+	hash.Set(a, &Integer{Value: 1})
+	hash.Set(b, &Integer{Value: 2})
+	hash.Set(a, &Integer{Value: 3})
+
+	if hash.Len() != 2 {
+		t.Fatalf("Expected 2 pairs, got %d", hash.Len())
+	}
+	for key, expected := range map[Hashable]string{a: "3", b: "2"} {
+		pair, ok := hash.Get(key)
+		if !ok || pair.Value.Inspect() != expected {
+			t.Errorf("Expected %s to be %s, got %+v", key.Inspect(), expected, pair)
+		}
+	}
+	if _, ok := hash.Get(&collidingKey{String{Value: "c"}}); ok {
+		t.Errorf("Expected c to be missing")
+	}
+}
+
+func TestArrayHashKey(t *testing.T) {
+	pair1 := &Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	pair2 := &Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	swapped := &Array{Elements: []Object{&String{Value: "a"}, &Integer{Value: 1}}}
+
+	if pair1.HashKey() != pair2.HashKey() {
+		t.Errorf("Expected %s and %s to have the same hash key", pair1.Inspect(), pair2.Inspect())
+	}
+	if pair1.HashKey() == swapped.HashKey() {
+		t.Errorf("Expected %s and %s to have different hash keys", pair1.Inspect(), swapped.Inspect())
+	}
+
+	hashable := []Object{
+		pair1,
+		&Array{},
+		&Array{Elements: []Object{pair1, &Boolean{Value: true}}},
+	}
+	for _, obj := range hashable {
+		if _, ok := AsHashable(obj); !ok {
+			t.Errorf("Expected %s to be hashable", obj.Inspect())
+		}
+	}
+	unhashable := []Object{
+		&Null{},
+		&Array{Elements: []Object{&Integer{Value: 1}, &Null{}}},
+		&Array{Elements: []Object{&Array{Elements: []Object{&Hash{}}}}},
+	}
+	for _, obj := range unhashable {
+		if _, ok := AsHashable(obj); ok {
+			t.Errorf("Expected %s not to be hashable", obj.Inspect())
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	fn := &Builtin{}
+	tests := []struct {
+		a, b     Object
+		expected bool
+	}{
+		{&Integer{Value: 1}, &Integer{Value: 1}, true},
+		{&Integer{Value: 1}, &Integer{Value: 2}, false},
+		{&Integer{Value: 1}, &Float{Value: 1}, true},
+		{&Float{Value: 1}, &Integer{Value: 1}, true},
+		{&Integer{Value: 1}, &Float{Value: 1.5}, false},
+		{&Integer{Value: 1<<53 + 1}, &Float{Value: 1 << 53}, false},
+		{&Integer{Value: math.MaxInt64}, &Float{Value: math.MaxInt64}, false},
+		{&Integer{Value: math.MinInt64}, &Float{Value: math.MinInt64}, true},
+		{&Integer{Value: 0}, &Float{Value: math.NaN()}, false},
+		{&String{Value: "1"}, &Integer{Value: 1}, false},
+		{&Float{Value: 0}, &Float{Value: math.Copysign(0, -1)}, true},
+		{&String{Value: "a"}, &String{Value: "a"}, true},
+		{&Null{}, &Null{}, true},
+		{&Array{Elements: []Object{&Integer{Value: 1}}}, &Array{Elements: []Object{&Integer{Value: 1}}}, true},
+		{&Array{Elements: []Object{&Integer{Value: 1}}}, &Array{}, false},
+		{&Array{Elements: []Object{&Integer{Value: 1}}}, &Array{Elements: []Object{&Float{Value: 1}}}, true},
+		{fn, fn, true},
+		{fn, &Builtin{}, false},
+	}
+	for _, tt := range tests {
+		if Equal(tt.a, tt.b) != tt.expected {
+			t.Errorf("Expected Equal(%s, %s) to be %t", tt.a.Inspect(), tt.b.Inspect(), tt.expected)
+		}
+	}
+}
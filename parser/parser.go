@@ -32,16 +32,30 @@ var precedences = map[token.TokenType]int{
 	token.SLASH:    PRODUCT,
 	token.LPAREN:   CALL,
 	token.LBRACKET: INDEX,
+	token.DOT:      INDEX,
+}
+
+// Precedence returns how tightly the infix operator binds its operands, as
+// one of the constants above. Anything that is not an infix operator gets
+// LOWEST.
+func Precedence(operator string) int {
+	if precedence, ok := precedences[token.TokenType(operator)]; ok {
+		return precedence
+	}
+	return LOWEST
 }
 
 type Parser struct {
 	l         *lexer.Lexer
 	curToken  token.Token
 	errors    []string
+	errorAt   []token.Token // The token each of the errors was found at
 	peekToken token.Token
 
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
+
+	depth int // How many blocks deep the current token is
 }
 
 type (
@@ -55,6 +69,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBooleanLiteral)
@@ -77,6 +92,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
 
 	// Step the lexer properly, and fill up the parser
 	p.nextToken()
@@ -112,6 +128,7 @@ func (p *Parser) ParseProgram() *ast.Program {
 		}
 		p.nextToken()
 	}
+	program.Comments = p.l.Comments()
 
 	return program
 }
@@ -137,6 +154,19 @@ func (p *Parser) peekStep(s token.TokenType) bool {
 	return r
 }
 
+// peekWord steps onto the next token if it is the identifier word, which is
+// how contextual keywords such as `as` and `from` are matched. They remain
+// ordinary identifiers everywhere else.
+func (p *Parser) peekWord(word string) bool {
+	if p.isPeekToken(token.IDENT) && p.peekToken.Literal == word {
+		p.nextToken()
+		return true
+	}
+	msg := fmt.Sprintf("Parser expected %q but got %s", word, p.peekToken.Type)
+	p.writeErrorAt(p.peekToken, msg)
+	return false
+}
+
 // Expression functionalities
 
 func (p *Parser) peekPrecedence() int {
@@ -157,13 +187,24 @@ func (p *Parser) Errors() []string {
 	return p.errors
 }
 
+// ErrorTokens returns the token that each of the errors was found at, in the
+// same order as Errors
+func (p *Parser) ErrorTokens() []token.Token {
+	return p.errorAt
+}
+
 func (p *Parser) writeError(msg string) {
+	p.writeErrorAt(p.curToken, msg)
+}
+
+func (p *Parser) writeErrorAt(tok token.Token, msg string) {
 	p.errors = append(p.errors, msg)
+	p.errorAt = append(p.errorAt, tok)
 }
 
 func (p *Parser) peekError(s token.TokenType) {
 	msg := fmt.Sprintf("Parser expected %s but got %s", s, p.peekToken.Type)
-	p.writeError(msg)
+	p.writeErrorAt(p.peekToken, msg)
 }
 
 func (p *Parser) parseStatement() ast.Statement {
@@ -172,6 +213,10 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.IMPORT:
+		return p.parseImportStatement()
+	case token.EXPORT:
+		return p.parseExportStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -200,6 +245,54 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	return stmt
 }
 
+// Parses either `import "path" as name;` or `import { a, b } from "path";`
+func (p *Parser) parseImportStatement() ast.Statement {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+
+	if p.isPeekToken(token.LBRACE) {
+		p.nextToken()
+		stmt.Names = p.parseIdentifierList(token.RBRACE)
+		if stmt.Names == nil || !p.peekWord("from") || !p.peekStep(token.STRING) {
+			return nil
+		}
+		stmt.Path = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+	} else {
+		if !p.peekStep(token.STRING) {
+			return nil
+		}
+		stmt.Path = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+		if !p.peekWord("as") || !p.peekStep(token.IDENT) {
+			return nil
+		}
+		stmt.Alias = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if p.isPeekToken(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// Only let statements at the top level of a module can be exported
+func (p *Parser) parseExportStatement() ast.Statement {
+	if p.depth > 0 {
+		p.writeError("export is only allowed at the top level")
+		return nil
+	}
+	if !p.peekStep(token.LET) {
+		return nil
+	}
+
+	stmt := p.parseLetStatement()
+	if stmt == nil {
+		return nil
+	}
+	stmt.Exported = true
+
+	return stmt
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 
@@ -251,6 +344,7 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 
 	array.Elements = p.parseExpressionList(token.RBRACKET)
+	array.End = p.curToken
 
 	return array
 }
@@ -271,6 +365,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
 		hash.Pairs[key] = value
+		hash.Keys = append(hash.Keys, key)
 
 		if !p.isPeekToken(token.RBRACE) && !p.peekStep(token.COMMA) {
 			return nil
@@ -280,6 +375,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 	if !p.peekStep(token.RBRACE) {
 		return nil
 	}
+	hash.End = p.curToken
 
 	return hash
 }
@@ -303,6 +399,21 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return lit
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("Could not parse %q as float", p.curToken.Literal)
+		p.writeError(msg)
+		return nil
+	}
+
+	lit.Value = value
+
+	return lit
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	lit := &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 	return lit
@@ -416,9 +527,31 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 	return identifiers
 }
 
+// Collects the identifiers that are separated by commas, up to sentinel
+func (p *Parser) parseIdentifierList(sentinel token.TokenType) []*ast.Identifier {
+	identifiers := []*ast.Identifier{}
+
+	for !p.isPeekToken(sentinel) {
+		if !p.peekStep(token.IDENT) {
+			return nil
+		}
+		identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+		if !p.isPeekToken(sentinel) && !p.peekStep(token.COMMA) {
+			return nil
+		}
+	}
+	p.nextToken()
+
+	return identifiers
+}
+
 func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	blkstmt := &ast.BlockStatement{Token: p.curToken, Statements: []ast.Statement{}}
 
+	p.depth++
+	defer func() { p.depth-- }()
+
 	p.nextToken()
 
 	for !p.isCurToken(token.RBRACE) && !p.isCurToken(token.EOF) {
@@ -428,6 +561,7 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		}
 		p.nextToken()
 	}
+	blkstmt.End = p.curToken
 
 	return blkstmt
 }
@@ -435,23 +569,50 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 func (p *Parser) parseCallExpression(fn ast.Expression) ast.Expression {
 	ce := &ast.CallExpression{Token: p.curToken, Function: fn}
 	ce.Arguments = p.parseExpressionList(token.RPAREN)
+	ce.End = p.curToken
 
 	return ce
 }
 
+// parseIndexExpression parses both index expressions, and slices such as
+// `a[1:3]`, which are told apart by the colon
 func (p *Parser) parseIndexExpression(array ast.Expression) ast.Expression {
-	ie := &ast.IndexExpression{
-		Token: p.curToken,
-		Left:  array,
+	bracket := p.curToken
+	var start ast.Expression
+	if !p.isPeekToken(token.COLON) {
+		p.nextToken()
+		start = p.parseExpression(LOWEST)
 	}
-	p.nextToken()
-	ie.Index = p.parseExpression(LOWEST)
 
+	if !p.isPeekToken(token.COLON) {
+		if !p.peekStep(token.RBRACKET) {
+			return nil
+		}
+		return &ast.IndexExpression{Token: bracket, Left: array, Index: start}
+	}
+
+	p.nextToken()
+	se := &ast.SliceExpression{Token: bracket, Left: array, Start: start}
+	if !p.isPeekToken(token.RBRACKET) {
+		p.nextToken()
+		se.End = p.parseExpression(LOWEST)
+	}
 	if !p.peekStep(token.RBRACKET) {
 		return nil
 	}
 
-	return ie
+	return se
+}
+
+func (p *Parser) parseMemberExpression(left ast.Expression) ast.Expression {
+	me := &ast.MemberExpression{Token: p.curToken, Left: left}
+
+	if !p.peekStep(token.IDENT) {
+		return nil
+	}
+	me.Member = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return me
 }
 
 func (p *Parser) parseExpressionList(sentinel token.TokenType) []ast.Expression {
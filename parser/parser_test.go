@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/waridh/go-monkey-interpreter/ast"
@@ -172,6 +173,35 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	program := getProgram(t, `2.5;`)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("Expected length of %d, got %d", 1, len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Could not get ExpressionStatement, got %T", program.Statements[0])
+	}
+	lit, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("Unable to cast to ast.FloatLiteral, got %T", stmt.Expression)
+	}
+	if lit.Value != 2.5 || lit.String() != "2.5" {
+		t.Errorf("Expected 2.5, got %v (%s)", lit.Value, lit.String())
+	}
+}
+
+func TestFloatLiteralOutOfRange(t *testing.T) {
+	input := "1" + strings.Repeat("0", 400) + ".5"
+	p := New(lexer.New(input))
+	p.ParseProgram()
+	expected := fmt.Sprintf("Could not parse %q as float", input)
+	if len(p.Errors()) == 0 || !strings.Contains(p.Errors()[0], expected) {
+		t.Errorf("Expected error %q, got %q", expected, p.Errors())
+	}
+}
+
 func TestIdentifierExpression(t *testing.T) {
 	input := []struct {
 		input    string
@@ -343,17 +373,23 @@ func TestHashLiteral(t *testing.T) {
 	tests := []struct {
 		input    string
 		expected map[any]any
+		keys     []string
 	}{
 		{`{foo: 1, 64: 2, true: 3};`, map[any]any{
 			"foo": 1,
 			64:    2,
 			true:  3,
-		}},
+		}, []string{"foo", "64", "true"}},
 		{`{1: 1 + 10, 64: 2 * 2, true: 3 / 3};`, map[any]any{
 			1:    infixTest{1, "+", 10},
 			64:   infixTest{2, "*", 2},
 			true: infixTest{3, "/", 3},
-		}},
+		}, []string{"1", "64", "true"}},
+		{`{true: 3 / 3, 64: 2 * 2, 1: 1 + 10};`, map[any]any{
+			1:    infixTest{1, "+", 10},
+			64:   infixTest{2, "*", 2},
+			true: infixTest{3, "/", 3},
+		}, []string{"true", "64", "1"}},
 	}
 
 	for _, test := range tests {
@@ -387,6 +423,12 @@ func TestHashLiteral(t *testing.T) {
 				t.Fatalf("Unexpected branch")
 			}
 		}
+		if len(hash.Keys) != len(hash.Pairs) {
+			t.Fatalf("Expected %d keys, got %d", len(hash.Pairs), len(hash.Keys))
+		}
+		for idx, expected := range test.keys {
+			testExpressionString(t, hash.Keys[idx], expected)
+		}
 	}
 }
 
@@ -548,6 +590,26 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a[1:n + 1]",
+			"(a[1:(n + 1)])",
+		},
+		{
+			"a[:2][1:]",
+			"((a[:2])[1:])",
+		},
+		{
+			"s[:] + t",
+			"((s[:]) + t)",
+		},
+		{
+			"math.abs(a) + b",
+			"((math.abs)(a) + b)",
+		},
+		{
+			"-a.b.c[0]",
+			"(-(((a.b).c)[0]))",
+		},
 	}
 
 	for _, test := range tests {
@@ -860,6 +922,164 @@ func TestIndexExpression(t *testing.T) {
 	}
 }
 
+func TestSliceExpression(t *testing.T) {
+	tests := []struct {
+		input string
+		start any
+		end   any
+	}{
+		{"a[1:3]", 1, 3},
+		{"a[1:]", 1, nil},
+		{"a[:b]", nil, "b"},
+		{"a[:]", nil, nil},
+	}
+	for _, tt := range tests {
+		program := getProgram(t, tt.input)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("Expected length of %d, got %d", 1, len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("Unable to cast to ast.ExpressionStatement, got %T", program.Statements[0])
+		}
+		se, ok := stmt.Expression.(*ast.SliceExpression)
+		if !ok {
+			t.Fatalf("Unable to cast to ast.SliceExpression, got %T", stmt.Expression)
+		}
+		testIdentifierExpression(t, se.Left, "a")
+		testExpression(t, se.Start, tt.start)
+		testExpression(t, se.End, tt.end)
+	}
+
+	for _, input := range []string{"a[1:2:3]", "a[1:"} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("Expected an error parsing %s", input)
+		}
+	}
+}
+
+func TestMemberExpression(t *testing.T) {
+	program := getProgram(t, "math.abs")
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("Expected length of %d, got %d", 1, len(program.Statements))
+	}
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("Unable to cast to ast.ExpressionStatement, got %T", program.Statements[0])
+	}
+	me, ok := stmt.Expression.(*ast.MemberExpression)
+	if !ok {
+		t.Fatalf("Unable to cast to ast.MemberExpression, got %T", stmt.Expression)
+	}
+	testIdentifierExpression(t, me.Left, "math")
+	testIdentifierExpression(t, me.Member, "abs")
+
+	p := New(lexer.New("math.5"))
+	p.ParseProgram()
+	if len(p.Errors()) == 0 {
+		t.Errorf("Expected an error when the member is not an identifier")
+	}
+}
+
+func TestImportStatement(t *testing.T) {
+	tests := []struct {
+		input string
+		path  string
+		alias string
+		names []string
+	}{
+		{`import "lib/math.mk" as math;`, "lib/math.mk", "math", nil},
+		{`import { add, sub } from "ops.mk"`, "ops.mk", "", []string{"add", "sub"}},
+		{`import {} from "empty.mk";`, "empty.mk", "", []string{}},
+		{`import { as, from } from "words.mk";`, "words.mk", "", []string{"as", "from"}},
+		{`import "words.mk" as from;`, "words.mk", "from", nil},
+	}
+	for _, tt := range tests {
+		program := getProgram(t, tt.input)
+		if len(program.Statements) != 1 {
+			t.Fatalf("Expected length of %d, got %d", 1, len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.ImportStatement)
+		if !ok {
+			t.Fatalf("Unable to cast to ast.ImportStatement, got %T", program.Statements[0])
+		}
+		if stmt.Path.Value != tt.path {
+			t.Errorf("Expected path %q, got %q", tt.path, stmt.Path.Value)
+		}
+		if tt.alias != "" {
+			testIdentifierExpression(t, stmt.Alias, tt.alias)
+		} else if stmt.Alias != nil {
+			t.Errorf("Expected no alias, got %s", stmt.Alias)
+		}
+		if len(stmt.Names) != len(tt.names) {
+			t.Fatalf("Expected %d names, got %d", len(tt.names), len(stmt.Names))
+		}
+		for i, name := range tt.names {
+			testIdentifierExpression(t, stmt.Names[i], name)
+		}
+		if stmt.String() != tt.input && stmt.String() != tt.input+";" {
+			t.Errorf("Unexpected String() %q", stmt.String())
+		}
+	}
+}
+
+// as and from are only keywords within an import
+func TestContextualKeywords(t *testing.T) {
+	program := getProgram(t, "let from = 1; let as = from + 1; as;")
+	if len(program.Statements) != 3 {
+		t.Fatalf("Expected length of %d, got %d", 3, len(program.Statements))
+	}
+	for i, name := range []string{"from", "as"} {
+		stmt, ok := program.Statements[i].(*ast.LetStatement)
+		if !ok {
+			t.Fatalf("Unable to cast to ast.LetStatement, got %T", program.Statements[i])
+		}
+		testIdentifierExpression(t, stmt.Name, name)
+	}
+	if program.String() != "let from = 1;let as = (from + 1);as" {
+		t.Errorf("Unexpected String() %q", program.String())
+	}
+}
+
+func TestExportStatement(t *testing.T) {
+	program := getProgram(t, "export let x = 5; let y = 6;")
+	if len(program.Statements) != 2 {
+		t.Fatalf("Expected length of %d, got %d", 2, len(program.Statements))
+	}
+	for i, exported := range []bool{true, false} {
+		stmt, ok := program.Statements[i].(*ast.LetStatement)
+		if !ok {
+			t.Fatalf("Unable to cast to ast.LetStatement, got %T", program.Statements[i])
+		}
+		if stmt.Exported != exported {
+			t.Errorf("Expected statement %d exported to be %t", i, exported)
+		}
+	}
+	if program.String() != "export let x = 5;let y = 6;" {
+		t.Errorf("Unexpected String() %q", program.String())
+	}
+
+	errorInputs := []string{
+		"fn() { export let x = 5; }",
+		"export 5;",
+		`import "lib.mk";`,
+		`import { a b } from "lib.mk";`,
+		`import { a } to "lib.mk";`,
+		`import "lib.mk" from lib;`,
+	}
+	for _, input := range errorInputs {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("Expected parser errors for %q", input)
+		}
+	}
+}
+
 func TestBooleanExpression(t *testing.T) {
 	input := []struct {
 		input    string
@@ -1074,3 +1294,27 @@ func testExpressionString(t *testing.T, expr ast.Expression, expected string) bo
 	}
 	return true
 }
+
+func TestErrorTokens(t *testing.T) {
+	tests := []struct {
+		input  string
+		line   int
+		column int
+	}{
+		{"let x 5;", 1, 7},
+		{"let x = 5;\nlet = 6;", 2, 5},
+		{"let x = 5;\n  * 2", 2, 3},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 || len(p.ErrorTokens()) != len(p.Errors()) {
+			t.Fatalf("Expected an error token for each of the errors of %q, got %v", tt.input, p.ErrorTokens())
+		}
+		tok := p.ErrorTokens()[0]
+		if tok.Line != tt.line || tok.Column != tt.column {
+			t.Errorf("Expected the first error of %q at %d:%d, got %d:%d", tt.input, tt.line, tt.column, tok.Line, tok.Column)
+		}
+	}
+}
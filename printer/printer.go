@@ -0,0 +1,395 @@
+// Package printer turns syntax trees back into Monkey source code, laid out
+// in the one canonical style that `monkey fmt` enforces.
+package printer
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/waridh/go-monkey-interpreter/ast"
+	"github.com/waridh/go-monkey-interpreter/lexer"
+	"github.com/waridh/go-monkey-interpreter/parser"
+	"github.com/waridh/go-monkey-interpreter/token"
+)
+
+const indentation = "  "
+
+// Format parses src and returns it in canonical form. Source that does not
+// parse is not formatted, and the parser errors are returned instead.
+func Format(src []byte) ([]byte, error) {
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if errs := p.Errors(); len(errs) != 0 {
+		return nil, errors.New(strings.Join(errs, "\n"))
+	}
+
+	var out bytes.Buffer
+	if err := Fprint(&out, program); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Fprint writes program to w in canonical form. The comments of the program
+// are placed between the statements they were written between, and a
+// comment on the same line as the end of a statement stays there.
+func Fprint(w io.Writer, program *ast.Program) error {
+	p := &printer{comments: program.Comments}
+	p.statements(program.Statements, nil)
+	// Whatever comments are left come after the last statement
+	for len(p.comments) != 0 {
+		p.comment()
+	}
+	if p.out.Len() != 0 {
+		p.out.WriteByte('\n')
+	}
+
+	_, err := w.Write(p.out.Bytes())
+	return err
+}
+
+type printer struct {
+	out      bytes.Buffer
+	indent   int
+	pending  bool          // Whether the indentation of the line is yet to be written
+	comments []token.Token // The comments that are yet to be printed
+	line     int           // The source line of the last thing printed
+}
+
+func (p *printer) write(s string) {
+	if p.pending {
+		p.out.WriteString(strings.Repeat(indentation, p.indent))
+		p.pending = false
+	}
+	p.out.WriteString(s)
+}
+
+// newline starts a new line for something that began on source line. One
+// blank line is kept wherever the source had any.
+func (p *printer) newline(line int) {
+	if p.out.Len() == 0 {
+		p.line = line
+		return
+	}
+	p.out.WriteByte('\n')
+	if p.line != 0 && line > p.line+1 {
+		p.out.WriteByte('\n')
+	}
+	p.pending = true
+	p.line = line
+}
+
+// comment prints the next comment on a line of its own
+func (p *printer) comment() {
+	comment := p.comments[0]
+	p.comments = p.comments[1:]
+	p.newline(comment.Line)
+	p.write(comment.Literal)
+}
+
+// commentsBefore prints the comments that come before line and column
+func (p *printer) commentsBefore(line, column int) {
+	for len(p.comments) != 0 && before(p.comments[0], line, column) {
+		p.comment()
+	}
+}
+
+// trailingComments prints the comments written on or before the last line of
+// the statement just printed, the first of them on the same line. Comments
+// from after the end of the enclosing block are left to the block.
+func (p *printer) trailingComments(line int, end *token.Token) {
+	for i := 0; len(p.comments) != 0 && p.comments[0].Line <= line; i++ {
+		if end != nil && !before(p.comments[0], end.Line, end.Column) {
+			break
+		}
+		if i == 0 {
+			comment := p.comments[0]
+			p.comments = p.comments[1:]
+			p.write(" " + comment.Literal)
+			continue
+		}
+		p.comment()
+	}
+	p.line = max(p.line, line)
+}
+
+// statements prints stmts one per line, inside the block ending at end if
+// there is one. In blocks, the last expression statement is the value of the
+// block and goes without a semicolon.
+func (p *printer) statements(stmts []ast.Statement, end *token.Token) {
+	for i, stmt := range stmts {
+		start := ast.StartOf(stmt)
+		p.commentsBefore(start.Line, start.Column)
+		p.newline(start.Line)
+		p.statement(stmt, end != nil && i == len(stmts)-1)
+		p.trailingComments(lastLine(stmt), end)
+	}
+}
+
+func (p *printer) statement(stmt ast.Statement, last bool) {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		if stmt.Exported {
+			p.write("export ")
+		}
+		p.write("let " + stmt.Name.Value + " = ")
+		p.expression(stmt.Value, parser.LOWEST)
+		p.write(";")
+	case *ast.ReturnStatement:
+		p.write("return ")
+		p.expression(stmt.ReturnValue, parser.LOWEST)
+		p.write(";")
+	case *ast.ImportStatement:
+		p.write(stmt.String())
+	case *ast.ExpressionStatement:
+		p.expression(stmt.Expression, parser.LOWEST)
+		if _, ok := stmt.Expression.(*ast.IfExpression); !ok && !last {
+			p.write(";")
+		}
+	case *ast.BlockStatement:
+		p.block(stmt, false)
+	}
+}
+
+// block prints a block over several lines, or as `{ x }` when inline is set
+// and the block is a single short expression
+func (p *printer) block(block *ast.BlockStatement, inline bool) {
+	if inline && p.isSimple(block) {
+		if len(block.Statements) == 0 {
+			p.write("{}")
+			return
+		}
+		p.write("{ ")
+		p.expression(block.Statements[0].(*ast.ExpressionStatement).Expression, parser.LOWEST)
+		p.write(" }")
+		return
+	}
+
+	// Blocks neither start nor end with a blank line
+	p.write("{")
+	p.line = 0
+	p.indent++
+	p.statements(block.Statements, &block.End)
+	p.commentsBefore(block.End.Line, block.End.Column)
+	p.indent--
+	if p.line != 0 {
+		p.line = block.End.Line
+		p.newline(block.End.Line)
+	}
+	p.write("}")
+}
+
+// isSimple reports whether block fits on one line: it is empty or holds a
+// single expression with no blocks of its own, and has no comments inside.
+func (p *printer) isSimple(block *ast.BlockStatement) bool {
+	if len(p.comments) != 0 && before(p.comments[0], block.End.Line, block.End.Column) {
+		return false
+	}
+	switch len(block.Statements) {
+	case 0:
+		return true
+	case 1:
+		stmt, ok := block.Statements[0].(*ast.ExpressionStatement)
+		return ok && !hasBlock(stmt.Expression)
+	default:
+		return false
+	}
+}
+
+// Operators that apply to the expression on their left, such as calls and
+// indexing, bind tighter than any infix or prefix operator. Literals and
+// other expressions that never need parentheses bind tighter still.
+const (
+	postfix = parser.CALL
+	atom    = parser.INDEX + 1
+)
+
+// expression prints expr, in parentheses when it binds more loosely than
+// precedence
+func (p *printer) expression(expr ast.Expression, precedence int) {
+	if precedenceOf(expr) < precedence {
+		p.write("(")
+		defer p.write(")")
+	}
+
+	switch expr := expr.(type) {
+	case *ast.Identifier:
+		p.write(expr.Value)
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.Boolean:
+		p.write(expr.TokenLiteral())
+	case *ast.StringLiteral:
+		p.write(`"` + expr.Value + `"`)
+	case *ast.PrefixExpression:
+		p.write(expr.Operator)
+		p.expression(expr.Right, parser.PREFIX)
+	case *ast.InfixExpression:
+		// Operators are left associative, so only the right operand needs
+		// parentheses at the same precedence
+		operator := parser.Precedence(expr.Operator)
+		p.expression(expr.Left, operator)
+		p.write(" " + expr.Operator + " ")
+		p.expression(expr.Right, operator+1)
+	case *ast.IfExpression:
+		p.write("if (")
+		p.expression(expr.Condition, parser.LOWEST)
+		p.write(") ")
+		p.block(expr.Consequence, false)
+		if expr.Alternative != nil {
+			p.write(" else ")
+			p.block(expr.Alternative, false)
+		}
+	case *ast.FunctionLiteral:
+		p.write("fn(")
+		for i, param := range expr.Parameter {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.write(param.Value)
+		}
+		p.write(") ")
+		p.block(expr.Body, true)
+	case *ast.ArrayLiteral:
+		p.write("[")
+		p.list(expr.Elements)
+		p.write("]")
+	case *ast.HashLiteral:
+		p.write("{")
+		for i, key := range expr.Keys {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.expression(key, parser.LOWEST)
+			p.write(": ")
+			p.expression(expr.Pairs[key], parser.LOWEST)
+		}
+		p.write("}")
+	case *ast.CallExpression:
+		p.expression(expr.Function, postfix)
+		p.write("(")
+		p.list(expr.Arguments)
+		p.write(")")
+	case *ast.IndexExpression:
+		p.expression(expr.Left, postfix)
+		p.write("[")
+		p.expression(expr.Index, parser.LOWEST)
+		p.write("]")
+	case *ast.SliceExpression:
+		p.expression(expr.Left, postfix)
+		p.write("[")
+		if expr.Start != nil {
+			p.expression(expr.Start, parser.LOWEST)
+		}
+		p.write(":")
+		if expr.End != nil {
+			p.expression(expr.End, parser.LOWEST)
+		}
+		p.write("]")
+	case *ast.MemberExpression:
+		p.expression(expr.Left, postfix)
+		p.write("." + expr.Member.Value)
+	}
+}
+
+func (p *printer) list(exprs []ast.Expression) {
+	for i, expr := range exprs {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.expression(expr, parser.LOWEST)
+	}
+}
+
+func precedenceOf(expr ast.Expression) int {
+	switch expr := expr.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(expr.Operator)
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	case *ast.CallExpression, *ast.IndexExpression, *ast.SliceExpression, *ast.MemberExpression:
+		return postfix
+	default:
+		return atom
+	}
+}
+
+// before reports whether tok starts before line and column
+func before(tok token.Token, line, column int) bool {
+	return tok.Line < line || (tok.Line == line && tok.Column < column)
+}
+
+// lastLine returns the source line that node ends on
+func lastLine(node ast.Node) int {
+	switch node := node.(type) {
+	case *ast.LetStatement:
+		return max(node.Token.Line, lastLine(node.Value))
+	case *ast.ReturnStatement:
+		return max(node.Token.Line, lastLine(node.ReturnValue))
+	case *ast.ImportStatement:
+		line := max(node.Token.Line, lastLine(node.Path))
+		if node.Alias != nil {
+			line = max(line, lastLine(node.Alias))
+		}
+		return line
+	case *ast.ExpressionStatement:
+		return max(node.Token.Line, lastLine(node.Expression))
+	case *ast.BlockStatement:
+		return node.End.Line
+	case *ast.StringLiteral:
+		// Strings may run over several lines
+		return node.Token.Line + strings.Count(node.Value, "\n")
+	case *ast.PrefixExpression:
+		return lastLine(node.Right)
+	case *ast.InfixExpression:
+		return lastLine(node.Right)
+	case *ast.IfExpression:
+		if node.Alternative != nil {
+			return node.Alternative.End.Line
+		}
+		return node.Consequence.End.Line
+	case *ast.FunctionLiteral:
+		return node.Body.End.Line
+	case *ast.ArrayLiteral:
+		return node.End.Line
+	case *ast.HashLiteral:
+		return node.End.Line
+	case *ast.CallExpression:
+		return node.End.Line
+	case *ast.IndexExpression:
+		return lastLine(node.Index)
+	case *ast.SliceExpression:
+		line := node.Token.Line
+		for _, bound := range []ast.Expression{node.Start, node.End} {
+			if bound != nil {
+				line = max(line, lastLine(bound))
+			}
+		}
+		return line
+	case *ast.MemberExpression:
+		return node.Member.Token.Line
+	case *ast.Identifier:
+		return node.Token.Line
+	case *ast.IntegerLiteral:
+		return node.Token.Line
+	case *ast.FloatLiteral:
+		return node.Token.Line
+	case *ast.Boolean:
+		return node.Token.Line
+	default:
+		return 0
+	}
+}
+
+// hasBlock reports whether expr contains a function or if expression
+func hasBlock(expr ast.Expression) bool {
+	found := false
+	ast.Inspect(expr, func(node ast.Node) bool {
+		switch node.(type) {
+		case *ast.IfExpression, *ast.FunctionLiteral:
+			found = true
+		}
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,155 @@
+package printer
+
+import (
+	"go/ast"
+	goparser "go/parser"
+	"go/token"
+	"strconv"
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/lexer"
+	"github.com/waridh/go-monkey-interpreter/parser"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x=5", "let x = 5;\n"},
+		{"5 + 5 * 2; -a * b", "5 + 5 * 2;\n-a * b;\n"},
+		{"(5 + 5) * 2", "(5 + 5) * 2;\n"},
+		{"a - (b - c); (a - b) - c", "a - (b - c);\na - b - c;\n"},
+		{"-(a + b); !(-a)", "-(a + b);\n!-a;\n"},
+		{"(a + b)(c)[0]; a.b(c)[1:]", "(a + b)(c)[0];\na.b(c)[1:];\n"},
+		{"add(a, b * c)[:(1 + 2)]", "add(a, b * c)[:1 + 2];\n"},
+		{`let h = {"a":1,true:[1,2]}; let e = {}`, "let h = {\"a\": 1, true: [1, 2]};\nlet e = {};\n"},
+		{"let f = fn(x,y){x+y}; fn(){}", "let f = fn(x, y) { x + y };\nfn() {};\n"},
+		{
+			"let f = fn(x) { let y = x; y }",
+			"let f = fn(x) {\n  let y = x;\n  y\n};\n",
+		},
+		{
+			"if (x < y) { x } else { if (x) { return y; } }",
+			"if (x < y) {\n  x\n} else {\n  if (x) {\n    return y;\n  }\n}\n",
+		},
+		{"if (x) {}", "if (x) {}\n"},
+		{"let f = fn() { if (a) { b } }", "let f = fn() {\n  if (a) {\n    b\n  }\n};\n"},
+		{`import "m" as m; import {a,b} from "m"; export let x=1`, "import \"m\" as m;\nimport { a, b } from \"m\";\nexport let x = 1;\n"},
+		{"let a = 1;\n\n\n\nlet b = 2;\nlet c = 3;", "let a = 1;\n\nlet b = 2;\nlet c = 3;\n"},
+		{"let f = fn(x) {\n\n  x;\n\n  x\n\n};", "let f = fn(x) {\n  x;\n\n  x\n};\n"},
+		{"puts(\n  1,\n  2\n);\nlet a = 1;", "puts(1, 2);\nlet a = 1;\n"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		formatted, err := Format([]byte(tt.input))
+		if err != nil {
+			t.Fatalf("Format(%q) failed: %s", tt.input, err)
+		}
+		if string(formatted) != tt.expected {
+			t.Errorf("Format(%q) - expected %q, got %q", tt.input, tt.expected, formatted)
+		}
+	}
+}
+
+func TestFormatComments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"// only a comment", "// only a comment\n"},
+		{"// leading\nlet x = 1; // trailing\n// last", "// leading\nlet x = 1; // trailing\n// last\n"},
+		{"let x = 1;\n\n// about y\nlet y = 2;", "let x = 1;\n\n// about y\nlet y = 2;\n"},
+		{
+			"let f = fn(x) { // what f does\n  x // the result\n  // done\n};",
+			"let f = fn(x) {\n  // what f does\n  x // the result\n  // done\n};\n",
+		},
+		{"let f = fn(x) { x }; // f", "let f = fn(x) { x }; // f\n"},
+		{
+			"let f = fn(x) {\n  if (x) { 1 } else { 2 } // if\n};",
+			"let f = fn(x) {\n  if (x) {\n    1\n  } else {\n    2\n  } // if\n};\n",
+		},
+		{
+			"let h = {\n  \"a\": 1, // first\n  \"b\": 2\n};\nh",
+			"let h = {\"a\": 1, \"b\": 2}; // first\nh;\n",
+		},
+		{
+			"if (x) {\n  a\n} else {\n  // nothing\n}",
+			"if (x) {\n  a\n} else {\n  // nothing\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		formatted, err := Format([]byte(tt.input))
+		if err != nil {
+			t.Fatalf("Format(%q) failed: %s", tt.input, err)
+		}
+		if string(formatted) != tt.expected {
+			t.Errorf("Format(%q) - expected %q, got %q", tt.input, tt.expected, formatted)
+		}
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	_, err := Format([]byte("let = 5;"))
+	if err == nil {
+		t.Fatalf("Expected source with syntax errors not to be formatted")
+	}
+}
+
+// TestIdempotency formats every Monkey program in the parser tests, and
+// checks that the result means the same thing and is already formatted
+func TestIdempotency(t *testing.T) {
+	fset := token.NewFileSet()
+	file, err := goparser.ParseFile(fset, "../parser/parser_test.go", nil, 0)
+	if err != nil {
+		t.Fatalf("could not read the parser tests: %s", err)
+	}
+
+	sources := []string{}
+	ast.Inspect(file, func(n ast.Node) bool {
+		lit, ok := n.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		if src, err := strconv.Unquote(lit.Value); err == nil {
+			sources = append(sources, src)
+		}
+		return true
+	})
+
+	checked := 0
+	for _, src := range sources {
+		p := parser.New(lexer.New(src))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			continue
+		}
+		checked++
+
+		formatted, err := Format([]byte(src))
+		if err != nil {
+			t.Errorf("Format(%q) failed: %s", src, err)
+			continue
+		}
+		again, err := Format(formatted)
+		if err != nil {
+			t.Errorf("formatted %q does not parse: %s", formatted, err)
+			continue
+		}
+		if string(again) != string(formatted) {
+			t.Errorf("formatting %q is not idempotent - first %q, then %q", src, formatted, again)
+		}
+
+		reparsed := parser.New(lexer.New(string(formatted))).ParseProgram()
+		if reparsed.String() != program.String() {
+			t.Errorf("formatting %q changed the program - expected %q, got %q",
+				src, program.String(), reparsed.String())
+		}
+	}
+
+	if checked < 50 {
+		t.Fatalf("only %d programs were found in the parser tests", checked)
+	}
+}
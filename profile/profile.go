@@ -0,0 +1,291 @@
+// Package profile samples where a Monkey program spends its time and what it
+// allocates, by Monkey function and source line, and writes what it found in
+// the protobuf format of pprof, so that `go tool pprof` can show it.
+package profile
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/waridh/go-monkey-interpreter/ast"
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+// DefaultInterval is how often evaluation is sampled, as often as the Go CPU
+// profiler samples
+const DefaultInterval = 10 * time.Millisecond
+
+// The values of every sample, in order
+var sampleTypes = [...]struct{ name, unit string }{
+	{"samples", "count"},
+	{"cpu", "nanoseconds"},
+	{"alloc_objects", "count"},
+	{"alloc_space", "bytes"},
+}
+
+// Profiler is an object.Observer keeping track of the Monkey call stack. A
+// clock on a goroutine of its own ticks every interval, and ticks are
+// charged to the stack that evaluation is observed at next. Allocations are
+// charged to the stack they were made on.
+type Profiler struct {
+	file     string
+	interval time.Duration
+	ticks    atomic.Int64
+	done     chan struct{}
+	start    time.Time
+	duration time.Duration
+
+	// Only used by the evaluating goroutine
+	memory  *object.Memory
+	seen    int64 // The ticks charged so far
+	objects int64 // The allocations charged so far
+	bytes   int64
+	stack   []frame
+	files   map[*object.Environment]string // The file of each global environment
+	module  string                         // The module being imported, if any
+
+	functions []function
+	locations []location
+	ids       map[any]uint64 // The IDs of functions and locations, counted from 1
+	samples   map[string]*sample
+	order     []*sample
+}
+
+type function struct {
+	name  string
+	file  string
+	start int
+}
+
+type location struct {
+	function uint64
+	line     int
+}
+
+type frame struct {
+	function uint64
+	line     int
+}
+
+type sample struct {
+	locations []uint64 // The innermost location first
+	values    [len(sampleTypes)]int64
+}
+
+// New creates a profiler for the program in file, which samples every
+// interval once started
+func New(file string, interval time.Duration) *Profiler {
+	return &Profiler{
+		file:     file,
+		interval: interval,
+		files:    make(map[*object.Environment]string),
+		ids:      make(map[any]uint64),
+		samples:  make(map[string]*sample),
+	}
+}
+
+// Start starts the clock
+func (p *Profiler) Start() {
+	p.start = time.Now()
+	p.done = make(chan struct{})
+	go func(start time.Time, done chan struct{}) {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				// Tickers drop ticks that are not received in time, so the
+				// ticks are counted from the start instead
+				p.ticks.Store(int64(time.Since(start) / p.interval))
+			case <-done:
+				return
+			}
+		}
+	}(p.start, p.done)
+}
+
+// Stop stops the clock, once evaluation is over
+func (p *Profiler) Stop() {
+	if p.done != nil {
+		close(p.done)
+		p.done = nil
+		p.duration = time.Since(p.start)
+	}
+}
+
+func (p *Profiler) Enter(node ast.Node, env *object.Environment) {
+	switch node := node.(type) {
+	case *ast.Program:
+		if p.memory == nil {
+			p.memory = env.Runtime().Memory
+			p.files[env] = p.file
+			p.stack = []frame{{function: p.function(function{name: "main", file: p.file, start: 1})}}
+		} else if _, ok := p.files[env]; !ok {
+			p.files[env] = p.module
+		}
+	case *ast.ImportStatement:
+		p.module = object.ModuleName(env.Module(), node.Path.Value)
+	case *ast.BlockStatement:
+		// Blocks are charged to the line they are part of
+	case ast.Statement:
+		p.stack[len(p.stack)-1].line = ast.StartOf(node).Line
+	}
+	p.charge()
+}
+
+func (p *Profiler) Exit(node ast.Node, result object.Object) {
+	p.charge()
+}
+
+func (p *Profiler) Call(fn object.Object, args []object.Object) {
+	p.charge()
+	var f function
+	switch fn := fn.(type) {
+	case *object.Function:
+		f = function{name: fn.Name, file: p.fileOf(fn.Env), start: fn.Body.Token.Line}
+		if f.name == "" {
+			f.name = fmt.Sprintf("fn@%d", f.start)
+		}
+	case *object.Builtin:
+		f = function{name: fn.Name}
+	}
+	p.stack = append(p.stack, frame{function: p.function(f), line: f.start})
+}
+
+func (p *Profiler) Return(fn object.Object, result object.Object) {
+	p.charge()
+	p.stack = p.stack[:len(p.stack)-1]
+}
+
+func (p *Profiler) Bind(name string, value object.Object, env *object.Environment) {}
+
+func (p *Profiler) Error(node ast.Node, err *object.Error) {}
+
+// fileOf finds the file that code evaluated in env comes from
+func (p *Profiler) fileOf(env *object.Environment) string {
+	for env.Outer() != nil {
+		env = env.Outer()
+	}
+	return p.files[env]
+}
+
+func (p *Profiler) function(f function) uint64 {
+	if id, ok := p.ids[f]; ok {
+		return id
+	}
+	p.functions = append(p.functions, f)
+	p.ids[f] = uint64(len(p.functions))
+	return p.ids[f]
+}
+
+func (p *Profiler) location(l location) uint64 {
+	if id, ok := p.ids[l]; ok {
+		return id
+	}
+	p.locations = append(p.locations, l)
+	p.ids[l] = uint64(len(p.locations))
+	return p.ids[l]
+}
+
+// charge charges the ticks and allocations since the last charge to the
+// current stack
+func (p *Profiler) charge() {
+	if p.memory == nil {
+		return
+	}
+	ticks, stats := p.ticks.Load(), p.memory.Stats()
+	if ticks == p.seen && stats.Objects == p.objects {
+		return
+	}
+
+	locations := make([]uint64, len(p.stack))
+	var key strings.Builder
+	for i := range p.stack {
+		f := p.stack[len(p.stack)-1-i]
+		locations[i] = p.location(location{function: f.function, line: f.line})
+		fmt.Fprintf(&key, "%d,", locations[i])
+	}
+	s, ok := p.samples[key.String()]
+	if !ok {
+		s = &sample{locations: locations}
+		p.samples[key.String()] = s
+		p.order = append(p.order, s)
+	}
+	s.values[0] += ticks - p.seen
+	s.values[1] += (ticks - p.seen) * p.interval.Nanoseconds()
+	s.values[2] += stats.Objects - p.objects
+	s.values[3] += stats.Allocated - p.bytes
+	p.seen, p.objects, p.bytes = ticks, stats.Objects, stats.Allocated
+}
+
+// Write writes the profile to w as a gzipped profile.proto message
+func (p *Profiler) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(p.encode()); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// encode writes the fields of a profile.proto Profile, with the strings they
+// refer to interned in a table written last
+func (p *Profiler) encode() []byte {
+	table := []string{""}
+	index := map[string]int64{"": 0}
+	str := func(s string) int64 {
+		if i, ok := index[s]; ok {
+			return i
+		}
+		table = append(table, s)
+		index[s] = int64(len(table) - 1)
+		return index[s]
+	}
+	valueType := func(name, unit string) func(b *protoBuffer) {
+		return func(b *protoBuffer) {
+			b.int64(1, str(name))
+			b.int64(2, str(unit))
+		}
+	}
+
+	var b protoBuffer
+	for _, t := range sampleTypes {
+		b.message(1, valueType(t.name, t.unit))
+	}
+	for _, s := range p.order {
+		b.message(2, func(b *protoBuffer) {
+			b.packedUint64s(1, s.locations)
+			b.packedInt64s(2, s.values[:])
+		})
+	}
+	for i, l := range p.locations {
+		b.message(4, func(b *protoBuffer) {
+			b.uint64(1, uint64(i+1))
+			b.message(4, func(b *protoBuffer) {
+				b.uint64(1, l.function)
+				b.int64(2, int64(l.line))
+			})
+		})
+	}
+	for i, f := range p.functions {
+		b.message(5, func(b *protoBuffer) {
+			b.uint64(1, uint64(i+1))
+			b.int64(2, str(f.name))
+			b.int64(3, str(f.name))
+			b.int64(4, str(f.file))
+			b.int64(5, int64(f.start))
+		})
+	}
+	b.int64(9, p.start.UnixNano())
+	b.int64(10, p.duration.Nanoseconds())
+	b.message(11, valueType("cpu", "nanoseconds"))
+	b.int64(12, p.interval.Nanoseconds())
+	b.int64(14, str("cpu"))
+	for _, s := range table {
+		b.string(6, s)
+	}
+	return b.data
+}
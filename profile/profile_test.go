@@ -0,0 +1,154 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/monkey"
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+// decode reads the fields of a protobuf message, giving each field the
+// varints and byte strings it holds
+func decode(t *testing.T, data []byte) map[int][]any {
+	t.Helper()
+	fields := map[int][]any{}
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		data = data[n:]
+		field, wire := int(key>>3), int(key&7)
+		value, n := binary.Uvarint(data)
+		if n <= 0 {
+			t.Fatalf("invalid varint in field %d", field)
+		}
+		data = data[n:]
+		switch wire {
+		case wireVarint:
+			fields[field] = append(fields[field], value)
+		case wireBytes:
+			fields[field] = append(fields[field], data[:value])
+			data = data[value:]
+		default:
+			t.Fatalf("unexpected wire type %d in field %d", wire, field)
+		}
+	}
+	return fields
+}
+
+func packed(data []byte) []uint64 {
+	values := []uint64{}
+	for len(data) > 0 {
+		value, n := binary.Uvarint(data)
+		values = append(values, value)
+		data = data[n:]
+	}
+	return values
+}
+
+func number(values []any) uint64 {
+	if len(values) == 0 {
+		return 0
+	}
+	return values[0].(uint64)
+}
+
+func TestProfile(t *testing.T) {
+	p := New("main.mk", DefaultInterval)
+	in := monkey.New(monkey.WithObserver(p))
+	// Ticks come from the script rather than the clock, so that samples
+	// land where the test expects them
+	in.RegisterBuiltin("tick", func(env *object.Environment, args ...object.Object) object.Object {
+		p.ticks.Add(1)
+		return &object.Null{}
+	})
+	_, err := in.Eval(`let slow = fn() {
+  tick();
+  tick()
+};
+let build = fn(n) {
+  [n, n]
+};
+slow();
+build(1);
+map([1], fn(x) { tick() });`)
+	if err != nil {
+		t.Fatalf("Eval failed: %s", err)
+	}
+
+	var out bytes.Buffer
+	if err := p.Write(&out); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+	gz, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatalf("the profile is not gzipped: %s", err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := decode(t, data)
+
+	table := []string{}
+	for _, s := range profile[6] {
+		table = append(table, string(s.([]byte)))
+	}
+	if len(table) == 0 || table[0] != "" {
+		t.Fatalf("Expected a string table starting with the empty string, got %q", table)
+	}
+	names := []string{}
+	for _, typ := range profile[1] {
+		names = append(names, table[number(decode(t, typ.([]byte))[1])])
+	}
+	if len(names) != 4 || names[1] != "cpu" || names[2] != "alloc_objects" {
+		t.Errorf("Unexpected sample types %q", names)
+	}
+	if table[number(profile[14])] != "cpu" {
+		t.Errorf("Expected cpu to be the default sample type")
+	}
+
+	functions := map[uint64]string{}
+	for _, raw := range profile[5] {
+		f := decode(t, raw.([]byte))
+		functions[number(f[1])] = table[number(f[2])] + "@" + table[number(f[4])]
+	}
+	// Where each location is, as function:line
+	locations := map[uint64]string{}
+	for _, raw := range profile[4] {
+		l := decode(t, raw.([]byte))
+		line := decode(t, l[4][0].([]byte))
+		locations[number(l[1])] = fmt.Sprintf("%s:%d", functions[number(line[1])], number(line[2]))
+	}
+
+	// The stacks charged with ticks and with allocated objects
+	ticks := map[string]uint64{}
+	objects := map[string]uint64{}
+	for _, raw := range profile[2] {
+		s := decode(t, raw.([]byte))
+		stack := ""
+		for _, id := range packed(s[1][0].([]byte)) {
+			stack += locations[id] + " "
+		}
+		values := packed(s[2][0].([]byte))
+		ticks[stack] += values[0]
+		objects[stack] += values[2]
+	}
+
+	expectedTicks := map[string]uint64{
+		"tick@:0 slow@main.mk:2 main@main.mk:8 ":           1,
+		"tick@:0 slow@main.mk:3 main@main.mk:8 ":           1,
+		"tick@:0 fn@10@main.mk:10 map@:0 main@main.mk:10 ": 1,
+	}
+	for stack, n := range expectedTicks {
+		if ticks[stack] != n {
+			t.Errorf("Expected %d ticks for %q, got %d (all %v)", n, stack, ticks[stack], ticks)
+		}
+	}
+	if objects["build@main.mk:6 main@main.mk:9 "] != 1 {
+		t.Errorf("Expected the array to be charged to build, got %v", objects)
+	}
+}
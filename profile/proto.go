@@ -0,0 +1,66 @@
+package profile
+
+import "encoding/binary"
+
+// The wire format of protocol buffers, as much of it as profile.proto
+// needs. Fields are written in order, with repeated integers packed.
+
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+type protoBuffer struct {
+	data []byte
+}
+
+func (b *protoBuffer) tag(field, wire int) {
+	b.data = binary.AppendUvarint(b.data, uint64(field<<3|wire))
+}
+
+// uint64 writes an integer field, leaving it out when it is zero as proto3
+// does
+func (b *protoBuffer) uint64(field int, value uint64) {
+	if value == 0 {
+		return
+	}
+	b.tag(field, wireVarint)
+	b.data = binary.AppendUvarint(b.data, value)
+}
+
+func (b *protoBuffer) int64(field int, value int64) {
+	b.uint64(field, uint64(value))
+}
+
+func (b *protoBuffer) bytes(field int, value []byte) {
+	b.tag(field, wireBytes)
+	b.data = binary.AppendUvarint(b.data, uint64(len(value)))
+	b.data = append(b.data, value...)
+}
+
+func (b *protoBuffer) string(field int, value string) {
+	b.bytes(field, []byte(value))
+}
+
+// message writes a field holding the message that encode writes
+func (b *protoBuffer) message(field int, encode func(b *protoBuffer)) {
+	var inner protoBuffer
+	encode(&inner)
+	b.bytes(field, inner.data)
+}
+
+func (b *protoBuffer) packedUint64s(field int, values []uint64) {
+	var inner []byte
+	for _, value := range values {
+		inner = binary.AppendUvarint(inner, value)
+	}
+	b.bytes(field, inner)
+}
+
+func (b *protoBuffer) packedInt64s(field int, values []int64) {
+	var inner []byte
+	for _, value := range values {
+		inner = binary.AppendUvarint(inner, uint64(value))
+	}
+	b.bytes(field, inner)
+}
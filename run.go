@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/waridh/go-monkey-interpreter/coverage"
+	"github.com/waridh/go-monkey-interpreter/monkey"
+	"github.com/waridh/go-monkey-interpreter/profile"
+	"github.com/waridh/go-monkey-interpreter/trace"
+)
+
+// runCommand evaluates a source file. Scripts can only touch files below the
+// directory given with --allow-fs, and only read the standard input with
+// --stdin. With --trace, every call and return is printed to
+// the standard error, and with --profile, a profile of the run is written
+// for pprof. With --cover, a coverage summary is printed to the standard
+// error and a report written, as an HTML page when it ends in .html and in
+// LCOV format otherwise. With --stats, the memory the run allocated is
+// printed to the standard error.
+func runCommand(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	traced := flags.Bool("trace", false, "print every call and return to standard error")
+	profilePath := flags.String("profile", "", "write a pprof profile of the run to `file`")
+	coverPath := flags.String("cover", "", "write a coverage report of the run to `file`")
+	stats := flags.Bool("stats", false, "print the memory allocated by the run to standard error")
+	allowFS := flags.String("allow-fs", "", "let the script read and write files below `dir`")
+	stdin := flags.Bool("stdin", false, "let the script read the standard input")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey run [--allow-fs dir] [--stdin] [--trace] [--stats] [--profile out.pb.gz] [--cover out.lcov|out.html] file")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	path := flags.Arg(0)
+	var opts []monkey.Option
+	if *allowFS != "" {
+		opts = append(opts, monkey.WithFileSystem(*allowFS))
+	}
+	if *stdin {
+		opts = append(opts, monkey.WithStdin(os.Stdin))
+	}
+	if *traced {
+		opts = append(opts, monkey.WithObserver(trace.New(os.Stderr)))
+	}
+	var profiler *profile.Profiler
+	if *profilePath != "" {
+		profiler = profile.New(path, profile.DefaultInterval)
+		opts = append(opts, monkey.WithObserver(profiler))
+	}
+	var cover *coverage.Coverage
+	if *coverPath != "" {
+		cover = coverage.New(path)
+		opts = append(opts, monkey.WithObserver(cover))
+	}
+	in := monkey.New(opts...)
+
+	if profiler != nil {
+		profiler.Start()
+	}
+	_, err := in.EvalFile(path)
+	status := 0
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		status = 1
+	}
+	// Runs that fail are profiled too
+	if profiler != nil {
+		profiler.Stop()
+		if err := writeProfile(profiler, *profilePath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+		}
+	}
+	if *stats {
+		fmt.Fprintln(os.Stderr, in.MemoryStats())
+	}
+	if cover != nil {
+		cover.WriteSummary(os.Stderr)
+		if err := writeCoverage(cover, *coverPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+func writeProfile(profiler *profile.Profiler, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := profiler.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func writeCoverage(cover *coverage.Coverage, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	write := cover.WriteLCOV
+	if strings.HasSuffix(path, ".html") {
+		write = cover.WriteHTML
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
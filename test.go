@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/waridh/go-monkey-interpreter/monkey"
+	"github.com/waridh/go-monkey-interpreter/test"
+)
+
+// testCommand runs the tests in the _test.mk files found in the given files
+// and directories, or in the current directory, failing if any do not pass.
+// With --junit, the results are also written as JUnit XML. Tests can only
+// touch files below the directory given with --allow-fs.
+func testCommand(args []string) int {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	verbose := flags.Bool("v", false, "list every test, and what passing tests printed")
+	pattern := flags.String("run", "", "only run the tests whose names match `regexp`")
+	junitPath := flags.String("junit", "", "write the results as JUnit XML to `file`")
+	allowFS := flags.String("allow-fs", "", "let tests read and write files below `dir`")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: monkey test [-v] [-run regexp] [-junit out.xml] [-allow-fs dir] [files or directories]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	var filter *regexp.Regexp
+	if *pattern != "" {
+		var err error
+		if filter, err = regexp.Compile(*pattern); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	found, err := test.Discover(paths)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if len(found) == 0 {
+		fmt.Println("no test files")
+		return 0
+	}
+
+	var opts []monkey.Option
+	if *allowFS != "" {
+		opts = append(opts, monkey.WithFileSystem(*allowFS))
+	}
+	status := 0
+	files := make([]*test.File, 0, len(found))
+	for _, path := range found {
+		f := test.Run(path, filter, opts...)
+		f.Write(os.Stdout, *verbose)
+		if !f.Passed() {
+			status = 1
+		}
+		files = append(files, f)
+	}
+	test.WriteSummary(os.Stdout, files)
+
+	if *junitPath != "" {
+		if err := writeJUnit(files, *junitPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = 1
+		}
+	}
+	return status
+}
+
+func writeJUnit(files []*test.File, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := test.WriteJUnit(f, files); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,188 @@
+package test
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/waridh/go-monkey-interpreter/evaluator"
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+// Register adds the assertion builtins to builtins. Each returns null when
+// the assertion holds, and otherwise an error that stops the test:
+//
+//	assert(condition[, message])
+//	assert_eq(actual, expected[, message])
+//	assert_throws(fn[, substring])
+func Register(builtins *object.Builtins) {
+	register(builtins, nil)
+}
+
+// register adds the assertion builtins, which record their failures in t
+// when it is not nil
+func register(builtins *object.Builtins, t *state) {
+	fail := func(format string, args ...any) object.Object {
+		err := &object.Error{Message: fmt.Sprintf(format, args...)}
+		if t != nil {
+			t.fail(err.Message)
+		}
+		return err
+	}
+
+	builtins.Register("assert", func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 1 && len(args) != 2 {
+			return &object.Error{Message: fmt.Sprintf("wrong number of arguments for assert. got=%d, want=1 or 2", len(args))}
+		}
+		if evaluator.IsTruthy(args[0]) {
+			return evaluator.NULL
+		}
+		return fail("assert failed%s", messageOf(args[1:]))
+	})
+
+	builtins.Register("assert_eq", func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 2 && len(args) != 3 {
+			return &object.Error{Message: fmt.Sprintf("wrong number of arguments for assert_eq. got=%d, want=2 or 3", len(args))}
+		}
+		actual, expected := args[0], args[1]
+		if object.Equal(actual, expected) {
+			return evaluator.NULL
+		}
+		if actual.Type() != expected.Type() {
+			return fail("assert_eq failed%s: expected %s %s, got %s %s", messageOf(args[2:]),
+				expected.Type(), describe(expected), actual.Type(), describe(actual))
+		}
+		return fail("assert_eq failed%s (-expected +actual):\n%s", messageOf(args[2:]), Diff(expected, actual))
+	})
+
+	builtins.Register("assert_throws", func(env *object.Environment, args ...object.Object) object.Object {
+		if len(args) != 1 && len(args) != 2 {
+			return &object.Error{Message: fmt.Sprintf("wrong number of arguments for assert_throws. got=%d, want=1 or 2", len(args))}
+		}
+		var substring string
+		if len(args) == 2 {
+			str, ok := args[1].(*object.String)
+			if !ok {
+				return &object.Error{Message: fmt.Sprintf("argument to `assert_throws` must be STRING, got %s", args[1].Type())}
+			}
+			substring = str.Value
+		}
+
+		result := evaluator.Apply(env, args[0], nil)
+		err, ok := result.(*object.Error)
+		if !ok {
+			return fail("assert_throws failed: expected an error, got %s", describe(result))
+		}
+		// Assertions failing within fn are errors it was expected to make
+		if t != nil {
+			t.failure = ""
+		}
+		if !strings.Contains(err.Message, substring) {
+			return fail("assert_throws failed: expected an error containing %q, got %q", substring, err.Message)
+		}
+		return evaluator.NULL
+	})
+}
+
+// describe shows obj on one line, quoting strings so that they cannot be
+// mistaken for other values
+func describe(obj object.Object) string {
+	if str, ok := obj.(*object.String); ok {
+		return strconv.Quote(str.Value)
+	}
+	return object.Describe(obj)
+}
+
+// messageOf formats the optional message given to an assertion
+func messageOf(args []object.Object) string {
+	if len(args) == 0 {
+		return ""
+	}
+	if str, ok := args[0].(*object.String); ok {
+		return ": " + str.Value
+	}
+	return ": " + object.Describe(args[0])
+}
+
+// Diff shows how actual differs from expected, with arrays and hashes laid
+// out one element per line. Lines only in expected start with "- ", lines
+// only in actual with "+ ", and lines in both with two spaces.
+func Diff(expected, actual object.Object) string {
+	a, b := render(expected, ""), render(actual, "")
+
+	// The longest common subsequence of lines, from the ends of a and b
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case j == len(b) || (i < len(a) && common[i+1][j] >= common[i][j+1]):
+			out.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			out.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// render lays out obj as lines, indented by indent. Strings are quoted, and
+// hashes are sorted by key, as their order does not make them unequal.
+func render(obj object.Object, indent string) []string {
+	switch obj := obj.(type) {
+	case *object.String:
+		return []string{indent + strconv.Quote(obj.Value)}
+	case *object.Array:
+		if len(obj.Elements) == 0 {
+			return []string{indent + "[]"}
+		}
+		lines := []string{indent + "["}
+		for _, element := range obj.Elements {
+			lines = append(lines, trailingComma(render(element, indent+"  "))...)
+		}
+		return append(lines, indent+"]")
+	case *object.Hash:
+		if obj.Len() == 0 {
+			return []string{indent + "{}"}
+		}
+		pairs := make([][]string, 0, obj.Len())
+		for _, pair := range obj.Pairs() {
+			value := render(pair.Value, indent+"  ")
+			key := describe(pair.Key)
+			value[0] = indent + "  " + key + ": " + strings.TrimPrefix(value[0], indent+"  ")
+			pairs = append(pairs, trailingComma(value))
+		}
+		sort.SliceStable(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+		lines := []string{indent + "{"}
+		for _, pair := range pairs {
+			lines = append(lines, pair...)
+		}
+		return append(lines, indent+"}")
+	default:
+		return []string{indent + object.Describe(obj)}
+	}
+}
+
+func trailingComma(lines []string) []string {
+	lines[len(lines)-1] += ","
+	return lines
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/monkey"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		expected string
+		actual   string
+		diff     string
+	}{
+		{`1`, `2`, "- 1\n+ 2"},
+		{`"a"`, `"b"`, "- \"a\"\n+ \"b\""},
+		{`[1, 2, 3]`, `[1, 3]`, "  [\n    1,\n-   2,\n    3,\n  ]"},
+		{`[]`, `[[1]]`, "- []\n+ [\n+   [\n+     1,\n+   ],\n+ ]"},
+		{`{"b": 2, "a": 1}`, `{"a": 1, "b": 3}`, "  {\n    \"a\": 1,\n-   \"b\": 2,\n+   \"b\": 3,\n  }"},
+		{`{[1, 2]: "x"}`, `{[1, 2]: "y"}`, "  {\n-   [1, 2]: \"x\",\n+   [1, 2]: \"y\",\n  }"},
+	}
+
+	for _, tt := range tests {
+		in := monkey.New()
+		expected, err := in.Eval(tt.expected)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := in.Eval(tt.actual)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := Diff(expected, actual); diff != tt.diff {
+			t.Errorf("%s against %s - expected\n%s\ngot\n%s", tt.expected, tt.actual, tt.diff, diff)
+		}
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string // The error, if any
+	}{
+		{`assert(true)`, ""},
+		{`assert(1)`, ""},
+		{`assert(1 > 2)`, "assert failed"},
+		{`assert(if (false) { 1 }, "nothing")`, "assert failed: nothing"},
+		{`assert_eq([1, {"a": 2}], [1, {"a": 2}])`, ""},
+		{`assert_eq(1, "1")`, `assert_eq failed: expected STRING "1", got INTEGER 1`},
+		{`assert_eq(1, 2, "sum")`, "assert_eq failed: sum (-expected +actual):\n- 2\n+ 1"},
+		{`assert_throws(fn() { 1 + true })`, ""},
+		{`assert_throws(fn() { 1 + true }, "type mismatch")`, ""},
+		{`assert_throws(fn() { assert(false) })`, ""},
+		{`assert_throws(fn() { 1 })`, "assert_throws failed: expected an error, got 1"},
+		{`assert_throws(fn() { 1 + true }, "nope")`, `assert_throws failed: expected an error containing "nope", got "type mismatch: INTEGER + BOOLEAN"`},
+		{`assert()`, "wrong number of arguments for assert. got=0, want=1 or 2"},
+	}
+
+	for _, tt := range tests {
+		in := monkey.New()
+		Register(in.Builtins())
+		_, err := in.Eval(tt.input)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.expected {
+			t.Errorf("%s - expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestAssertionsInOtherBuiltins(t *testing.T) {
+	in := monkey.New()
+	Register(in.Builtins())
+	_, err := in.Eval(`map([1, 2], fn(x) { assert_eq(x, 1) })`)
+	if err == nil || !strings.HasPrefix(err.Error(), "assert_eq failed") {
+		t.Errorf("Expected assertions in callbacks to fail, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure"`
+	Error     *junitProblem `xml:"error"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit reports how the tests of files went as JUnit XML, with a
+// testsuite for each file. A file that could not be run is reported as a
+// test of its own that errored.
+func WriteJUnit(w io.Writer, files []*File) error {
+	report := junitSuites{}
+	var total float64
+	for _, f := range files {
+		suite := junitSuite{Name: f.Path, Time: seconds(f.Duration.Seconds())}
+		if f.Err != nil {
+			suite.Cases = append(suite.Cases, junitCase{
+				Name:      "load",
+				ClassName: f.Path,
+				Time:      seconds(0),
+				Error:     &junitProblem{Message: firstLine(f.Err.Error()), Text: f.Err.Error()},
+			})
+			suite.Errors++
+		}
+		for _, r := range f.Results {
+			c := junitCase{Name: r.Name, ClassName: f.Path, Line: r.Line, Time: seconds(r.Duration.Seconds()), SystemOut: r.Output}
+			switch r.Status {
+			case Fail:
+				text := r.Message
+				if r.FailedAt != 0 {
+					text = fmt.Sprintf("%s:%d: %s", f.Path, r.FailedAt, r.Message)
+				}
+				c.Failure = &junitProblem{Message: firstLine(r.Message), Type: "assertion", Text: text}
+				suite.Failures++
+			case Error:
+				c.Error = &junitProblem{Message: firstLine(r.Message), Text: r.Message}
+				suite.Errors++
+			}
+			suite.Cases = append(suite.Cases, c)
+		}
+		suite.Tests = len(suite.Cases)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		total += f.Duration.Seconds()
+		report.Suites = append(report.Suites, suite)
+	}
+	report.Time = seconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
@@ -0,0 +1,291 @@
+// Package test runs tests written in Monkey. Test files end in _test.mk,
+// and every function without parameters bound at the top level of one to a
+// name starting with test_ is a test. Each test runs in an interpreter of its
+// own, which evaluates the whole file before calling the test, so that tests
+// cannot see what other tests changed.
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/waridh/go-monkey-interpreter/ast"
+	"github.com/waridh/go-monkey-interpreter/lexer"
+	"github.com/waridh/go-monkey-interpreter/monkey"
+	"github.com/waridh/go-monkey-interpreter/object"
+	"github.com/waridh/go-monkey-interpreter/parser"
+)
+
+// Suffix is how the names of test files end
+const Suffix = "_test.mk"
+
+// Status is how a test went
+type Status string
+
+const (
+	Pass  Status = "PASS"
+	Fail  Status = "FAIL"  // An assertion failed
+	Error Status = "ERROR" // The test stopped with an error of its own
+)
+
+// Result is how one test went
+type Result struct {
+	Name     string
+	Line     int // Where the test function is bound
+	Status   Status
+	Message  string // Why the test failed, if it did
+	FailedAt int    // The line of the statement with the failed assertion
+	Output   string // What the test printed
+	Duration time.Duration
+}
+
+// File is how the tests of one file went. Err is set when the file could not
+// be read or parsed, and none of its tests ran.
+type File struct {
+	Path     string
+	Err      error
+	Results  []Result
+	Duration time.Duration
+}
+
+// Count counts the tests that went as status
+func (f *File) Count(status Status) int {
+	count := 0
+	for _, r := range f.Results {
+		if r.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// Passed is whether the file could be run and all of its tests passed
+func (f *File) Passed() bool {
+	return f.Err == nil && f.Count(Pass) == len(f.Results)
+}
+
+// Discover finds the test files in paths. Directories are searched
+// recursively, skipping hidden ones, and files are taken as they are.
+func Discover(paths []string) ([]string, error) {
+	var files []string
+	seen := map[string]bool{}
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+	for _, root := range paths {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			add(root)
+			continue
+		}
+		var found []string
+		err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !d.IsDir() && strings.HasSuffix(d.Name(), Suffix) {
+				found = append(found, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(found)
+		for _, path := range found {
+			add(path)
+		}
+	}
+	return files, nil
+}
+
+// Run runs the tests in the file at path whose names match filter, or all of
+// them when filter is nil. Tests cannot touch files or read the standard
+// input unless opts allow it, as for any other interpreter.
+func Run(path string, filter *regexp.Regexp, opts ...monkey.Option) *File {
+	f := &File{Path: path}
+	start := time.Now()
+	defer func() { f.Duration = time.Since(start) }()
+
+	src, err := os.ReadFile(path)
+	if err != nil {
+		f.Err = err
+		return f
+	}
+	p := parser.New(lexer.New(string(src)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		f.Err = &monkey.ParseError{File: path, Errors: p.Errors()}
+		return f
+	}
+	for _, let := range Functions(program) {
+		if filter != nil && !filter.MatchString(let.Name.Value) {
+			continue
+		}
+		f.Results = append(f.Results, runTest(path, let.Name.Value, ast.StartOf(let).Line, opts))
+	}
+	return f
+}
+
+// Functions finds the bindings of the tests in program
+func Functions(program *ast.Program) []*ast.LetStatement {
+	var tests []*ast.LetStatement
+	for _, stmt := range program.Statements {
+		let, ok := stmt.(*ast.LetStatement)
+		if !ok || !strings.HasPrefix(let.Name.Value, "test_") {
+			continue
+		}
+		if fn, ok := let.Value.(*ast.FunctionLiteral); ok && len(fn.Parameter) == 0 {
+			tests = append(tests, let)
+		}
+	}
+	return tests
+}
+
+// runTest evaluates the file at path in a new interpreter made with opts,
+// and then calls the test called name
+func runTest(path, name string, line int, opts []monkey.Option) (r Result) {
+	r = Result{Name: name, Line: line, Status: Pass}
+	t := &state{}
+	var output bytes.Buffer
+	in := monkey.New(append([]monkey.Option{
+		monkey.WithStdout(&output),
+		monkey.WithStderr(&output),
+		monkey.WithObserver(t),
+	}, opts...)...)
+	register(in.Builtins(), t)
+
+	start := time.Now()
+	defer func() {
+		// A bug in the interpreter should not stop the other tests
+		if v := recover(); v != nil {
+			r.Status, r.Message = Error, fmt.Sprint("panic: ", v)
+		}
+		r.Duration = time.Since(start)
+		r.Output = output.String()
+	}()
+	_, err := in.EvalFile(path)
+	if err == nil {
+		_, err = in.Eval(name + "()")
+	}
+	switch {
+	case t.failure != "":
+		r.Status, r.Message, r.FailedAt = Fail, t.failure, t.failedAt
+	case err != nil:
+		r.Status, r.Message = Error, err.Error()
+	}
+	return r
+}
+
+// state is an object.Observer following a test, to tell where an assertion
+// failed
+type state struct {
+	lines    []int // The lines of the statements being evaluated, innermost last
+	failure  string
+	failedAt int
+}
+
+func (t *state) fail(message string) {
+	if t.failure != "" {
+		return
+	}
+	t.failure = message
+	if len(t.lines) != 0 {
+		t.failedAt = t.lines[len(t.lines)-1]
+	}
+}
+
+func (t *state) Enter(node ast.Node, env *object.Environment) {
+	if _, ok := node.(*ast.BlockStatement); ok {
+		return
+	}
+	if stmt, ok := node.(ast.Statement); ok {
+		t.lines = append(t.lines, ast.StartOf(stmt).Line)
+	}
+}
+
+func (t *state) Exit(node ast.Node, result object.Object) {
+	if _, ok := node.(*ast.BlockStatement); ok {
+		return
+	}
+	if _, ok := node.(ast.Statement); ok {
+		t.lines = t.lines[:len(t.lines)-1]
+	}
+}
+
+func (t *state) Call(fn object.Object, args []object.Object)                    {}
+func (t *state) Return(fn object.Object, result object.Object)                  {}
+func (t *state) Bind(name string, value object.Object, env *object.Environment) {}
+func (t *state) Error(node ast.Node, err *object.Error)                         {}
+
+// Write reports how the tests of f went, in the style of go test. Tests that
+// passed are only listed when verbose, as is what they printed.
+func (f *File) Write(w io.Writer, verbose bool) {
+	for _, r := range f.Results {
+		if r.Status == Pass && !verbose {
+			continue
+		}
+		fmt.Fprintf(w, "--- %s: %s (%s:%d) (%.3fs)\n", r.Status, r.Name, f.Path, r.Line, r.Duration.Seconds())
+		if r.Message != "" {
+			message := r.Message
+			if r.FailedAt != 0 {
+				message = fmt.Sprintf("line %d: %s", r.FailedAt, message)
+			}
+			writeIndented(w, message)
+		}
+		if r.Output != "" {
+			writeIndented(w, "output:\n"+strings.TrimSuffix(r.Output, "\n"))
+		}
+	}
+
+	switch {
+	case f.Err != nil:
+		fmt.Fprintf(w, "FAIL\t%s\t%s\n", f.Path, f.Err)
+	case f.Passed():
+		fmt.Fprintf(w, "ok\t%s\t%d passed (%.3fs)\n", f.Path, len(f.Results), f.Duration.Seconds())
+	default:
+		fmt.Fprintf(w, "FAIL\t%s\t%d passed, %d failed, %d errored (%.3fs)\n",
+			f.Path, f.Count(Pass), f.Count(Fail), f.Count(Error), f.Duration.Seconds())
+	}
+}
+
+func writeIndented(w io.Writer, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+}
+
+// WriteSummary totals how the tests of files went
+func WriteSummary(w io.Writer, files []*File) {
+	var passed, failed, errored, broken int
+	for _, f := range files {
+		passed += f.Count(Pass)
+		failed += f.Count(Fail)
+		errored += f.Count(Error)
+		if f.Err != nil {
+			broken++
+		}
+	}
+	fmt.Fprintf(w, "%d passed, %d failed, %d errored", passed, failed, errored)
+	if broken != 0 {
+		fmt.Fprintf(w, ", %d files could not be run", broken)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,198 @@
+package test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/monkey"
+)
+
+const mathTests = `import { add } from "lib.mk";
+let seen = [];
+let test_add = fn() {
+  assert_eq(add(1, 2), 3);
+};
+let test_isolated = fn() {
+  let seen = push(seen, 1);
+  assert_eq(len(seen), 1);
+};
+let test_fails = fn() {
+  puts("checking");
+  assert(true);
+  assert_eq(add(1, 1), 3);
+};
+let test_errors = fn() { nope };
+let test_divides_by_zero = fn() { 1 / 0 };
+let test_takes_arguments = fn(x) { x };
+let helper = fn() { assert(false) };
+`
+
+// files writes the files of a project with tests, returning its directory
+func files(t *testing.T) string {
+	dir := t.TempDir()
+	sources := map[string]string{
+		"math_test.mk":           mathTests,
+		"lib.mk":                 "export let add = fn(a, b) { a + b };",
+		"nested/broken_test.mk":  "let = 1;",
+		"nested/empty_test.mk":   "let x = 1;",
+		".hidden/hidden_test.mk": "let test_hidden = fn() { assert(false) };",
+	}
+	for name, src := range sources {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDiscover(t *testing.T) {
+	dir := files(t)
+	found, err := Discover([]string{dir, filepath.Join(dir, "lib.mk"), filepath.Join(dir, "math_test.mk")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		filepath.Join(dir, "math_test.mk"),
+		filepath.Join(dir, "nested", "broken_test.mk"),
+		filepath.Join(dir, "nested", "empty_test.mk"),
+		filepath.Join(dir, "lib.mk"),
+	}
+	if !reflect.DeepEqual(found, expected) {
+		t.Errorf("Expected %q, got %q", expected, found)
+	}
+	if _, err := Discover([]string{filepath.Join(dir, "missing")}); err == nil {
+		t.Errorf("Expected discovering in a missing path to fail")
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := files(t)
+	f := Run(filepath.Join(dir, "math_test.mk"), nil)
+	if f.Err != nil {
+		t.Fatalf("Run failed: %s", f.Err)
+	}
+
+	tests := []struct {
+		name     string
+		line     int
+		status   Status
+		message  string
+		failedAt int
+		output   string
+	}{
+		{"test_add", 3, Pass, "", 0, ""},
+		{"test_isolated", 6, Pass, "", 0, ""},
+		{"test_fails", 10, Fail, "assert_eq failed (-expected +actual):\n- 3\n+ 2", 13, "checking\n"},
+		{"test_errors", 15, Error, "identity not found: nope", 0, ""},
+		{"test_divides_by_zero", 16, Error, "division by zero", 0, ""},
+	}
+	if len(f.Results) != len(tests) {
+		t.Fatalf("Expected %d results, got %+v", len(tests), f.Results)
+	}
+	for i, tt := range tests {
+		r := f.Results[i]
+		if r.Name != tt.name || r.Line != tt.line || r.Status != tt.status || r.Message != tt.message ||
+			r.FailedAt != tt.failedAt || r.Output != tt.output {
+			t.Errorf("%s - expected %+v, got %+v", tt.name, tt, r)
+		}
+	}
+	if f.Passed() || f.Count(Pass) != 2 || f.Count(Fail) != 1 || f.Count(Error) != 2 {
+		t.Errorf("Unexpected counts for %+v", f.Results)
+	}
+
+	f = Run(filepath.Join(dir, "math_test.mk"), regexp.MustCompile("^test_add$"))
+	if len(f.Results) != 1 || !f.Passed() {
+		t.Errorf("Expected only test_add to run, got %+v", f.Results)
+	}
+	if f = Run(filepath.Join(dir, "nested", "broken_test.mk"), nil); f.Err == nil || f.Passed() {
+		t.Errorf("Expected a file with parser errors not to run")
+	}
+}
+
+func TestRunOptions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "io_test.mk")
+	src := `let test_read = fn() { assert_eq(read_file("data.txt"), "data") };`
+	for name, content := range map[string]string{path: src, filepath.Join(dir, "data.txt"): "data"} {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f := Run(path, nil)
+	if len(f.Results) != 1 || f.Results[0].Status != Error ||
+		!strings.Contains(f.Results[0].Message, "the filesystem capability is disabled") {
+		t.Errorf("Expected tests to have no file access by default, got %+v", f.Results)
+	}
+	if f = Run(path, nil, monkey.WithFileSystem(dir)); !f.Passed() {
+		t.Errorf("Expected tests to read files when allowed, got %+v", f.Results)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := files(t)
+	path := filepath.Join(dir, "math_test.mk")
+	f := Run(path, nil)
+
+	var out bytes.Buffer
+	f.Write(&out, false)
+	WriteSummary(&out, []*File{f, Run(filepath.Join(dir, "nested", "broken_test.mk"), nil)})
+	for _, text := range []string{
+		"--- FAIL: test_fails (" + path + ":10)",
+		"    line 13: assert_eq failed (-expected +actual):\n    - 3\n    + 2\n    output:\n    checking\n",
+		"--- ERROR: test_errors (" + path + ":15)",
+		"FAIL\t" + path + "\t2 passed, 1 failed, 2 errored",
+		"2 passed, 1 failed, 2 errored, 1 files could not be run\n",
+	} {
+		if !strings.Contains(out.String(), text) {
+			t.Errorf("Expected the report to contain %q, got\n%s", text, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "test_add") {
+		t.Errorf("Expected passing tests to be left out, got\n%s", out.String())
+	}
+
+	out.Reset()
+	f.Write(&out, true)
+	if !strings.Contains(out.String(), "--- PASS: test_add ("+path+":3)") {
+		t.Errorf("Expected passing tests to be listed when verbose, got\n%s", out.String())
+	}
+}
+
+func TestWriteJUnit(t *testing.T) {
+	dir := files(t)
+	results := []*File{
+		Run(filepath.Join(dir, "math_test.mk"), nil),
+		Run(filepath.Join(dir, "nested", "broken_test.mk"), nil),
+	}
+	var out bytes.Buffer
+	if err := WriteJUnit(&out, results); err != nil {
+		t.Fatalf("WriteJUnit failed: %s", err)
+	}
+
+	var report junitSuites
+	if err := xml.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("invalid XML: %s\n%s", err, out.String())
+	}
+	if report.Tests != 6 || report.Failures != 1 || report.Errors != 3 || len(report.Suites) != 2 {
+		t.Fatalf("Unexpected totals in\n%s", out.String())
+	}
+	failed := report.Suites[0].Cases[2]
+	if failed.Name != "test_fails" || failed.Failure == nil || failed.Failure.Message != "assert_eq failed (-expected +actual):" ||
+		!strings.HasPrefix(failed.Failure.Text, results[0].Path+":13: ") || failed.SystemOut != "checking\n" {
+		t.Errorf("Unexpected test case %+v", failed)
+	}
+	if broken := report.Suites[1].Cases[0]; broken.Error == nil || !strings.Contains(broken.Error.Text, "parser errors") {
+		t.Errorf("Expected the broken file to be reported as an error, got %+v", broken)
+	}
+}
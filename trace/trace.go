@@ -0,0 +1,62 @@
+// Package trace prints the calls a Monkey program makes as it runs, with
+// their arguments and what they return, indented by how deeply they are
+// nested.
+package trace
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/waridh/go-monkey-interpreter/ast"
+	"github.com/waridh/go-monkey-interpreter/object"
+)
+
+// Tracer is an object.Observer writing a line for every call and return
+type Tracer struct {
+	out   io.Writer
+	depth int
+}
+
+func New(out io.Writer) *Tracer {
+	return &Tracer{out: out}
+}
+
+func (t *Tracer) Call(fn object.Object, args []object.Object) {
+	described := make([]string, len(args))
+	for i, arg := range args {
+		described[i] = object.Describe(arg)
+	}
+	fmt.Fprintf(t.out, "%s-> %s(%s)\n", t.indent(), name(fn), strings.Join(described, ", "))
+	t.depth++
+}
+
+func (t *Tracer) Return(fn object.Object, result object.Object) {
+	t.depth--
+	fmt.Fprintf(t.out, "%s<- %s = %s\n", t.indent(), name(fn), object.Describe(result))
+}
+
+// Only calls are traced
+func (t *Tracer) Enter(node ast.Node, env *object.Environment)                   {}
+func (t *Tracer) Exit(node ast.Node, result object.Object)                       {}
+func (t *Tracer) Bind(name string, value object.Object, env *object.Environment) {}
+func (t *Tracer) Error(node ast.Node, err *object.Error)                         {}
+
+func (t *Tracer) indent() string {
+	return strings.Repeat("  ", t.depth)
+}
+
+// name gives the name a function was bound to, or fn for those never bound
+func name(fn object.Object) string {
+	switch fn := fn.(type) {
+	case *object.Function:
+		if fn.Name != "" {
+			return fn.Name
+		}
+	case *object.Builtin:
+		if fn.Name != "" {
+			return fn.Name
+		}
+	}
+	return "fn"
+}
@@ -0,0 +1,42 @@
+package trace
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/waridh/go-monkey-interpreter/monkey"
+)
+
+func TestTracer(t *testing.T) {
+	var out bytes.Buffer
+	in := monkey.New(monkey.WithObserver(New(&out)))
+	_, err := in.Eval(`let fact = fn(n) { if (n < 2) { 1 } else { n * fact(n - 1) } };
+let apply = fn(f, x) { f(x) };
+apply(fact, 3);
+map([1], fn(x) { x + 1 });
+apply(fn(x) { x + true }, 1);`)
+	if err == nil {
+		t.Fatalf("Expected the type mismatch to fail")
+	}
+
+	expected := `-> apply(fn(n), 3)
+  -> fact(3)
+    -> fact(2)
+      -> fact(1)
+      <- fact = 1
+    <- fact = 2
+  <- fact = 6
+<- apply = 6
+-> map([1], fn(x))
+  -> fn(1)
+  <- fn = 2
+<- map = [2]
+-> apply(fn(x), 1)
+  -> fn(1)
+  <- fn = ERROR: type mismatch: INTEGER + BOOLEAN
+<- apply = ERROR: type mismatch: INTEGER + BOOLEAN
+`
+	if out.String() != expected {
+		t.Errorf("Expected the trace\n%s\ngot\n%s", expected, out.String())
+	}
+}